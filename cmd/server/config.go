@@ -0,0 +1,356 @@
+package main
+
+import (
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"worker/internal/infrastructure/secrets"
+	"worker/internal/infrastructure/storage"
+	"worker/internal/infrastructure/video"
+)
+
+// processingConfig agrupa as etapas opcionais do pipeline de processamento
+type processingConfig struct {
+	StreamingEnabled bool   // frames vão do ffmpeg direto para o ZIP, sem gravação em disco
+	StreamUpload     bool   // pacote enviado ao S3 em upload multipart à medida que é escrito, sem arquivo local; SHA-256 e frame-count vão em tags
+	ArchiveFormat    string // formato do pacote de frames: zip, tar, tar.gz ou tar.zst
+	Zip              storage.ZipConfig
+	Extractor        video.ExtractorConfig
+	FrameCount       int               // quantidade fixa de frames por vídeo; 0 usa a taxa de frames
+	Renditions       []video.Rendition // tamanhos gerados por frame; vazio mantém um único arquivo
+
+	ArchiveMaxPartSize int64 // divide o pacote em partes independentes de até esse tamanho em bytes; 0 gera um único arquivo
+
+	OutputKey   storage.OutputKeyConfig   // layout da chave do pacote no bucket e política de colisão
+	DownloadURL storage.DownloadURLConfig // URL HTTPS do pacote enviada no evento de sucesso
+	S3          storage.S3Config          // classe de armazenamento e criptografia dos objetos enviados
+
+	ValidationEnabled bool
+	InputLimits       video.InputLimits
+
+	DedupEnabled     bool
+	DedupMaxDistance int
+
+	QualityEnabled    bool
+	QualityThresholds video.QualityThresholds
+
+	AnalysisEnabled bool // gera analysis.json com trechos pretos, congelados, silenciosos e loudness
+	Analysis        video.AnalysisConfig
+
+	Watermark          *video.Watermark            // nil quando nenhuma marca d'água está configurada
+	WatermarkByDefault bool                        // aplica a marca d'água quando a mensagem não especifica
+	Watermarks         map[string]*video.Watermark // marcas d'água que a mensagem pode escolher pelo nome
+
+	EncryptionByDefault bool   // criptografa o ZIP quando a mensagem não especifica
+	EncryptionSecret    string // segredo para derivar a senha de cada job; nunca registrar em log
+}
+
+// loadProcessingConfig lê as configurações opcionais do pipeline via variáveis de ambiente
+func loadProcessingConfig() processingConfig {
+	return processingConfig{
+		StreamingEnabled: getEnvBool("FRAME_STREAMING_ENABLED", false),
+		StreamUpload:     getEnvBool("ZIP_STREAM_UPLOAD_ENABLED", false),
+		ArchiveFormat:    loadArchiveFormat(),
+		Zip:              loadZipConfig(),
+		FrameCount:       getEnvInt("FRAME_COUNT", 0),
+		Renditions:       loadRenditionsConfig(),
+		Extractor: video.ExtractorConfig{
+			Parallel: video.ParallelConfig{
+				MaxParallel:     getEnvInt("FFMPEG_MAX_PARALLEL", 1),
+				MinDuration:     getEnvFloat("FFMPEG_PARALLEL_MIN_DURATION", video.DefaultParallelMinDuration),
+				SegmentDuration: getEnvFloat("FFMPEG_SEGMENT_DURATION", video.DefaultParallelSegmentDuration),
+			},
+			Sandbox: video.SandboxConfig{
+				Enabled:        getEnvBool("FFMPEG_SANDBOX_ENABLED", true),
+				AllowedFormats: getEnvList("FFMPEG_ALLOWED_FORMATS", video.DefaultAllowedInputFormats),
+				CPUSeconds:     getEnvLimit("FFMPEG_LIMIT_CPU_SECONDS", video.DefaultSandboxCPUSeconds, 0),
+				MemoryBytes:    getEnvLimit("FFMPEG_LIMIT_MEMORY_MB", video.DefaultSandboxMemoryBytes>>20, 20),
+				FileSizeBytes:  getEnvLimit("FFMPEG_LIMIT_FILE_SIZE_MB", video.DefaultSandboxFileBytes>>20, 20),
+				MaxOpenFiles:   getEnvLimit("FFMPEG_LIMIT_OPEN_FILES", video.DefaultSandboxMaxOpenFiles, 0),
+			},
+			FrameLimit: video.FrameLimitConfig{
+				MaxFrames: getEnvInt("FRAME_MAX_PER_JOB", 0),
+				Policy:    getEnv("FRAME_LIMIT_POLICY", video.FrameLimitPolicyError),
+			},
+			Interlace: video.InterlaceConfig{
+				Detect:       getEnvBool("DEINTERLACE_DETECT", true),
+				SampleFrames: getEnvInt("DEINTERLACE_SAMPLE_FRAMES", video.DefaultIdetSampleFrames),
+			},
+		},
+
+		ArchiveMaxPartSize: int64(getEnvInt("ARCHIVE_MAX_PART_SIZE_MB", 0)) << 20,
+
+		OutputKey:   loadOutputKeyConfig(),
+		DownloadURL: loadDownloadURLConfig(),
+		S3:          loadS3Config(),
+
+		ValidationEnabled: getEnvBool("INPUT_VALIDATION_ENABLED", true),
+		InputLimits: video.InputLimits{
+			MaxSizeBytes:  int64(getEnvInt("INPUT_MAX_SIZE_MB", 2048)) * 1024 * 1024,
+			MaxDuration:   getEnvFloat("INPUT_MAX_DURATION", 4*60*60),
+			MaxWidth:      getEnvInt("INPUT_MAX_WIDTH", 7680),
+			MaxHeight:     getEnvInt("INPUT_MAX_HEIGHT", 4320),
+			AllowedCodecs: getEnvList("INPUT_ALLOWED_CODECS", video.DefaultAllowedCodecs),
+		},
+
+		DedupEnabled:     getEnvBool("FRAME_DEDUP_ENABLED", false),
+		DedupMaxDistance: getEnvInt("FRAME_DEDUP_MAX_DISTANCE", video.DefaultDedupMaxDistance),
+
+		QualityEnabled: getEnvBool("FRAME_QUALITY_ENABLED", false),
+		QualityThresholds: video.QualityThresholds{
+			MinLuminance: getEnvFloat("FRAME_QUALITY_MIN_LUMINANCE", video.DefaultMinLuminance),
+			MinVariance:  getEnvFloat("FRAME_QUALITY_MIN_VARIANCE", video.DefaultMinVariance),
+			MinSharpness: getEnvFloat("FRAME_QUALITY_MIN_SHARPNESS", video.DefaultMinSharpness),
+		},
+
+		AnalysisEnabled: getEnvBool("VIDEO_ANALYSIS_ENABLED", false),
+		Analysis: video.AnalysisConfig{
+			BlackMinDuration:   getEnvFloat("ANALYSIS_BLACK_MIN_DURATION", video.DefaultBlackMinDuration),
+			FreezeMinDuration:  getEnvFloat("ANALYSIS_FREEZE_MIN_DURATION", video.DefaultFreezeMinDuration),
+			FreezeNoise:        getEnvFloat("ANALYSIS_FREEZE_NOISE_DB", video.DefaultFreezeNoise),
+			SilenceMinDuration: getEnvFloat("ANALYSIS_SILENCE_MIN_DURATION", video.DefaultSilenceMinDuration),
+			SilenceNoise:       getEnvFloat("ANALYSIS_SILENCE_NOISE_DB", video.DefaultSilenceNoise),
+		},
+
+		Watermark:          loadWatermarkConfig("WATERMARK_"),
+		WatermarkByDefault: getEnvBool("WATERMARK_ENABLED", false),
+		Watermarks:         loadNamedWatermarks(),
+
+		EncryptionByDefault: getEnvBool("ARCHIVE_ENCRYPTION_ENABLED", false),
+		EncryptionSecret:    os.Getenv("ARCHIVE_ENCRYPTION_SECRET"),
+	}
+}
+
+// loadWatermarkConfig lê a marca d'água das variáveis com o prefixo informado
+// (ex.: WATERMARK_IMAGE). A imagem aceita um caminho local ou uma URI
+// s3://bucket/chave, resolvida na inicialização do worker.
+func loadWatermarkConfig(prefix string) *video.Watermark {
+	image := strings.TrimSpace(os.Getenv(prefix + "IMAGE"))
+	text := os.Getenv(prefix + "TEXT")
+	if image == "" && text == "" {
+		return nil
+	}
+
+	return &video.Watermark{
+		ImagePath: image,
+		Text:      text,
+		FontFile:  os.Getenv(prefix + "FONT_FILE"),
+		Position:  getEnv(prefix+"POSITION", video.DefaultWatermarkPosition),
+		Opacity:   getEnvFloat(prefix+"OPACITY", video.DefaultWatermarkOpacity),
+		Scale:     getEnvFloat(prefix+"SCALE", video.DefaultWatermarkScale),
+		Margin:    getEnvInt(prefix+"MARGIN", video.DefaultWatermarkMargin),
+	}
+}
+
+var watermarkName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// loadNamedWatermarks lê a lista de marcas d'água selecionáveis pela
+// mensagem: WATERMARK_NAMES=logo,confidencial e, para cada nome, as
+// variáveis WATERMARK_LOGO_IMAGE, WATERMARK_LOGO_TEXT etc. Nomes inválidos
+// ou sem imagem nem texto são ignorados, o que os mantém fora da lista.
+func loadNamedWatermarks() map[string]*video.Watermark {
+	watermarks := make(map[string]*video.Watermark)
+	for _, name := range getEnvList("WATERMARK_NAMES", nil) {
+		name = strings.ToLower(name)
+		if !watermarkName.MatchString(name) {
+			log.Printf("⚠️ Nome de marca d'água inválido em WATERMARK_NAMES: %q", name)
+			continue
+		}
+		prefix := "WATERMARK_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		watermark := loadWatermarkConfig(prefix)
+		if watermark == nil {
+			log.Printf("⚠️ Marca d'água %q sem %sIMAGE ou %sTEXT, ignorada", name, prefix, prefix)
+			continue
+		}
+		watermark.Name = name
+		watermarks[name] = watermark
+	}
+	return watermarks
+}
+
+// loadSecretsConfig lê os prefixos de segredos que as mensagens podem
+// referenciar em password_ref (ex.: SECRETS_ALLOWED_PREFIXES=zip/). Sem
+// prefixos, todas as referências são recusadas.
+func loadSecretsConfig() secrets.SecretsConfig {
+	return secrets.SecretsConfig{AllowedPrefixes: getEnvList("SECRETS_ALLOWED_PREFIXES", nil)}
+}
+
+// loadArchiveFormat lê o formato padrão do pacote de frames de ARCHIVE_FORMAT
+func loadArchiveFormat() string {
+	value := getEnv("ARCHIVE_FORMAT", storage.ArchiveFormatZip)
+	format, err := storage.ParseArchiveFormat(value)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para ARCHIVE_FORMAT (%q), usando padrão %s", value, storage.ArchiveFormatZip)
+		return storage.ArchiveFormatZip
+	}
+	return format
+}
+
+// loadZipConfig lê a compressão das entradas do ZIP e o modo reprodutível
+// (que vale para todos os formatos). Compressões inválidas são substituídas
+// pelo padrão (seleção automática por entrada).
+func loadZipConfig() storage.ZipConfig {
+	config := storage.ZipConfig{
+		Compression: getEnv("ZIP_COMPRESSION", storage.ZipCompressionAuto),
+		Level:       getEnvInt("ZIP_COMPRESSION_LEVEL", storage.DefaultZipCompressionLevel),
+		MinSavings:  getEnvFloat("ZIP_COMPRESSION_MIN_SAVINGS", storage.DefaultZipMinSavings),
+		Parallel:    getEnvInt("ZIP_COMPRESSION_PARALLEL", 1),
+
+		Reproducible: getEnvBool("ARCHIVE_REPRODUCIBLE", false),
+	}
+	if err := config.Validate(); err != nil {
+		log.Printf("⚠️ Compressão do ZIP inválida (%v), usando padrão", err)
+		defaults := storage.DefaultZipConfig()
+		defaults.Reproducible = config.Reproducible
+		return defaults
+	}
+	return config
+}
+
+// loadOutputKeyConfig lê o template da chave de saída e a política de
+// colisão. Sem OUTPUT_KEY_TEMPLATE a chave é a do layout original;
+// configurações inválidas também voltam a ele.
+func loadOutputKeyConfig() storage.OutputKeyConfig {
+	config := storage.OutputKeyConfig{
+		Template:  getEnv("OUTPUT_KEY_TEMPLATE", ""),
+		Collision: strings.ToLower(getEnv("OUTPUT_KEY_COLLISION", storage.OutputCollisionOverwrite)),
+	}
+	if err := config.Validate(); err != nil {
+		log.Printf("⚠️ Chave de saída inválida (%v), usando padrão %s", err, storage.DefaultOutputKeyTemplate)
+		return storage.DefaultOutputKeyConfig()
+	}
+	return config
+}
+
+// loadDownloadURLConfig lê a validade das URLs pré-assinadas e o template
+// opcional de URL pública ou de CDN. Valores inválidos são substituídos pela
+// URL pré-assinada com a validade padrão.
+func loadDownloadURLConfig() storage.DownloadURLConfig {
+	config := storage.DownloadURLConfig{
+		Expiry:  time.Duration(getEnvInt("DOWNLOAD_URL_EXPIRY_SECONDS", int(storage.DefaultDownloadURLExpiry/time.Second))) * time.Second,
+		BaseURL: strings.TrimSpace(os.Getenv("DOWNLOAD_URL_BASE")),
+	}
+	if err := config.Validate(); err != nil {
+		log.Printf("⚠️ URL de download inválida (%v), usando URL pré-assinada com validade de %s", err, storage.DefaultDownloadURLExpiry)
+		return storage.DownloadURLConfig{Expiry: storage.DefaultDownloadURLExpiry}
+	}
+	return config
+}
+
+// loadS3Config lê a classe de armazenamento e a criptografia dos objetos
+// enviados. Configurações inválidas são ignoradas, mantendo os padrões do
+// bucket.
+func loadS3Config() storage.S3Config {
+	config := storage.S3Config{
+		StorageClass:         strings.ToUpper(strings.TrimSpace(os.Getenv("S3_STORAGE_CLASS"))),
+		ServerSideEncryption: strings.TrimSpace(os.Getenv("S3_SERVER_SIDE_ENCRYPTION")),
+		KMSKeyID:             strings.TrimSpace(os.Getenv("S3_SSE_KMS_KEY_ID")),
+	}
+	if err := config.Validate(); err != nil {
+		log.Printf("⚠️ Configuração de objetos do S3 inválida (%v), usando padrões do bucket", err)
+		return storage.S3Config{}
+	}
+	return config
+}
+
+// loadRenditionsConfig lê as rendições padrão de FRAME_RENDITIONS
+// (ex.: "thumb:320,medium:960,original"). Valores inválidos são ignorados.
+func loadRenditionsConfig() []video.Rendition {
+	value := strings.TrimSpace(os.Getenv("FRAME_RENDITIONS"))
+	if value == "" {
+		return nil
+	}
+	renditions, err := video.ParseRenditions(value)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para FRAME_RENDITIONS (%q): %v; gerando um único tamanho", value, err)
+		return nil
+	}
+	return renditions
+}
+
+// getEnv lê uma variável de texto, usando o padrão se ausente
+func getEnv(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvBool lê uma variável booleana, usando o padrão se ausente ou inválida
+func getEnvBool(key string, fallback bool) bool {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para %s (%q), usando padrão %v", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvInt lê uma variável inteira, usando o padrão se ausente ou inválida
+func getEnvInt(key string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para %s (%q), usando padrão %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvLimit lê um limite de recurso não negativo, multiplicado por 2^shift
+// (ex.: shift 20 para valores em MB). Valores negativos, inválidos ou que
+// estourariam após a multiplicação usam o padrão, já que um uint64 negativo
+// viraria um limite praticamente infinito.
+func getEnvLimit(key string, fallback uint64, shift uint) uint64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback << shift
+	}
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || parsed > math.MaxUint64>>shift {
+		log.Printf("⚠️ Valor inválido para %s (%q), usando padrão %d", key, value, fallback)
+		return fallback << shift
+	}
+	return parsed << shift
+}
+
+// getEnvList lê uma lista separada por vírgulas, usando o padrão se ausente
+func getEnvList(key string, fallback []string) []string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvFloat lê uma variável decimal, usando o padrão se ausente ou inválida
+func getEnvFloat(key string, fallback float64) float64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para %s (%q), usando padrão %v", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestGetEnvLimit(t *testing.T) {
+	tests := []struct {
+		value    string
+		shift    uint
+		expected uint64
+	}{
+		{"", 20, 64 << 20},
+		{"128", 20, 128 << 20},
+		{"0", 0, 0},
+		// Negativos e valores que estouram após o deslocamento usam o padrão
+		{"-1", 0, 64},
+		{"-1", 20, 64 << 20},
+		{"17592186044416", 20, 64 << 20}, // 2^44 MB = 2^64 bytes
+		{"17592186044415", 20, 17592186044415 << 20},
+		{"abc", 0, 64},
+	}
+	for _, tt := range tests {
+		t.Setenv("FFMPEG_LIMIT_TESTE", tt.value)
+		if limit := getEnvLimit("FFMPEG_LIMIT_TESTE", 64, tt.shift); limit != tt.expected {
+			t.Errorf("%q << %d: esperado %d, mas obteve %d", tt.value, tt.shift, tt.expected, limit)
+		}
+	}
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"errors"
 	"log"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 	"worker/internal/infrastructure/notification"
 	"worker/internal/infrastructure/queue"
+	"worker/internal/infrastructure/secrets"
 	"worker/internal/infrastructure/storage"
 	"worker/internal/infrastructure/video"
 )
@@ -29,6 +37,9 @@ func getUserEmail(msg *queue.VideoMessage, defaultEmail string) string {
 }
 
 func main() {
+	// Processo auxiliar do sandbox: aplica os limites e executa o ffmpeg
+	video.RunSandboxHelper()
+
 	// Identificação do worker
 	workerID := os.Getenv("WORKER_ID")
 	if workerID == "" {
@@ -97,17 +108,86 @@ func main() {
 	log.Printf("- Kafka Topic: %s", kafkaTopic)
 	log.Printf("- LocalStack: %s", localstackEndpoint)
 
+	processing := loadProcessingConfig()
+	log.Printf("- Validação de entrada: %v (limites: %+v)", processing.ValidationEnabled, processing.InputLimits)
+	log.Printf("- Extração em streaming: %v", processing.StreamingEnabled)
+	log.Printf("- Formato do arquivo de frames: %s (upload em streaming: %v)", processing.ArchiveFormat, processing.StreamUpload)
+	if processing.ArchiveMaxPartSize > 0 {
+		log.Printf("- Pacote de frames dividido em partes de até %d MB", processing.ArchiveMaxPartSize>>20)
+	}
+	log.Printf("- Compressão do ZIP: %s (nível %d, até %d entrada(s) em paralelo, limitado a %d núcleo(s))",
+		processing.Zip.Compression, processing.Zip.Level, max(processing.Zip.Parallel, 1), runtime.NumCPU())
+	log.Printf("- Arquivo de frames reprodutível: %v", processing.Zip.Reproducible)
+	outputKeyTemplate := processing.OutputKey.Template
+	if outputKeyTemplate == "" {
+		outputKeyTemplate = storage.DefaultOutputKeyTemplate + " (original)"
+	}
+	log.Printf("- Chave de saída: %s (colisão: %s)", outputKeyTemplate, processing.OutputKey.Collision)
+	log.Printf("- Objetos no S3: classe %q, criptografia %q (padrões do bucket quando vazios)", processing.S3.StorageClass, processing.S3.ServerSideEncryption)
+	if processing.DownloadURL.BaseURL != "" {
+		log.Printf("- URL de download: pública (%s)", processing.DownloadURL.BaseURL)
+	} else {
+		log.Printf("- URL de download: pré-assinada (validade de %s)", processing.DownloadURL.Expiry)
+	}
+	log.Printf("- Criptografia do ZIP (AES-256): padrão %v (segredo de derivação configurado: %v)", processing.EncryptionByDefault, processing.EncryptionSecret != "")
+	if processing.EncryptionByDefault && processing.EncryptionSecret == "" {
+		log.Printf("⚠️ ARCHIVE_ENCRYPTION_SECRET ausente: jobs sem password_ref falharão com a criptografia ativa")
+	}
+	log.Printf("- Detecção de entrelaçamento: %v", processing.Extractor.Interlace.Detect)
+	log.Printf("- Sandbox do ffmpeg: %v (limites: %+v)", processing.Extractor.Sandbox.Enabled, processing.Extractor.Sandbox)
+	log.Printf("- Extração paralela: até %d processos ffmpeg (segmentos de %.0fs em vídeos com %.0fs ou mais)",
+		processing.Extractor.Parallel.MaxParallel, processing.Extractor.Parallel.SegmentDuration, processing.Extractor.Parallel.MinDuration)
+	if processing.Extractor.FrameLimit.MaxFrames > 0 {
+		log.Printf("- Limite de frames por job: %d (política: %s)", processing.Extractor.FrameLimit.MaxFrames, processing.Extractor.FrameLimit.Policy)
+	}
+	if processing.StreamingEnabled && processing.Extractor.Parallel.MaxParallel > 1 {
+		log.Printf("⚠️ Extração paralela por segmentos não é usada no modo streaming; os frames saem de um único ffmpeg")
+	}
+	if processing.StreamingEnabled && (processing.DedupEnabled || processing.QualityEnabled) {
+		log.Printf("⚠️ Deduplicação e análise de qualidade operam sobre frames em disco e são ignoradas no modo streaming (registrado em skipped_filters no manifesto)")
+	}
+	log.Printf("- Deduplicação de frames: %v (distância máxima: %d)", processing.DedupEnabled, processing.DedupMaxDistance)
+	log.Printf("- Análise de qualidade: %v (limites: %+v)", processing.QualityEnabled, processing.QualityThresholds)
+	log.Printf("- Análise do vídeo: %v (limites: %+v)", processing.AnalysisEnabled, processing.Analysis)
+
 	// Inicializar serviços
-	s3Service, err := storage.NewS3Service(bucketName)
+	s3Service, err := storage.NewS3ServiceWithConfig(bucketName, processing.S3)
 	if err != nil {
 		log.Fatalf("Erro ao inicializar S3: %v", err)
 	}
 
+	// Resolver a imagem da marca d'água (local ou S3) uma única vez
+	if processing.Watermark != nil {
+		if err := prepareWatermark(processing.Watermark, s3Service, "temp/worker-"+workerID); err != nil {
+			log.Fatalf("Erro ao configurar marca d'água: %v", err)
+		}
+		log.Printf("- Marca d'água: %s (padrão: %v)", processing.Watermark.Position, processing.WatermarkByDefault)
+	}
+	for name, watermark := range processing.Watermarks {
+		if err := prepareWatermark(watermark, s3Service, "temp/worker-"+workerID); err != nil {
+			log.Fatalf("Erro ao configurar marca d'água %s: %v", name, err)
+		}
+		log.Printf("- Marca d'água selecionável: %s (%s)", name, watermark.Position)
+	}
+
 	sqsService, err := queue.NewSQSService(queueURL)
 	if err != nil {
 		log.Fatalf("Erro ao inicializar SQS: %v", err)
 	}
 
+	// Secrets Manager para as senhas referenciadas nas mensagens (opcional),
+	// restrito aos prefixos configurados
+	secretsConfig := loadSecretsConfig()
+	secretsService, err := secrets.NewSecretsServiceWithConfig(secretsConfig)
+	if err != nil {
+		log.Printf("⚠️ Secrets Manager não disponível - mensagens com password_ref falharão: %v", err)
+		secretsService = nil
+	} else if len(secretsConfig.AllowedPrefixes) == 0 {
+		log.Printf("⚠️ SECRETS_ALLOWED_PREFIXES ausente - mensagens com password_ref serão recusadas")
+	} else {
+		log.Printf("- Segredos permitidos em password_ref: %s", strings.Join(secretsConfig.AllowedPrefixes, ", "))
+	}
+
 	// Inicializar serviço de notificação Kafka (opcional)
 	var notificationService *notification.NotificationService
 	brokers := []string{kafkaBrokers}
@@ -121,20 +201,696 @@ func main() {
 		defer notificationService.Close()
 	}
 
-	extractor := video.NewFFmpegExtractor()
-	zipService := storage.NewZipService()
+	extractor := video.NewFFmpegExtractorWithConfig(processing.Extractor)
+	archiveService := storage.NewArchiveServiceWithConfig(processing.Zip)
 
 	log.Printf("✅ %s iniciado - monitorando fila SQS...", workerName)
 
 	for {
-		processMessages(s3Service, sqsService, extractor, zipService, notificationService, defaultUserEmail, workerID, workerName)
+		processMessages(s3Service, sqsService, extractor, archiveService, secretsService, notificationService, processing, defaultUserEmail, workerID, workerName)
 		time.Sleep(5 * time.Second) // Verifica a cada 5 segundos
 	}
 }
 
+// prepareWatermark valida a marca d'água e baixa a imagem para disco quando ela está no S3
+func prepareWatermark(watermark *video.Watermark, s3Service *storage.S3Service, tempDir string) error {
+	if bucket, key, ok := storage.ParseS3URI(watermark.ImagePath); ok {
+		localPath := filepath.Join(tempDir, "watermark"+filepath.Ext(key))
+		if watermark.Name != "" {
+			localPath = filepath.Join(tempDir, "watermark-"+watermark.Name+filepath.Ext(key))
+		}
+		if err := s3Service.DownloadObject(bucket, key, localPath); err != nil {
+			return err
+		}
+		watermark.ImagePath = localPath
+	}
+
+	if watermark.ImagePath != "" {
+		if _, err := os.Stat(watermark.ImagePath); err != nil {
+			return err
+		}
+	}
+	return watermark.Validate()
+}
+
+// jobExtractOptions monta as opções de extração do job combinando a
+// configuração do worker com as preferências da mensagem
+func jobExtractOptions(msg *queue.VideoMessage, processing processingConfig) (video.ExtractOptions, error) {
+	var opts video.ExtractOptions
+
+	// Quantidade fixa de frames: a mensagem tem precedência sobre a configuração
+	opts.FrameCount = processing.FrameCount
+	if msg.FrameCount > 0 {
+		opts.FrameCount = msg.FrameCount
+	}
+	if opts.FrameCount > 0 {
+		opts.TrimStart = msg.TrimStart
+		opts.TrimEnd = msg.TrimEnd
+	}
+
+	// Marca d'água: a mensagem pode escolher uma das configuradas pelo nome,
+	// o que a aplica a menos que watermark seja false
+	watermark, applyWatermark := processing.Watermark, processing.WatermarkByDefault
+	if msg.WatermarkName != "" {
+		named, ok := processing.Watermarks[msg.WatermarkName]
+		if !ok {
+			return opts, errors.New("marca d'água não permitida: " + msg.WatermarkName)
+		}
+		watermark, applyWatermark = named, true
+	}
+	if msg.Watermark != nil {
+		applyWatermark = *msg.Watermark
+	}
+	if applyWatermark && watermark != nil {
+		opts.Watermark = watermark
+	}
+
+	opts.Deinterlace = msg.Deinterlace
+
+	// Rendições: a lista da mensagem substitui a configuração do worker
+	opts.Renditions = processing.Renditions
+	if len(msg.Renditions) > 0 {
+		renditions, err := video.ParseRenditionList(msg.Renditions)
+		if err != nil {
+			return opts, err
+		}
+		opts.Renditions = renditions
+	}
+
+	// Trechos e instantes pedidos na mensagem
+	for _, r := range msg.Ranges {
+		start, err := video.ParseTimestamp(r.Start)
+		if err != nil {
+			return opts, err
+		}
+		end, err := video.ParseTimestamp(r.End)
+		if err != nil {
+			return opts, err
+		}
+		opts.Ranges = append(opts.Ranges, video.TimeRange{Start: start, End: end})
+	}
+	for _, value := range msg.Timestamps {
+		timestamp, err := video.ParseTimestamp(value)
+		if err != nil {
+			return opts, err
+		}
+		opts.Timestamps = append(opts.Timestamps, timestamp)
+	}
+
+	return opts, nil
+}
+
+// jobArchivePassword define a senha do pacote do job: a do segredo referenciado
+// na mensagem (password_ref), se estiver nos prefixos permitidos, ou, com a criptografia ativa, a derivada do
+// segredo do worker e do ID do vídeo. Retorna vazio sem criptografia.
+func jobArchivePassword(msg *queue.VideoMessage, processing processingConfig, secretsService *secrets.SecretsService) (string, error) {
+	if msg.PasswordRef != "" {
+		if secretsService == nil {
+			return "", errors.New("Secrets Manager não disponível")
+		}
+		return secretsService.GetSecret(msg.PasswordRef)
+	}
+
+	encrypt := processing.EncryptionByDefault
+	if msg.Encrypt != nil {
+		encrypt = *msg.Encrypt
+	}
+	if !encrypt {
+		return "", nil
+	}
+	if processing.EncryptionSecret == "" {
+		return "", errors.New("ARCHIVE_ENCRYPTION_SECRET não configurado")
+	}
+	return storage.DeriveArchivePassword(processing.EncryptionSecret, msg.IDVideo), nil
+}
+
+// extractionFailureReason diferencia violações dos limites do sandbox de
+// falhas comuns do ffmpeg na notificação de erro
+func extractionFailureReason(err error) string {
+	var limitErr *video.ResourceLimitError
+	if errors.As(err, &limitErr) {
+		return "Vídeo excedeu os limites de processamento"
+	}
+	var frameLimitErr *video.FrameLimitError
+	if errors.As(err, &frameLimitErr) {
+		return "Vídeo excedeu o limite de frames por job"
+	}
+	return "Erro ao extrair frames"
+}
+
+// transferFailureReason destaca na notificação de erro as falhas de
+// integridade na transferência com o S3, usando fallback para as demais
+func transferFailureReason(err error, fallback string) string {
+	var integrityErr *storage.IntegrityError
+	if errors.As(err, &integrityErr) {
+		return "Falha de integridade na transferência com o S3"
+	}
+	return fallback
+}
+
+// newJobManifest cria o manifesto do job com a descrição do vídeo de origem
+// e as configurações aplicadas na extração
+func newJobManifest(msg *queue.VideoMessage, info *video.VideoInfo, videoPath string,
+	opts video.ExtractOptions, processing processingConfig) *video.Manifest {
+
+	manifest := video.NewManifest()
+	manifest.Source = &video.ManifestSource{Key: msg.VideoKey, Info: info}
+	if stat, err := os.Stat(videoPath); err == nil {
+		manifest.Source.Size = stat.Size()
+	}
+
+	settings := video.NewManifestSettings(opts)
+	settings.Streaming = processing.StreamingEnabled
+	switch {
+	case processing.DedupEnabled && processing.StreamingEnabled:
+		settings.SkippedFilters = append(settings.SkippedFilters, video.FilterDedup)
+	case processing.DedupEnabled:
+		maxDistance := processing.DedupMaxDistance
+		settings.DedupMaxDistance = &maxDistance
+	}
+	switch {
+	case processing.QualityEnabled && processing.StreamingEnabled:
+		settings.SkippedFilters = append(settings.SkippedFilters, video.FilterQuality)
+	case processing.QualityEnabled:
+		thresholds := processing.QualityThresholds
+		settings.QualityThresholds = &thresholds
+	}
+	manifest.Settings = settings
+	return manifest
+}
+
+// archiveFile é um arquivo gerado em memória e incluído no pacote ao lado dos
+// frames, registrado nos anexos do manifesto
+type archiveFile struct {
+	Name string
+	Data []byte
+}
+
+// analyzeVideo executa a análise do vídeo de origem e a registra no
+// manifesto. A análise é informativa: falhas são registradas no log e o
+// processamento segue sem o relatório.
+func analyzeVideo(videoPath string, info *video.VideoInfo, processing processingConfig,
+	manifest *video.Manifest, workerName string) (*video.AnalysisReport, []archiveFile) {
+
+	report, err := video.NewVideoAnalyzer(processing.Analysis, processing.Extractor.Sandbox).Analyze(videoPath, info)
+	if err != nil {
+		log.Printf("⚠️ %s - Erro na análise do vídeo, seguindo sem %s: %v", workerName, video.AnalysisFileName, err)
+		return nil, nil
+	}
+	data, err := report.Marshal()
+	if err != nil {
+		log.Printf("⚠️ %s - Erro ao serializar %s: %v", workerName, video.AnalysisFileName, err)
+		return nil, nil
+	}
+
+	manifest.AddAttachment(video.AnalysisFileName, data)
+	return report, []archiveFile{{Name: video.AnalysisFileName, Data: data}}
+}
+
+// analysisSummary resume o relatório de análise para o evento de sucesso
+func analysisSummary(report *video.AnalysisReport) *notification.AnalysisSummary {
+	if report == nil {
+		return nil
+	}
+
+	summary := &notification.AnalysisSummary{
+		Findings:      report.Findings,
+		BlackSeconds:  video.TotalDuration(report.BlackSegments),
+		FrozenSeconds: video.TotalDuration(report.FrozenSegments),
+		SilentSeconds: video.TotalDuration(report.SilentSegments),
+	}
+	if report.Loudness != nil {
+		summary.IntegratedLoudness = report.Loudness.Integrated
+		summary.TruePeak = report.Loudness.TruePeak
+	}
+	return summary
+}
+
+// interlaceSummary resume a decisão de desentrelaçamento para o evento de sucesso
+func interlaceSummary(settings *video.ManifestSettings) *notification.InterlaceSummary {
+	if settings == nil || settings.Interlace == nil {
+		return nil
+	}
+	return &notification.InterlaceSummary{
+		Deinterlaced: settings.Interlace.Deinterlace,
+		Source:       settings.Interlace.Source,
+	}
+}
+
+// streamFramesToArchive extrai os frames via pipe do ffmpeg gravando cada um
+// diretamente no pacote, seguido dos anexos, do manifesto e das somas SHA-256.
+// As rendições de um frame são agrupadas para ficarem na mesma parte quando o
+// pacote é dividido. Retorna a quantidade de frames; o pacote é finalizado
+// por quem chamou.
+func streamFramesToArchive(extractor video.FrameExtractor, output *jobOutput,
+	videoPath string, opts video.ExtractOptions, manifest *video.Manifest, attachments []archiveFile) (int, error) {
+
+	var pending []archiveFile
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		var size int64
+		for _, file := range pending {
+			size += int64(len(file.Data))
+		}
+		if err := output.startFrame(len(pending), size); err != nil {
+			return err
+		}
+		for _, file := range pending {
+			if err := output.stream.AddEntry(file.Name, file.Data); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	count, err := extractor.StreamFrames(videoPath, opts, func(frame video.Frame, data []byte) error {
+		if err := manifest.AddFrameData(frame, data); err != nil {
+			return err
+		}
+		// Cada frame começa pela primeira rendição (ou é o próprio arquivo)
+		if frame.Rendition == "" || frame.Rendition == opts.Renditions[0].Name {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		pending = append(pending, archiveFile{Name: frame.Path, Data: data})
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return count, err
+	}
+	for _, attachment := range attachments {
+		if err := output.stream.AddEntry(attachment.Name, attachment.Data); err != nil {
+			return count, err
+		}
+	}
+
+	manifestData, err := manifest.Marshal()
+	if err != nil {
+		return count, err
+	}
+	if err := output.stream.AddEntry(video.ManifestFileName, manifestData); err != nil {
+		return count, err
+	}
+	return count, output.stream.AddEntry(video.ChecksumsFileName, manifest.Checksums(manifestData))
+}
+
+// writeManifestFiles grava os anexos, o manifesto e o arquivo SHA256SUMS no
+// diretório informado e retorna os caminhos gerados
+func writeManifestFiles(manifest *video.Manifest, dir string, attachments []archiveFile) ([]string, error) {
+	var paths []string
+	for _, attachment := range attachments {
+		path := filepath.Join(dir, attachment.Name)
+		if err := os.WriteFile(path, attachment.Data, 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	manifestData, err := manifest.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	manifestPath := filepath.Join(dir, video.ManifestFileName)
+	if err := os.WriteFile(manifestPath, manifestData, 0644); err != nil {
+		return nil, err
+	}
+	checksumsPath := filepath.Join(dir, video.ChecksumsFileName)
+	if err := os.WriteFile(checksumsPath, manifest.Checksums(manifestData), 0644); err != nil {
+		return nil, err
+	}
+	return append(paths, manifestPath, checksumsPath), nil
+}
+
+// writeFramesArchive adiciona ao pacote os frames extraídos, cada rendição em sua
+// subpasta, seguidos dos arquivos do manifesto
+func writeFramesArchive(output *jobOutput, frames []video.Frame, manifestFiles []string) error {
+	for _, frame := range frames {
+		files := frame.Renditions
+		if len(files) == 0 {
+			files = []video.RenditionFile{{Path: frame.Path}}
+		}
+
+		var size int64
+		for _, file := range files {
+			info, err := os.Stat(file.Path)
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		if err := output.startFrame(len(files), size); err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			name := filepath.Base(file.Path)
+			if file.Name != "" {
+				name = video.RenditionEntryName(file.Name, file.Path)
+			}
+			if err := output.stream.AddFileAs(file.Path, name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, file := range manifestFiles {
+		if err := output.stream.AddFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jobArchive é um arquivo do pacote de frames (ZIP ou TAR): um arquivo local
+// enviado ao S3 após ser concluído ou, com upload em streaming, o próprio
+// upload multipart
+type jobArchive struct {
+	stream    storage.ArchiveWriter
+	upload    *storage.S3UploadWriter // nil no modo baseado em arquivo
+	localPath string
+	key       string
+	options   storage.UploadOptions
+	frames    int   // frames no arquivo, gravado nos metadados do objeto (tag no streaming)
+	size      int64 // tamanho enviado ao S3, conhecido após finish
+	sent      bool  // envio concluído ou tentado: o objeto pode existir no S3 mesmo se finish falhou
+}
+
+// openJobArchive cria o arquivo no formato e destino configurados
+func openJobArchive(s3Service *storage.S3Service, archiveService storage.ArchiveService,
+	format, localPath, key string, streamUpload bool, options storage.UploadOptions) (*jobArchive, error) {
+
+	if streamUpload {
+		upload := s3Service.NewUploadWriter(key, options)
+		stream, err := archiveService.NewArchiveTo(format, upload)
+		if err != nil {
+			upload.Abort(err)
+			return nil, err
+		}
+		return &jobArchive{stream: stream, upload: upload, key: key, options: options}, nil
+	}
+
+	stream, err := archiveService.NewArchive(format, localPath)
+	if err != nil {
+		return nil, err
+	}
+	return &jobArchive{stream: stream, localPath: localPath, key: key, options: options}, nil
+}
+
+// finish finaliza o arquivo e conclui o envio para o S3
+func (a *jobArchive) finish(s3Service *storage.S3Service) error {
+	if err := a.stream.Close(); err != nil {
+		a.abort(err)
+		return err
+	}
+	a.sent = true
+	frames := strconv.Itoa(a.frames)
+	if a.upload != nil {
+		a.upload.SetTag("frame-count", frames)
+		if err := a.upload.Close(); err != nil {
+			return err
+		}
+		a.size = a.upload.Size()
+		return nil
+	}
+
+	info, err := os.Stat(a.localPath)
+	if err != nil {
+		return err
+	}
+	a.size = info.Size()
+	options := a.options
+	options.Metadata = maps.Clone(a.options.Metadata)
+	options.Metadata["frame-count"] = frames
+	return s3Service.UploadArchive(a.localPath, a.key, options)
+}
+
+// abort descarta o arquivo incompleto; no upload em streaming, o upload
+// multipart é abortado e nenhum objeto é criado no S3
+func (a *jobArchive) abort(err error) {
+	if a.upload != nil {
+		a.upload.Abort(err)
+		return
+	}
+	a.stream.Close()
+}
+
+// jobOutput é o destino do pacote de frames do job: um único arquivo ou, com
+// tamanho máximo de parte, uma sequência de arquivos independentes
+// (video_frames.part001.zip, ...) enviados ao S3 à medida que são concluídos
+type jobOutput struct {
+	stream storage.ArchiveWriter // recebe as entradas: o arquivo único ou o SplitArchive
+
+	s3Service      *storage.S3Service
+	archiveService storage.ArchiveService
+	format         string
+	localPath      string
+	key            string
+	streamUpload   bool
+	options        storage.UploadOptions // tipo, nome, metadados e tags dos objetos
+
+	single   *jobArchive           // nil com divisão em partes
+	split    *storage.SplitArchive // nil sem divisão em partes
+	archives []*jobArchive         // partes abertas, a última é a atual
+	parts    []notification.ArchivePart
+	frame    int // número do último frame adicionado, a partir de 1
+}
+
+// openJobOutput cria o destino do pacote. Sem divisão o arquivo é criado
+// imediatamente; com maxPartSize > 0 as partes são criadas sob demanda.
+func openJobOutput(s3Service *storage.S3Service, archiveService storage.ArchiveService,
+	format, localPath, key string, streamUpload bool, maxPartSize int64, options storage.UploadOptions) (*jobOutput, error) {
+
+	output := &jobOutput{
+		s3Service:      s3Service,
+		archiveService: archiveService,
+		format:         format,
+		localPath:      localPath,
+		key:            key,
+		streamUpload:   streamUpload,
+		options:        options,
+	}
+	if maxPartSize > 0 {
+		output.split = storage.NewSplitArchive(maxPartSize, output.openPart)
+		output.stream = output.split
+		return output, nil
+	}
+
+	archive, err := openJobArchive(s3Service, archiveService, format, localPath, key, streamUpload, options)
+	if err != nil {
+		return nil, err
+	}
+	output.single = archive
+	output.stream = archive.stream
+	return output, nil
+}
+
+// openPart cria a parte de número informado para o SplitArchive
+func (o *jobOutput) openPart(part int) (storage.ArchiveWriter, error) {
+	options := o.options
+	options.Filename = storage.ArchivePartName(o.options.Filename, o.format, part)
+	options.Metadata = maps.Clone(o.options.Metadata)
+	options.Metadata["part"] = strconv.Itoa(part)
+
+	archive, err := openJobArchive(o.s3Service, o.archiveService, o.format,
+		storage.ArchivePartName(o.localPath, o.format, part), storage.ArchivePartName(o.key, o.format, part), o.streamUpload, options)
+	if err != nil {
+		return nil, err
+	}
+	o.archives = append(o.archives, archive)
+	o.parts = append(o.parts, notification.ArchivePart{Key: archive.key})
+	return &jobPartWriter{ArchiveWriter: archive.stream, output: o, archive: archive, index: len(o.parts) - 1}, nil
+}
+
+// startFrame reserva espaço para os arquivos do próximo frame, mantendo-os
+// na mesma parte, e registra o frame no intervalo da parte
+func (o *jobOutput) startFrame(files int, size int64) error {
+	o.frame++
+	if o.split == nil {
+		return nil
+	}
+	if err := o.split.Fit(files, size); err != nil {
+		return err
+	}
+
+	part := &o.parts[len(o.parts)-1]
+	if part.FirstFrame == 0 {
+		part.FirstFrame = o.frame
+	}
+	part.LastFrame = o.frame
+	return nil
+}
+
+// finish conclui o pacote (ou a última parte) e o envio para o S3
+func (o *jobOutput) finish() error {
+	if o.split == nil {
+		o.single.frames = o.frame
+		return o.single.finish(o.s3Service)
+	}
+	if err := o.split.Close(); err != nil {
+		o.abort(err)
+		return err
+	}
+	// O manifesto e as somas são as últimas entradas do pacote
+	if len(o.parts) > 0 {
+		o.parts[len(o.parts)-1].Manifest = true
+	}
+	return nil
+}
+
+// abort descarta o pacote incompleto, removendo do S3 toda parte cujo envio
+// foi iniciado, inclusive a que falhou
+func (o *jobOutput) abort(err error) {
+	if o.split == nil {
+		o.single.abort(err)
+		return
+	}
+
+	for _, archive := range o.archives {
+		if !archive.sent {
+			// Parte atual, ainda não concluída
+			archive.abort(err)
+			os.Remove(archive.localPath)
+			continue
+		}
+		// Uma parte cujo envio falhou também pode ter chegado ao S3
+		if deleteErr := o.s3Service.DeleteObject(archive.key); deleteErr != nil {
+			log.Printf("⚠️ Erro ao remover parte %s do S3: %v", archive.key, deleteErr)
+		}
+	}
+}
+
+// keys retorna as chaves dos objetos enviados ao S3
+func (o *jobOutput) keys() []string {
+	if o.split == nil {
+		return []string{o.key}
+	}
+	keys := make([]string, 0, len(o.parts))
+	for _, part := range o.parts {
+		keys = append(keys, part.Key)
+	}
+	return keys
+}
+
+// jobUploadOptions descreve os objetos do pacote no S3: nome amigável para
+// download a partir do título, metadados que identificam o job (a
+// quantidade de frames é acrescentada ao concluir cada arquivo) e tags para
+// regras de ciclo de vida
+func jobUploadOptions(msg *queue.VideoMessage, settings *video.ManifestSettings, format, workerName string, encrypted bool) storage.UploadOptions {
+	return storage.UploadOptions{
+		ContentType: storage.ArchiveContentType(format),
+		Filename:    friendlyArchiveName(msg, format),
+		Metadata: map[string]string{
+			"video-id":        msg.IDVideo,
+			"user":            msg.Username,
+			"worker":          workerName,
+			"settings-sha256": settings.Hash(),
+		},
+		Tags: map[string]string{
+			"artifact":  "frames",
+			"format":    format,
+			"encrypted": strconv.FormatBool(encrypted),
+			"user":      msg.Username,
+		},
+	}
+}
+
+// friendlyArchiveName monta o nome sugerido para download a partir do
+// título do vídeo (ou do nome do arquivo de origem), sem separadores de
+// caminho nem caracteres de controle
+func friendlyArchiveName(msg *queue.VideoMessage, format string) string {
+	name := strings.TrimSpace(msg.Titulo)
+	if name == "" {
+		base := filepath.Base(msg.VideoKey)
+		name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, name)
+	return name + "_frames" + storage.ArchiveExtension(format)
+}
+
+// jobOutputKey monta a chave do pacote a partir do template com os dados da
+// mensagem e aplica a política de colisão. Com divisão em partes, a chave
+// também é considerada ocupada quando já existe a sua primeira parte.
+func jobOutputKey(msg *queue.VideoMessage, s3Service *storage.S3Service, processing processingConfig, format string) (string, error) {
+	basename := filepath.Base(msg.VideoKey)
+	values := map[string]string{
+		"id_video": msg.IDVideo,
+		"id":       strconv.Itoa(msg.ID),
+		"username": msg.Username,
+		"autor":    msg.Autor,
+		"basename": strings.TrimSuffix(basename, filepath.Ext(basename)),
+	}
+	key := processing.OutputKey.Render(values, format)
+
+	return storage.ResolveOutputKey(key, processing.OutputKey.Collision, func(candidate string) (bool, error) {
+		found, err := s3Service.ObjectExists(candidate)
+		if err != nil || found || processing.ArchiveMaxPartSize <= 0 {
+			return found, err
+		}
+		return s3Service.ObjectExists(storage.ArchivePartName(candidate, format, 1))
+	})
+}
+
+// jobDownloadLinks gera a URI e a URL de download do pacote (a primeira
+// parte, quando dividido) e de cada parte. Falhas apenas deixam o evento sem
+// a URL HTTPS, sem falhar o job já enviado.
+func jobDownloadLinks(s3Service *storage.S3Service, output *jobOutput, config storage.DownloadURLConfig) storage.DownloadLink {
+	keys := output.keys()
+	link, err := s3Service.DownloadLink(keys[0], config)
+	if err != nil {
+		log.Printf("⚠️ Erro ao gerar URL de download para %s: %v", keys[0], err)
+	}
+	for i := range output.parts {
+		partLink, err := s3Service.DownloadLink(output.parts[i].Key, config)
+		if err != nil {
+			log.Printf("⚠️ Erro ao gerar URL de download para %s: %v", output.parts[i].Key, err)
+			continue
+		}
+		output.parts[i].URL = partLink.URL
+	}
+	return link
+}
+
+// jobPartWriter é uma parte do SplitArchive: Close conclui a parte, a envia
+// ao S3 e remove o arquivo local, liberando o disco antes da próxima
+type jobPartWriter struct {
+	storage.ArchiveWriter
+	output  *jobOutput
+	archive *jobArchive
+	index   int // posição da parte em output.parts
+}
+
+func (p *jobPartWriter) Close() error {
+	if part := p.output.parts[p.index]; part.FirstFrame > 0 {
+		p.archive.frames = part.LastFrame - part.FirstFrame + 1
+	}
+	err := p.archive.finish(p.output.s3Service)
+	if p.archive.localPath != "" {
+		os.Remove(p.archive.localPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.output.parts[p.index].Size = p.archive.size
+	log.Printf("📤 Parte do pacote de frames enviada: %s (%d bytes)", p.archive.key, p.archive.size)
+	return nil
+}
+
 func processMessages(s3Service *storage.S3Service, sqsService *queue.SQSService,
-	extractor video.FrameExtractor, zipService storage.ZipService, notificationService *notification.NotificationService,
-	defaultUserEmail, workerID, workerName string) {
+	extractor video.FrameExtractor, archiveService storage.ArchiveService, secretsService *secrets.SecretsService,
+	notificationService *notification.NotificationService, processing processingConfig, defaultUserEmail, workerID, workerName string) {
 
 	messages, err := sqsService.ReceiveMessages()
 	if err != nil {
@@ -154,18 +910,28 @@ func processMessages(s3Service *storage.S3Service, sqsService *queue.SQSService,
 		}
 		log.Printf("🔒 %s - Mensagem reservada para processamento: %s", workerName, msg.VideoKey)
 
-		if err := processVideoMessage(msg, s3Service, sqsService, extractor, zipService, notificationService, defaultUserEmail, workerID, workerName); err != nil {
+		if err := processVideoMessage(msg, s3Service, sqsService, extractor, archiveService, secretsService, notificationService, processing, defaultUserEmail, workerID, workerName); err != nil {
 			log.Printf("🔴 %s - Erro ao processar mensagem %s: %v", workerName, msg.VideoKey, err)
 		}
 	}
 }
 
 func processVideoMessage(msg *queue.VideoMessage, s3Service *storage.S3Service,
-	sqsService *queue.SQSService, extractor video.FrameExtractor, zipService storage.ZipService,
-	notificationService *notification.NotificationService, defaultUserEmail, workerID, workerName string) error {
+	sqsService *queue.SQSService, extractor video.FrameExtractor, archiveService storage.ArchiveService,
+	secretsService *secrets.SecretsService, notificationService *notification.NotificationService, processing processingConfig,
+	defaultUserEmail, workerID, workerName string) error {
 
 	log.Printf("🎬 %s - Processando vídeo: %s", workerName, msg.VideoKey)
 
+	// Registra o erro e envia a notificação de falha do processamento
+	fail := func(reason string, err error) error {
+		log.Printf("🔴 %s - %s: %v", workerName, reason, err)
+		sendNotificationIfAvailable(notificationService, func() error {
+			return notificationService.SendProcessingFailed(msg.IDVideo, msg.Titulo, reason+": "+err.Error(), msg.Autor, getUserEmail(msg, defaultUserEmail))
+		})
+		return err
+	}
+
 	// Criar caminhos únicos para evitar conflitos entre workers
 	workerTempDir := filepath.Join("temp", "worker-"+workerID)
 	workerOutputDir := filepath.Join("outputs", "worker-"+workerID)
@@ -174,71 +940,230 @@ func processVideoMessage(msg *queue.VideoMessage, s3Service *storage.S3Service,
 	os.MkdirAll(workerTempDir, 0755)
 	os.MkdirAll(workerOutputDir, 0755)
 
+	// Interpretar as opções do job antes de baixar o vídeo
+	opts, err := jobExtractOptions(msg, processing)
+	if err != nil {
+		return fail("Parâmetros de extração inválidos", err)
+	}
+	archiveFormat := processing.ArchiveFormat
+	if msg.ArchiveFormat != "" {
+		if archiveFormat, err = storage.ParseArchiveFormat(msg.ArchiveFormat); err != nil {
+			return fail("Parâmetros de extração inválidos", err)
+		}
+	}
+
+	// Criptografia do ZIP com a senha do job (que nunca vai para o log)
+	password, err := jobArchivePassword(msg, processing, secretsService)
+	if err != nil {
+		return fail("Erro ao obter a senha do arquivo de frames", err)
+	}
+	if password != "" {
+		if archiveFormat != storage.ArchiveFormatZip {
+			return fail("Parâmetros de extração inválidos", errors.New("criptografia disponível apenas no formato zip"))
+		}
+		zipConfig := processing.Zip
+		zipConfig.Password = password
+		archiveService = storage.NewArchiveServiceWithConfig(zipConfig)
+		log.Printf("🔐 %s - Arquivo de frames será criptografado com AES-256", workerName)
+	}
+
+	// Rejeitar vídeos acima do tamanho máximo antes do download
+	var validator *video.InputValidator
+	if processing.ValidationEnabled {
+		validator = video.NewInputValidatorWithSandbox(processing.InputLimits, processing.Extractor.Sandbox)
+
+		size, err := s3Service.ObjectSize(msg.VideoKey)
+		if err != nil {
+			return fail("Erro ao consultar vídeo no S3", err)
+		}
+		if err := validator.ValidateSize(size); err != nil {
+			return fail("Vídeo rejeitado", err)
+		}
+	}
+
 	// Baixar vídeo do S3 em diretório específico do worker
 	localVideoPath := filepath.Join(workerTempDir, filepath.Base(msg.VideoKey))
 	if err := s3Service.DownloadVideo(msg.VideoKey, localVideoPath); err != nil {
-		log.Printf("🔴 %s - Erro ao baixar vídeo do S3: %v", workerName, err)
-		sendNotificationIfAvailable(notificationService, func() error {
-			return notificationService.SendProcessingFailed(msg.IDVideo, msg.Titulo, "Erro ao baixar vídeo do S3: "+err.Error(), msg.Autor, getUserEmail(msg, defaultUserEmail))
-		})
-		return err
+		return fail(transferFailureReason(err, "Erro ao baixar vídeo do S3"), err)
 	}
 	defer os.Remove(localVideoPath)
 
+	// Validar contêiner, codec, duração e resolução antes de processar
+	var videoInfo *video.VideoInfo
+	if validator != nil {
+		info, err := validator.Validate(localVideoPath)
+		var validationErr *video.ValidationError
+		if errors.As(err, &validationErr) {
+			if validationErr.Err != nil {
+				log.Printf("🔍 %s - Causa da rejeição: %v", workerName, validationErr.Err)
+			}
+			return fail("Vídeo rejeitado", err)
+		}
+		if err != nil {
+			return fail(extractionFailureReason(err), err)
+		}
+		videoInfo = info
+	} else if info, err := video.ProbeVideoWithSandbox(localVideoPath, processing.Extractor.Sandbox); err == nil {
+		// Sem validação os metadados servem apenas ao manifesto
+		videoInfo = info
+	}
+
+	// Calcular a quantidade de frames e aplicar o limite por job
+	planned, err := extractor.Plan(localVideoPath, opts)
+	if err != nil {
+		return fail(extractionFailureReason(err), err)
+	}
+	if planned.FrameRate() < opts.FrameRate() || planned.FrameCount < opts.FrameCount {
+		log.Printf("📉 %s - Extração reduzida para respeitar o limite de frames (%.3f fps, quantidade %d)", workerName, planned.FrameRate(), planned.FrameCount)
+	}
+	opts = planned
+	if interlace := opts.Interlace(); interlace != nil && interlace.Deinterlace {
+		log.Printf("🎞️ %s - Desentrelaçando frames (decisão: %s)", workerName, interlace.Source)
+	}
+	if correction := opts.Correction(); correction != nil {
+		log.Printf("🔄 %s - Corrigindo orientação/proporção dos frames: %v", workerName, correction.Filters)
+	}
+
 	// Criar diretório temporário para frames específico do worker
 	framesDir := filepath.Join(workerTempDir, "frames_"+msg.VideoID)
 	os.MkdirAll(framesDir, 0755)
 	defer os.RemoveAll(framesDir)
 
-	// Extrair frames
-	log.Printf("⚙️ %s - Extraindo frames do vídeo: %s", workerName, msg.VideoKey)
-	frames, err := extractor.ExtractFrames(localVideoPath, framesDir)
+	// Pacote de frames em diretório específico do worker
+	s3ArchiveKey, err := jobOutputKey(msg, s3Service, processing, archiveFormat)
 	if err != nil {
-		log.Printf("🔴 %s - Erro ao extrair frames: %v", workerName, err)
-		sendNotificationIfAvailable(notificationService, func() error {
-			return notificationService.SendProcessingFailed(msg.IDVideo, msg.Titulo, "Erro ao extrair frames: "+err.Error(), msg.Autor, getUserEmail(msg, defaultUserEmail))
-		})
-		return err
+		if errors.Is(err, storage.ErrOutputKeyExists) {
+			return fail("Pacote de frames já existe no destino", err)
+		}
+		return fail("Erro ao verificar a chave de saída no S3", err)
+	}
+	archiveName := path.Base(s3ArchiveKey)
+	localArchivePath := filepath.Join(workerOutputDir, archiveName)
+	defer os.Remove(localArchivePath)
+
+	if opts.Watermark != nil {
+		log.Printf("💧 %s - Aplicando marca d'água aos frames", workerName)
+	}
+	switch opts.Mode() {
+	case video.ExtractionModeCount:
+		log.Printf("🎯 %s - Extraindo %d frames distribuídos pelo vídeo", workerName, opts.FrameCount)
+	case video.ExtractionModeRange:
+		log.Printf("🎯 %s - Extraindo frames de %d trecho(s) do vídeo", workerName, len(opts.Ranges))
+	case video.ExtractionModeTimestamps:
+		log.Printf("🎯 %s - Extraindo frames em %d instante(s) do vídeo", workerName, len(opts.Timestamps))
+	}
+	manifest := newJobManifest(msg, videoInfo, localVideoPath, opts, processing)
+	uploadOptions := jobUploadOptions(msg, manifest.Settings, archiveFormat, workerName, password != "")
+
+	// Analisar trechos pretos, congelados e silenciosos e o loudness (opcional)
+	var analysis *video.AnalysisReport
+	var attachments []archiveFile
+	if processing.AnalysisEnabled {
+		log.Printf("🩺 %s - Analisando vídeo: %s", workerName, msg.VideoKey)
+		analysis, attachments = analyzeVideo(localVideoPath, videoInfo, processing, manifest, workerName)
+		if analysis != nil && len(analysis.Findings) > 0 {
+			log.Printf("🩺 %s - Problemas encontrados no vídeo: %v", workerName, analysis.Findings)
+		}
+	}
+
+	var frameCount int
+	var output *jobOutput
+	if processing.StreamingEnabled {
+		// Frames vão direto do ffmpeg para o pacote, sem passar pelo disco
+		log.Printf("⚙️ %s - Extraindo frames em streaming para o arquivo %s: %s", workerName, archiveName, msg.VideoKey)
+		output, err = openJobOutput(s3Service, archiveService, archiveFormat, localArchivePath, s3ArchiveKey, processing.StreamUpload, processing.ArchiveMaxPartSize, uploadOptions)
+		if err != nil {
+			return fail("Erro ao criar arquivo de frames", err)
+		}
+		count, err := streamFramesToArchive(extractor, output, localVideoPath, opts, manifest, attachments)
+		if err != nil {
+			output.abort(err)
+			return fail(transferFailureReason(err, extractionFailureReason(err)), err)
+		}
+		frameCount = count
+	} else {
+		// Extrair frames
+		log.Printf("⚙️ %s - Extraindo frames do vídeo: %s", workerName, msg.VideoKey)
+		frames, err := extractor.ExtractFramesWithOptions(localVideoPath, framesDir, opts)
+		if err != nil {
+			return fail(extractionFailureReason(err), err)
+		}
+		paths := video.FramePaths(frames)
+
+		// Descartar frames pretos, em branco ou borrados (opcional)
+		var qualityResult *video.QualityResult
+		if processing.QualityEnabled {
+			qualityResult, err = video.NewFrameQualityAnalyzer(processing.QualityThresholds).Filter(paths)
+			if err != nil {
+				return fail("Erro ao analisar qualidade dos frames", err)
+			}
+			log.Printf("🔍 %s - Descartados %d frames de baixa qualidade (%d mantidos)", workerName, len(qualityResult.Rejected), len(qualityResult.Frames))
+			paths = qualityResult.Frames
+		}
+
+		// Remover frames quase idênticos (opcional)
+		if processing.DedupEnabled {
+			result, err := video.NewFrameDeduplicator(processing.DedupMaxDistance).Filter(paths)
+			if err != nil {
+				return fail("Erro ao remover frames duplicados", err)
+			}
+			log.Printf("🧹 %s - Removidos %d frames quase idênticos (%d mantidos)", workerName, result.Removed, len(result.Frames))
+			paths = result.Frames
+			manifest.ApplyDedup(result)
+		}
+
+		frames = video.RetainFrames(frames, paths)
+		frameCount = len(frames)
+		if frameCount > 0 {
+			// Gerar manifesto e somas SHA-256 dos frames incluídos no pacote
+			for _, frame := range frames {
+				if err := manifest.AddFrame(frame); err != nil {
+					return fail("Erro ao gerar manifesto", err)
+				}
+			}
+			manifest.ApplyQuality(qualityResult)
+			manifestFiles, err := writeManifestFiles(manifest, framesDir, attachments)
+			if err != nil {
+				return fail("Erro ao gerar manifesto", err)
+			}
+
+			log.Printf("📦 %s - Criando arquivo de frames: %s", workerName, archiveName)
+			output, err = openJobOutput(s3Service, archiveService, archiveFormat, localArchivePath, s3ArchiveKey, processing.StreamUpload, processing.ArchiveMaxPartSize, uploadOptions)
+			if err != nil {
+				return fail("Erro ao criar arquivo de frames", err)
+			}
+			if err := writeFramesArchive(output, frames, manifestFiles); err != nil {
+				output.abort(err)
+				return fail(transferFailureReason(err, "Erro ao criar arquivo de frames"), err)
+			}
+		}
 	}
 
-	if len(frames) == 0 {
+	if frameCount == 0 {
 		log.Printf("⚠️ %s - Nenhum frame extraído para %s", workerName, msg.VideoKey)
+		if output != nil {
+			output.abort(errors.New("nenhum frame extraído"))
+		}
 		sendNotificationIfAvailable(notificationService, func() error {
 			return notificationService.SendProcessingFailed(msg.IDVideo, msg.Titulo, "Nenhum frame foi extraído do vídeo", msg.Autor, getUserEmail(msg, defaultUserEmail))
 		})
 		return nil
 	}
 
-	log.Printf("✅ %s - Extraídos %d frames do vídeo: %s", workerName, len(frames), msg.VideoKey)
+	log.Printf("✅ %s - Extraídos %d frames do vídeo: %s", workerName, frameCount, msg.VideoKey)
 
-	// Criar ZIP em diretório específico do worker
-	zipName := filepath.Base(msg.VideoKey)
-	zipName = zipName[:len(zipName)-len(filepath.Ext(zipName))] + "_frames.zip"
-	localZipPath := filepath.Join(workerOutputDir, zipName)
-
-	log.Printf("📦 %s - Criando arquivo ZIP: %s", workerName, zipName)
-	if err := zipService.CreateZipFile(frames, localZipPath); err != nil {
-		log.Printf("🔴 %s - Erro ao criar arquivo ZIP: %v", workerName, err)
-		sendNotificationIfAvailable(notificationService, func() error {
-			return notificationService.SendProcessingFailed(msg.IDVideo, msg.Titulo, "Erro ao criar arquivo ZIP: "+err.Error(), msg.Autor, getUserEmail(msg, defaultUserEmail))
-		})
-		return err
+	// Upload do pacote para S3 (no modo em streaming, apenas conclui o upload multipart)
+	log.Printf("📤 %s - Fazendo upload do arquivo de frames para S3: %s", workerName, s3ArchiveKey)
+	if err := output.finish(); err != nil {
+		return fail(transferFailureReason(err, "Erro ao fazer upload do arquivo de frames"), err)
 	}
-	defer os.Remove(localZipPath)
-
-	// Upload ZIP para S3
-	s3ZipKey := "processed/" + zipName
-	log.Printf("📤 %s - Fazendo upload do ZIP para S3: %s", workerName, s3ZipKey)
-	if err := s3Service.UploadZip(localZipPath, s3ZipKey); err != nil {
-		log.Printf("🔴 %s - Erro ao fazer upload do ZIP: %v", workerName, err)
-		sendNotificationIfAvailable(notificationService, func() error {
-			return notificationService.SendProcessingFailed(msg.IDVideo, msg.Titulo, "Erro ao fazer upload do ZIP: "+err.Error(), msg.Autor, getUserEmail(msg, defaultUserEmail))
-		})
-		return err
+	keys := output.keys()
+	if len(keys) > 1 {
+		log.Printf("🧩 %s - Pacote de frames dividido em %d partes", workerName, len(keys))
 	}
 
-	// Enviar notificação de sucesso
-	videoURL := "s3://video-service-bucket/" + s3ZipKey // URL do arquivo processado
+	// Enviar notificação de sucesso; com partes, a URL aponta para a primeira
+	link := jobDownloadLinks(s3Service, output, processing.DownloadURL)
 	log.Printf("📢 %s - Enviando notificação de sucesso para: %s", workerName, msg.VideoKey)
 	sendNotificationIfAvailable(notificationService, func() error {
 		// Usar msg.Email da fila SQS, com fallback para defaultUserEmail se vazio
@@ -246,9 +1171,17 @@ func processVideoMessage(msg *queue.VideoMessage, s3Service *storage.S3Service,
 		if userEmail == "" {
 			userEmail = defaultUserEmail
 		}
-		return notificationService.SendProcessingCompleted(msg.IDVideo, msg.Titulo, videoURL, msg.Autor, userEmail)
+		return notificationService.SendProcessingCompleted(msg.IDVideo, msg.Titulo, link.URI, msg.Autor, userEmail, notification.CompletedDetails{
+			Analysis:     analysisSummary(analysis),
+			Interlace:    interlaceSummary(manifest.Settings),
+			Parts:        output.parts,
+			Encrypted:    password != "",
+			DedupRemoved: manifest.DedupRemoved,
+			DownloadURL:  link.URL,
+			ExpiresAt:    link.ExpiresAt,
+		})
 	})
 
-	log.Printf("🎉 %s - Processamento concluído: %s -> %s", workerName, msg.VideoKey, s3ZipKey)
+	log.Printf("🎉 %s - Processamento concluído: %s -> %s", workerName, msg.VideoKey, strings.Join(keys, ", "))
 	return nil
 }
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"worker/internal/infrastructure/storage"
+	"worker/internal/infrastructure/video"
+)
+
+const testBucket = "bucket-teste"
+
+// partsS3 simula o S3 para o envio das partes: grava os objetos, responde ao
+// HeadObject da conferência e registra as remoções. PUTs em chaves de failKeys
+// falham após receber o corpo.
+type partsS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	metadata map[string]http.Header
+	deleted  []string
+	failKeys []string
+}
+
+func (f *partsS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/"+testBucket+"/")
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if slices.Contains(f.failKeys, key) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<Error><Code>InvalidRequest</Code><Message>falha simulada</Message></Error>`))
+			return
+		}
+		sum := md5.Sum(data)
+		if expected := r.Header.Get("Content-Md5"); expected != "" && expected != base64.StdEncoding.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<Error><Code>BadDigest</Code><Message>MD5 divergente</Message></Error>`))
+			return
+		}
+		metadata := http.Header{}
+		for name, values := range r.Header {
+			if strings.HasPrefix(name, "X-Amz-Meta-") {
+				metadata[name] = values
+			}
+		}
+		f.objects[key] = data
+		f.metadata[key] = metadata
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	case http.MethodHead:
+		data, found := f.objects[key]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for name, values := range f.metadata[key] {
+			w.Header()[name] = values
+		}
+		sum := md5.Sum(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	case http.MethodDelete:
+		delete(f.objects, key)
+		f.deleted = append(f.deleted, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newTestJobOutput cria um pacote dividido em partes de até maxPartSize
+// bytes, enviado a um partsS3
+func newTestJobOutput(t *testing.T, maxPartSize int64, failKeys ...string) (*jobOutput, *partsS3, string) {
+	t.Helper()
+	fake := &partsS3{objects: map[string][]byte{}, metadata: map[string]http.Header{}, failKeys: failKeys}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	t.Setenv("LOCALSTACK_ENDPOINT", server.URL)
+
+	s3Service, err := storage.NewS3Service(testBucket)
+	if err != nil {
+		t.Fatalf("erro ao criar serviço S3: %v", err)
+	}
+	dir := t.TempDir()
+	options := storage.UploadOptions{
+		ContentType: storage.ArchiveContentType(storage.ArchiveFormatZip),
+		Filename:    "video_frames.zip",
+		Metadata:    map[string]string{"video-id": "abc"},
+	}
+	output, err := openJobOutput(s3Service, storage.NewArchiveService(), storage.ArchiveFormatZip,
+		filepath.Join(dir, "video_frames.zip"), "processed/video_frames.zip", false, maxPartSize, options)
+	if err != nil {
+		t.Fatalf("erro ao criar pacote: %v", err)
+	}
+	return output, fake, dir
+}
+
+// writeTestFrames cria count frames de size bytes e o arquivo do manifesto
+func writeTestFrames(t *testing.T, count, size int) ([]video.Frame, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	frames := make([]video.Frame, count)
+	for i := range frames {
+		path := filepath.Join(dir, fmt.Sprintf("frame_%04d.png", i+1))
+		if err := os.WriteFile(path, []byte(strings.Repeat(strconv.Itoa(i%10), size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		frames[i] = video.Frame{Path: path}
+	}
+	manifestPath := filepath.Join(dir, video.ManifestFileName)
+	if err := os.WriteFile(manifestPath, []byte(`{"frames":5}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return frames, []string{manifestPath}
+}
+
+func TestJobOutputParts(t *testing.T) {
+	// Com 400 bytes por frame e 4000 por parte, cabem dois frames em cada
+	output, fake, dir := newTestJobOutput(t, 4000)
+	frames, manifestFiles := writeTestFrames(t, 5, 400)
+
+	if err := writeFramesArchive(output, frames, manifestFiles); err != nil {
+		t.Fatalf("erro inesperado ao gravar frames: %v", err)
+	}
+	if err := output.finish(); err != nil {
+		t.Fatalf("erro inesperado ao concluir pacote: %v", err)
+	}
+
+	expected := [][2]int{{1, 2}, {3, 4}, {5, 5}}
+	if len(output.parts) != len(expected) {
+		t.Fatalf("esperado %d partes, mas obteve %+v", len(expected), output.parts)
+	}
+	for i, part := range output.parts {
+		if part.FirstFrame != expected[i][0] || part.LastFrame != expected[i][1] {
+			t.Errorf("parte %d: esperado frames %v, mas obteve %d-%d", i+1, expected[i], part.FirstFrame, part.LastFrame)
+		}
+		if part.Manifest != (i == len(output.parts)-1) {
+			t.Errorf("parte %d: Manifest inesperado %v", i+1, part.Manifest)
+		}
+		key := storage.ArchivePartName("processed/video_frames.zip", storage.ArchiveFormatZip, i+1)
+		if part.Key != key {
+			t.Errorf("parte %d: esperado chave %s, mas obteve %s", i+1, key, part.Key)
+		}
+		if data, found := fake.objects[key]; !found || part.Size != int64(len(data)) || part.Size == 0 {
+			t.Errorf("parte %d: tamanho %d não confere com o objeto enviado (%d bytes)", i+1, part.Size, len(data))
+		}
+		frameCount := strconv.Itoa(part.LastFrame - part.FirstFrame + 1)
+		if got := fake.metadata[key].Get("X-Amz-Meta-Frame-Count"); got != frameCount {
+			t.Errorf("parte %d: esperado frame-count %s, mas obteve %q", i+1, frameCount, got)
+		}
+	}
+	if len(fake.deleted) > 0 {
+		t.Errorf("nenhuma parte deveria ser removida, mas removeu %v", fake.deleted)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Errorf("esperado arquivos locais removidos, mas restaram %d", len(entries))
+	}
+}
+
+func TestJobOutputAbortLastPartUploadFailed(t *testing.T) {
+	// O envio da última parte falha: o objeto pode ter chegado ao S3 e
+	// também precisa ser removido
+	lastKey := storage.ArchivePartName("processed/video_frames.zip", storage.ArchiveFormatZip, 3)
+	output, fake, _ := newTestJobOutput(t, 4000, lastKey)
+	frames, manifestFiles := writeTestFrames(t, 5, 400)
+
+	if err := writeFramesArchive(output, frames, manifestFiles); err != nil {
+		t.Fatalf("erro inesperado ao gravar frames: %v", err)
+	}
+	if err := output.finish(); err == nil {
+		t.Fatal("esperado erro no envio da última parte")
+	}
+
+	keys := output.keys()
+	if len(keys) != 3 {
+		t.Fatalf("esperado 3 partes, mas obteve %v", keys)
+	}
+	for _, key := range keys {
+		if !slices.Contains(fake.deleted, key) {
+			t.Errorf("esperado remoção de %s, mas removeu %v", key, fake.deleted)
+		}
+	}
+	if len(fake.objects) > 0 {
+		t.Errorf("esperado nenhum objeto no S3, mas restaram %d", len(fake.objects))
+	}
+}
+
+func TestJobOutputAbortMidWrite(t *testing.T) {
+	output, fake, dir := newTestJobOutput(t, 4000)
+	frames, _ := writeTestFrames(t, 3, 400)
+
+	// O terceiro frame abre a segunda parte, que ainda não foi enviada
+	if err := writeFramesArchive(output, frames, nil); err != nil {
+		t.Fatalf("erro inesperado ao gravar frames: %v", err)
+	}
+	output.abort(errors.New("falha na extração"))
+
+	first := storage.ArchivePartName("processed/video_frames.zip", storage.ArchiveFormatZip, 1)
+	if !slices.Equal(fake.deleted, []string{first}) {
+		t.Errorf("esperado remoção apenas de %s, mas removeu %v", first, fake.deleted)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Errorf("esperado arquivo local da parte atual removido, mas restaram %d", len(entries))
+	}
+}
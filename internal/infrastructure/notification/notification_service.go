@@ -16,12 +16,60 @@ type User struct {
 	Email string `json:"email"`
 }
 
+// AnalysisSummary resume a análise do vídeo de origem no evento de sucesso;
+// o relatório completo fica no arquivo analysis.json do ZIP
+type AnalysisSummary struct {
+	Findings           []string `json:"findings"` // problemas encontrados, ex.: black_segments
+	BlackSeconds       float64  `json:"blackSeconds"`
+	FrozenSeconds      float64  `json:"frozenSeconds"`
+	SilentSeconds      float64  `json:"silentSeconds"`
+	IntegratedLoudness *float64 `json:"integratedLoudness,omitempty"` // LUFS
+	TruePeak           *float64 `json:"truePeak,omitempty"`           // dBTP
+}
+
+// InterlaceSummary informa no evento de sucesso se os frames foram
+// desentrelaçados; a contagem do idet fica no manifesto
+type InterlaceSummary struct {
+	Deinterlaced bool   `json:"deinterlaced"`
+	Source       string `json:"source"` // job (pedido na mensagem) ou detection (idet)
+}
+
+// ArchivePart descreve uma parte do pacote de frames quando ele é dividido
+// por tamanho; cada parte é um arquivo válido por si só, mas o manifest.json
+// e o SHA256SUMS, que cobrem todos os frames, vão apenas na última parte
+type ArchivePart struct {
+	Key        string `json:"key"`
+	URL        string `json:"url,omitempty"` // URL HTTPS de download da parte
+	Size       int64  `json:"size"`
+	FirstFrame int    `json:"firstFrame,omitempty"` // intervalo de frames da parte, a partir de 1
+	LastFrame  int    `json:"lastFrame,omitempty"`  // omitido na parte que contém apenas o manifesto
+	Manifest   bool   `json:"manifest,omitempty"`   // parte com o manifest.json e o SHA256SUMS de todo o pacote
+}
+
 // EventData representa os dados específicos do evento
 type EventData struct {
-	VideoID      string `json:"videoId"`
-	VideoTitle   string `json:"videoTitle"`
-	VideoURL     string `json:"videoUrl,omitempty"`     // opcional para VIDEO_PROCESSED
-	ErrorMessage string `json:"errorMessage,omitempty"` // apenas para VIDEO_FAILED
+	VideoID      string            `json:"videoId"`
+	VideoTitle   string            `json:"videoTitle"`
+	VideoURL     string            `json:"videoUrl,omitempty"`             // URI s3:// do pacote; opcional para VIDEO_PROCESSED
+	DownloadURL  string            `json:"downloadUrl,omitempty"`          // URL HTTPS do pacote, pré-assinada ou pública
+	ExpiresAt    string            `json:"downloadUrlExpiresAt,omitempty"` // expiração da URL pré-assinada (RFC3339)
+	ErrorMessage string            `json:"errorMessage,omitempty"`         // apenas para VIDEO_FAILED
+	Analysis     *AnalysisSummary  `json:"analysis,omitempty"`             // apenas para VIDEO_PROCESSED, quando a análise está ativa
+	Interlace    *InterlaceSummary `json:"interlace,omitempty"`            // apenas para VIDEO_PROCESSED, quando houve decisão de desentrelaçamento
+	Parts        []ArchivePart     `json:"parts,omitempty"`                // apenas para VIDEO_PROCESSED, quando o pacote é dividido
+	Encrypted    bool              `json:"encrypted,omitempty"`            // apenas para VIDEO_PROCESSED, pacote ZIP com AES-256
+	DedupRemoved *int              `json:"dedupRemoved,omitempty"`         // apenas para VIDEO_PROCESSED, quando a deduplicação está ativa
+}
+
+// CompletedDetails agrupa os dados opcionais do evento VIDEO_PROCESSED
+type CompletedDetails struct {
+	Analysis     *AnalysisSummary  // nil quando a análise não foi executada
+	Interlace    *InterlaceSummary // nil sem detecção nem pedido de desentrelaçamento
+	Parts        []ArchivePart     // vazio com um único arquivo
+	Encrypted    bool              // pacote protegido por senha; a senha nunca é enviada
+	DedupRemoved *int              // frames quase idênticos descartados; nil sem deduplicação
+	DownloadURL  string            // vazio quando a URL não pôde ser gerada
+	ExpiresAt    time.Time         // zero para URLs que não expiram
 }
 
 // NotificationEvent representa a estrutura completa da notificação para Kafka
@@ -111,8 +159,10 @@ func (ns *NotificationService) SendEvent(event NotificationEvent) error {
 	return nil
 }
 
-// SendProcessingCompleted envia notificação de processamento concluído
-func (ns *NotificationService) SendProcessingCompleted(videoID, videoTitle, videoURL, userName, userEmail string) error {
+// SendProcessingCompleted envia notificação de processamento concluído, com
+// os dados opcionais de details
+func (ns *NotificationService) SendProcessingCompleted(videoID, videoTitle, videoURL, userName, userEmail string,
+	details CompletedDetails) error {
 	event := NotificationEvent{
 		EventID:   uuid.New().String(),
 		EventType: "VIDEO_PROCESSED",
@@ -123,11 +173,22 @@ func (ns *NotificationService) SendProcessingCompleted(videoID, videoTitle, vide
 			Email: userEmail,
 		},
 		Data: EventData{
-			VideoID:    videoID,
-			VideoTitle: videoTitle,
-			VideoURL:   videoURL,
+			VideoID:      videoID,
+			VideoTitle:   videoTitle,
+			VideoURL:     videoURL,
+			Analysis:     details.Analysis,
+			Interlace:    details.Interlace,
+			Parts:        details.Parts,
+			Encrypted:    details.Encrypted,
+			DedupRemoved: details.DedupRemoved,
 		},
 	}
+	if details.DownloadURL != "" {
+		event.Data.DownloadURL = details.DownloadURL
+		if !details.ExpiresAt.IsZero() {
+			event.Data.ExpiresAt = details.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+	}
 	return ns.SendEvent(event)
 }
 
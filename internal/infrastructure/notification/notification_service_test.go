@@ -164,6 +164,140 @@ func TestNotificationEventJSONSerialization(t *testing.T) {
 	}
 }
 
+func TestNotificationEventAnalysisSummary(t *testing.T) {
+	loudness := -16.4
+	data := EventData{
+		VideoID:  testVideoID,
+		VideoURL: testVideoURL,
+		Analysis: &AnalysisSummary{Findings: []string{"black_segments"}, BlackSeconds: 2.5, IntegratedLoudness: &loudness},
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("erro ao serializar evento para JSON: %v", err)
+	}
+	jsonStr := string(jsonData)
+	for _, field := range []string{`"findings":["black_segments"]`, `"blackSeconds":2.5`, `"integratedLoudness":-16.4`} {
+		if !strings.Contains(jsonStr, field) {
+			t.Errorf("JSON não contém campo esperado: %s\nJSON: %s", field, jsonStr)
+		}
+	}
+	if strings.Contains(jsonStr, "truePeak") {
+		t.Errorf("esperado truePeak omitido quando indefinido: %s", jsonStr)
+	}
+
+	// Sem análise o campo não aparece no evento
+	jsonData, _ = json.Marshal(EventData{VideoID: testVideoID})
+	if strings.Contains(string(jsonData), "analysis") {
+		t.Errorf("esperado campo analysis omitido: %s", jsonData)
+	}
+}
+
+func TestNotificationEventArchiveParts(t *testing.T) {
+	data := EventData{
+		VideoID: testVideoID,
+		Parts: []ArchivePart{
+			{Key: "processed/video_frames.part001.zip", Size: 1024, FirstFrame: 1, LastFrame: 40},
+			{Key: "processed/video_frames.part002.zip", Size: 512, Manifest: true},
+		},
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("erro ao serializar evento para JSON: %v", err)
+	}
+	jsonStr := string(jsonData)
+	expected := `"parts":[{"key":"processed/video_frames.part001.zip","size":1024,"firstFrame":1,"lastFrame":40},` +
+		`{"key":"processed/video_frames.part002.zip","size":512,"manifest":true}]`
+	if !strings.Contains(jsonStr, expected) {
+		t.Errorf("JSON não contém as partes esperadas: %s", jsonStr)
+	}
+
+	// Com um único arquivo o campo não aparece no evento
+	jsonData, _ = json.Marshal(EventData{VideoID: testVideoID})
+	if strings.Contains(string(jsonData), "parts") {
+		t.Errorf("esperado campo parts omitido: %s", jsonData)
+	}
+}
+
+func TestNotificationEventEncrypted(t *testing.T) {
+	jsonData, err := json.Marshal(EventData{VideoID: testVideoID, Encrypted: true})
+	if err != nil {
+		t.Fatalf("erro ao serializar evento para JSON: %v", err)
+	}
+	if !strings.Contains(string(jsonData), `"encrypted":true`) {
+		t.Errorf("JSON não contém a indicação de criptografia: %s", jsonData)
+	}
+
+	// Sem criptografia o campo não aparece no evento
+	jsonData, _ = json.Marshal(EventData{VideoID: testVideoID})
+	if strings.Contains(string(jsonData), "encrypted") {
+		t.Errorf("esperado campo encrypted omitido: %s", jsonData)
+	}
+}
+
+func TestNotificationEventInterlace(t *testing.T) {
+	jsonData, err := json.Marshal(EventData{VideoID: testVideoID, Interlace: &InterlaceSummary{Deinterlaced: true, Source: "detection"}})
+	if err != nil {
+		t.Fatalf("erro ao serializar evento para JSON: %v", err)
+	}
+	if !strings.Contains(string(jsonData), `"interlace":{"deinterlaced":true,"source":"detection"}`) {
+		t.Errorf("JSON não contém a decisão de desentrelaçamento: %s", jsonData)
+	}
+
+	jsonData, _ = json.Marshal(EventData{VideoID: testVideoID})
+	if strings.Contains(string(jsonData), "interlace") {
+		t.Errorf("esperado campo interlace omitido: %s", jsonData)
+	}
+}
+
+func TestNotificationEventDedupRemoved(t *testing.T) {
+	removed := 0
+	jsonData, err := json.Marshal(EventData{VideoID: testVideoID, DedupRemoved: &removed})
+	if err != nil {
+		t.Fatalf("erro ao serializar evento para JSON: %v", err)
+	}
+	if !strings.Contains(string(jsonData), `"dedupRemoved":0`) {
+		t.Errorf("JSON não contém a quantidade de frames removidos: %s", jsonData)
+	}
+
+	// Sem deduplicação o campo não aparece no evento
+	jsonData, _ = json.Marshal(EventData{VideoID: testVideoID})
+	if strings.Contains(string(jsonData), "dedupRemoved") {
+		t.Errorf("esperado campo dedupRemoved omitido: %s", jsonData)
+	}
+}
+
+func TestNotificationEventDownloadURL(t *testing.T) {
+	data := EventData{
+		VideoID:     testVideoID,
+		VideoURL:    "s3://bucket-real/processed/video_frames.zip",
+		DownloadURL: "https://bucket-real.s3.amazonaws.com/processed/video_frames.zip?X-Amz-Expires=86400",
+		ExpiresAt:   "2023-12-02T10:00:00Z",
+		Parts:       []ArchivePart{{Key: "processed/video_frames.part001.zip", URL: "https://cdn.exemplo.com/processed/video_frames.part001.zip", Size: 10}},
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("erro ao serializar evento para JSON: %v", err)
+	}
+	for _, expected := range []string{
+		`"videoUrl":"s3://bucket-real/processed/video_frames.zip"`,
+		`"downloadUrl":"https://bucket-real.s3.amazonaws.com/processed/video_frames.zip?X-Amz-Expires=86400"`,
+		`"downloadUrlExpiresAt":"2023-12-02T10:00:00Z"`,
+		`"url":"https://cdn.exemplo.com/processed/video_frames.part001.zip"`,
+	} {
+		if !strings.Contains(string(jsonData), expected) {
+			t.Errorf("JSON não contém %s: %s", expected, jsonData)
+		}
+	}
+
+	// Sem URL de download os campos não aparecem no evento
+	jsonData, _ = json.Marshal(EventData{VideoID: testVideoID})
+	if strings.Contains(string(jsonData), "downloadUrl") {
+		t.Errorf("esperados campos de download omitidos: %s", jsonData)
+	}
+}
+
 func TestNotificationEventFailedType(t *testing.T) {
 	// Testar evento de falha
 	event := NotificationEvent{
@@ -12,19 +12,38 @@ import (
 	"github.com/aws/aws-sdk-go/service/sqs"
 )
 
+// TimeRange é um trecho do vídeo pedido na mensagem. Os instantes aceitam
+// HH:MM:SS[.mmm], MM:SS[.mmm] ou segundos.
+type TimeRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
 type VideoMessage struct {
-	IDVideo     string `json:"id_video"`
-	Titulo      string `json:"titulo"`
-	Autor       string `json:"autor"`
-	Status      string `json:"status"`
-	FilePath    string `json:"file_path"`
-	DataCriacao string `json:"data_criacao"`
-	DataUpload  string `json:"data_upload"`
-	Email       string `json:"email"`
-	Username    string `json:"username"`
-	ID          int    `json:"id"`
-	VideoKey    string // Campo derivado do file_path
-	VideoID     string // Campo para o ReceiptHandle
+	IDVideo       string      `json:"id_video"`
+	Titulo        string      `json:"titulo"`
+	Autor         string      `json:"autor"`
+	Status        string      `json:"status"`
+	FilePath      string      `json:"file_path"`
+	DataCriacao   string      `json:"data_criacao"`
+	DataUpload    string      `json:"data_upload"`
+	Email         string      `json:"email"`
+	Username      string      `json:"username"`
+	ID            int         `json:"id"`
+	Watermark     *bool       `json:"watermark,omitempty"`      // Opcional: sobrescreve o padrão de marca d'água do worker
+	WatermarkName string      `json:"watermark_name,omitempty"` // Opcional: escolhe uma das marcas d'água configuradas no worker
+	FrameCount    int         `json:"frame_count,omitempty"`    // Opcional: extrai exatamente N frames distribuídos pelo vídeo
+	TrimStart     float64     `json:"trim_start,omitempty"`     // Opcional: segundos ignorados no início (modo frame_count)
+	TrimEnd       float64     `json:"trim_end,omitempty"`       // Opcional: segundos ignorados no fim (modo frame_count)
+	Ranges        []TimeRange `json:"ranges,omitempty"`         // Opcional: extrai frames apenas nestes trechos
+	Timestamps    []string    `json:"timestamps,omitempty"`     // Opcional: extrai um frame em cada instante
+	Deinterlace   *bool       `json:"deinterlace,omitempty"`    // Opcional: força ou impede o desentrelaçamento
+	Renditions    []string    `json:"renditions,omitempty"`     // Opcional: rendições por frame (nome:largura ou nome)
+	ArchiveFormat string      `json:"archive_format,omitempty"` // Opcional: zip, tar, tar.gz ou tar.zst
+	Encrypt       *bool       `json:"encrypt,omitempty"`        // Opcional: sobrescreve o padrão de criptografia do ZIP
+	PasswordRef   string      `json:"password_ref,omitempty"`   // Opcional: segredo com a senha do ZIP (secretsmanager://nome ou ARN)
+	VideoKey      string      // Campo derivado do file_path
+	VideoID       string      // Campo para o ReceiptHandle
 }
 
 type SQSService struct {
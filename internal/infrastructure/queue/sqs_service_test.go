@@ -169,6 +169,104 @@ func TestVideoMessageJSONSerialization(t *testing.T) {
 	}
 }
 
+func TestVideoMessageWatermarkOverride(t *testing.T) {
+	// Campo opcional: ausente deve resultar em nil (usar padrão do worker)
+	var msg VideoMessage
+	if err := json.Unmarshal([]byte(`{"id_video":"video-1"}`), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if msg.Watermark != nil {
+		t.Errorf("esperado Watermark nil quando o campo não é informado")
+	}
+
+	if err := json.Unmarshal([]byte(`{"id_video":"video-1","watermark":false}`), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if msg.Watermark == nil || *msg.Watermark {
+		t.Errorf("esperado Watermark false explícito na mensagem")
+	}
+
+	if err := json.Unmarshal([]byte(`{"id_video":"video-1","watermark_name":"confidencial"}`), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if msg.WatermarkName != "confidencial" {
+		t.Errorf("esperado marca d'água 'confidencial', mas obteve %q", msg.WatermarkName)
+	}
+}
+
+func TestVideoMessageFrameCount(t *testing.T) {
+	var msg VideoMessage
+	data := `{"id_video":"video-1","frame_count":50,"trim_start":2.5,"trim_end":10}`
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if msg.FrameCount != 50 || msg.TrimStart != 2.5 || msg.TrimEnd != 10 {
+		t.Errorf("campos do modo de quantidade inesperados: %+v", msg)
+	}
+}
+
+func TestVideoMessageRangesAndTimestamps(t *testing.T) {
+	var msg VideoMessage
+	data := `{"id_video":"video-1","ranges":[{"start":"00:10:00","end":"00:12:30"}],"timestamps":["5","01:02.5"]}`
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if len(msg.Ranges) != 1 || msg.Ranges[0].Start != "00:10:00" || msg.Ranges[0].End != "00:12:30" {
+		t.Errorf("trechos inesperados: %+v", msg.Ranges)
+	}
+	if len(msg.Timestamps) != 2 || msg.Timestamps[1] != "01:02.5" {
+		t.Errorf("instantes inesperados: %+v", msg.Timestamps)
+	}
+}
+
+func TestVideoMessageDeinterlaceOverride(t *testing.T) {
+	var msg VideoMessage
+	if err := json.Unmarshal([]byte(`{"id_video":"video-1","deinterlace":true}`), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if msg.Deinterlace == nil || !*msg.Deinterlace {
+		t.Errorf("esperado desentrelaçamento forçado na mensagem")
+	}
+}
+
+func TestVideoMessageRenditions(t *testing.T) {
+	var msg VideoMessage
+	if err := json.Unmarshal([]byte(`{"id_video":"video-1","renditions":["thumb:320","original"]}`), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if len(msg.Renditions) != 2 || msg.Renditions[0] != "thumb:320" || msg.Renditions[1] != "original" {
+		t.Errorf("rendições inesperadas: %v", msg.Renditions)
+	}
+}
+
+func TestVideoMessageArchiveFormat(t *testing.T) {
+	var msg VideoMessage
+	if err := json.Unmarshal([]byte(`{"id_video":"video-1","archive_format":"tar.zst"}`), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if msg.ArchiveFormat != "tar.zst" {
+		t.Errorf("formato inesperado: %q", msg.ArchiveFormat)
+	}
+}
+
+func TestVideoMessageEncryption(t *testing.T) {
+	var msg VideoMessage
+	data := `{"id_video":"video-1","encrypt":true,"password_ref":"secretsmanager://cliente/zip"}`
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatalf("erro ao deserializar JSON: %v", err)
+	}
+	if msg.Encrypt == nil || !*msg.Encrypt || msg.PasswordRef != "secretsmanager://cliente/zip" {
+		t.Errorf("criptografia inesperada: %v, %q", msg.Encrypt, msg.PasswordRef)
+	}
+
+	// Sem os campos, o padrão do worker é mantido
+	msg = VideoMessage{}
+	json.Unmarshal([]byte(`{"id_video":"video-1"}`), &msg)
+	if msg.Encrypt != nil || msg.PasswordRef != "" {
+		t.Errorf("esperado criptografia ausente, mas obteve %v, %q", msg.Encrypt, msg.PasswordRef)
+	}
+}
+
 func TestVideoKeyExtractionLogic(t *testing.T) {
 	// Testar a lógica de extração de VideoKey do FilePath
 	testCases := []struct {
@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// secretRefPrefix identifica uma referência por nome: secretsmanager://nome
+const secretRefPrefix = "secretsmanager://"
+
+// ErrSecretRefNotAllowed indica uma referência fora dos prefixos permitidos;
+// o Secrets Manager não chega a ser consultado
+var ErrSecretRefNotAllowed = errors.New("referência de segredo não permitida")
+
+// SecretsConfig restringe os segredos que podem ser lidos. As referências
+// vêm das mensagens da fila e, sem restrição, dariam acesso a qualquer
+// segredo que a role do worker consegue ler.
+type SecretsConfig struct {
+	AllowedPrefixes []string // prefixos de nome (ex.: zip/) ou de ARN; vazio recusa todas as referências
+}
+
+// SecretsService lê segredos do AWS Secrets Manager, usados por exemplo como
+// senha do pacote de frames. Os valores lidos nunca devem ser registrados em log.
+type SecretsService struct {
+	client *secretsmanager.SecretsManager
+	config SecretsConfig
+}
+
+// NewSecretsService cria o serviço sem prefixos permitidos, que recusa
+// todas as referências
+func NewSecretsService() (*SecretsService, error) {
+	return NewSecretsServiceWithConfig(SecretsConfig{})
+}
+
+// NewSecretsServiceWithConfig cria o serviço que lê apenas os segredos dos
+// prefixos configurados
+func NewSecretsServiceWithConfig(config SecretsConfig) (*SecretsService, error) {
+	endpoint := os.Getenv("LOCALSTACK_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localhost:4566"
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(endpoint), // LocalStack endpoint
+		Credentials: credentials.NewStaticCredentials("test", "test", ""),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretsService{client: secretsmanager.New(sess), config: config}, nil
+}
+
+// ParseSecretRef extrai o identificador do segredo de uma referência no
+// formato secretsmanager://nome ou de um ARN do Secrets Manager
+func ParseSecretRef(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case strings.HasPrefix(ref, secretRefPrefix) && len(ref) > len(secretRefPrefix):
+		return ref[len(secretRefPrefix):], nil
+	case strings.HasPrefix(ref, "arn:aws:secretsmanager:"):
+		return ref, nil
+	}
+	return "", fmt.Errorf("referência de segredo não suportada (use %snome ou um ARN do Secrets Manager)", secretRefPrefix)
+}
+
+// Allowed informa se o identificador (nome ou ARN) está em um dos
+// prefixos permitidos
+func (c SecretsConfig) Allowed(id string) bool {
+	for _, prefix := range c.AllowedPrefixes {
+		if prefix != "" && strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSecret retorna o valor textual do segredo referenciado, recusando com
+// ErrSecretRefNotAllowed referências fora dos prefixos permitidos
+func (s *SecretsService) GetSecret(ref string) (string, error) {
+	id, err := ParseSecretRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if !s.config.Allowed(id) {
+		return "", fmt.Errorf("%w: %s", ErrSecretRefNotAllowed, id)
+	}
+
+	output, err := s.client.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		return "", err
+	}
+	if output.SecretString == nil || *output.SecretString == "" {
+		return "", errors.New("segredo sem valor textual")
+	}
+	return *output.SecretString, nil
+}
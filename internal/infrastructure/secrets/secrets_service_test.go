@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestSecretsServer simula o endpoint do Secrets Manager respondendo ao
+// GetSecretValue com o valor informado e registrando o SecretId pedido
+func newTestSecretsServer(t *testing.T, value string, secretID *string) *SecretsService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct{ SecretId string }
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &input)
+		*secretID = input.SecretId
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		json.NewEncoder(w).Encode(map[string]string{"Name": input.SecretId, "SecretString": value})
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("LOCALSTACK_ENDPOINT", server.URL)
+	service, err := NewSecretsServiceWithConfig(SecretsConfig{AllowedPrefixes: []string{"cliente/"}})
+	if err != nil {
+		t.Fatalf("erro ao criar SecretsService: %v", err)
+	}
+	return service
+}
+
+func TestParseSecretRef(t *testing.T) {
+	arn := "arn:aws:secretsmanager:us-east-1:000000000000:secret:cliente/zip-AbCdEf"
+	testCases := map[string]string{
+		"secretsmanager://cliente/zip": "cliente/zip",
+		arn:                            arn,
+	}
+	for ref, expected := range testCases {
+		id, err := ParseSecretRef(ref)
+		if err != nil || id != expected {
+			t.Errorf("%q: esperado %q, mas obteve %q (%v)", ref, expected, id, err)
+		}
+	}
+
+	for _, ref := range []string{"", "secretsmanager://", "s3://bucket/senha.txt", "senha-em-claro"} {
+		if _, err := ParseSecretRef(ref); err == nil {
+			t.Errorf("%q: esperado erro para referência não suportada", ref)
+		}
+	}
+}
+
+func TestGetSecret(t *testing.T) {
+	var secretID string
+	service := newTestSecretsServer(t, "senha-do-cliente", &secretID)
+
+	value, err := service.GetSecret("secretsmanager://cliente/zip")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if value != "senha-do-cliente" || secretID != "cliente/zip" {
+		t.Errorf("esperado segredo cliente/zip, mas obteve %q de %q", value, secretID)
+	}
+}
+
+func TestGetSecretEmptyValue(t *testing.T) {
+	var secretID string
+	service := newTestSecretsServer(t, "", &secretID)
+
+	_, err := service.GetSecret("secretsmanager://cliente/zip")
+	if err == nil || !strings.Contains(err.Error(), "sem valor") {
+		t.Errorf("esperado erro para segredo vazio, mas obteve %v", err)
+	}
+}
+
+func TestGetSecretInvalidRef(t *testing.T) {
+	var secretID string
+	service := newTestSecretsServer(t, "senha", &secretID)
+
+	if _, err := service.GetSecret("senha-em-claro"); err == nil {
+		t.Error("esperado erro para referência inválida")
+	}
+	if secretID != "" {
+		t.Errorf("esperado nenhuma chamada ao Secrets Manager, mas pediu %q", secretID)
+	}
+}
+
+func TestGetSecretNotAllowed(t *testing.T) {
+	var secretID string
+	service := newTestSecretsServer(t, "credencial-do-banco", &secretID)
+
+	refs := []string{
+		"secretsmanager://prod/db-password",
+		"secretsmanager://clientes/zip", // prefixo parecido, mas fora de cliente/
+		"arn:aws:secretsmanager:us-east-1:000000000000:secret:cliente/zip-AbCdEf",
+	}
+	for _, ref := range refs {
+		if _, err := service.GetSecret(ref); !errors.Is(err, ErrSecretRefNotAllowed) {
+			t.Errorf("%q: esperado ErrSecretRefNotAllowed, mas obteve %v", ref, err)
+		}
+	}
+	if secretID != "" {
+		t.Errorf("esperado nenhuma chamada ao Secrets Manager, mas pediu %q", secretID)
+	}
+}
+
+func TestSecretsConfigAllowedEmpty(t *testing.T) {
+	// Sem prefixos configurados nenhuma referência é aceita
+	if (SecretsConfig{}).Allowed("cliente/zip") || (SecretsConfig{AllowedPrefixes: []string{""}}).Allowed("cliente/zip") {
+		t.Error("esperada referência recusada sem prefixos permitidos")
+	}
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Formatos de arquivo suportados para o pacote de frames
+const (
+	ArchiveFormatZip    = "zip"
+	ArchiveFormatTar    = "tar"
+	ArchiveFormatTarGz  = "tar.gz"
+	ArchiveFormatTarZst = "tar.zst"
+)
+
+// ArchiveFormats lista os formatos aceitos, na ordem de preferência
+var ArchiveFormats = []string{ArchiveFormatZip, ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatTarZst}
+
+// archiveFormatAliases mapeia nomes alternativos para o formato canônico
+var archiveFormatAliases = map[string]string{
+	"tgz":      ArchiveFormatTarGz,
+	"tar.gzip": ArchiveFormatTarGz,
+	"tzst":     ArchiveFormatTarZst,
+	"tar.zstd": ArchiveFormatTarZst,
+}
+
+// ReproducibleModTime é a data gravada em todas as entradas no modo
+// reprodutível (o início do calendário do ZIP)
+var ReproducibleModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// errEncryptionZipOnly indica criptografia pedida para um formato TAR
+var errEncryptionZipOnly = errors.New("criptografia disponível apenas no formato zip")
+
+// ArchiveWriter permite adicionar entradas a um arquivo (ZIP ou TAR) à medida
+// que são produzidas, sem precisar gravá-las antes em disco
+type ArchiveWriter interface {
+	AddFile(filename string) error
+	AddFileAs(filename, name string) error
+	AddEntry(name string, data []byte) error
+	Close() error
+}
+
+// ArchiveService cria arquivos no formato escolhido, em disco ou em
+// qualquer destino (ex.: um upload em streaming)
+type ArchiveService interface {
+	CreateArchive(format string, files []string, path string) error
+	NewArchive(format, path string) (ArchiveWriter, error)
+	NewArchiveTo(format string, w io.Writer) (ArchiveWriter, error)
+}
+
+type archiveService struct {
+	zip          ZipService
+	encrypted    bool // criptografia pedida; suportada apenas no ZIP
+	reproducible bool
+}
+
+func NewArchiveService() ArchiveService {
+	return &archiveService{zip: NewZipService()}
+}
+
+// NewArchiveServiceWithConfig cria o serviço com a compressão (e, com senha,
+// a criptografia) do ZIP informada. O modo reprodutível vale também para os
+// formatos TAR.
+func NewArchiveServiceWithConfig(zip ZipConfig) ArchiveService {
+	return &archiveService{
+		zip:          NewZipServiceWithConfig(zip),
+		encrypted:    zip.Password != "",
+		reproducible: zip.Reproducible,
+	}
+}
+
+// sortedByEntryName retorna uma cópia dos caminhos ordenada pelo nome da
+// entrada, tornando a ordem independente de como a lista foi obtida
+func sortedByEntryName(files []string) []string {
+	sorted := slices.Clone(files)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		return strings.Compare(filepath.Base(a), filepath.Base(b))
+	})
+	return sorted
+}
+
+// ParseArchiveFormat normaliza o nome do formato, aceitando aliases como
+// "tgz" e "tzst"
+func ParseArchiveFormat(value string) (string, error) {
+	format := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), "."))
+	if alias, ok := archiveFormatAliases[format]; ok {
+		format = alias
+	}
+	for _, supported := range ArchiveFormats {
+		if format == supported {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("formato de arquivo não suportado: %q (use %s)", value, strings.Join(ArchiveFormats, ", "))
+}
+
+// ArchiveExtension retorna a extensão do arquivo, com o ponto inicial
+func ArchiveExtension(format string) string {
+	return "." + format
+}
+
+// ArchiveContentType retorna o Content-Type do objeto no S3
+func ArchiveContentType(format string) string {
+	switch format {
+	case ArchiveFormatTar:
+		return "application/x-tar"
+	case ArchiveFormatTarGz:
+		return "application/gzip"
+	case ArchiveFormatTarZst:
+		return "application/zstd"
+	}
+	return "application/zip"
+}
+
+func (a *archiveService) CreateArchive(format string, files []string, path string) error {
+	archive, err := a.NewArchive(format, path)
+	if err != nil {
+		return err
+	}
+
+	if a.reproducible {
+		files = sortedByEntryName(files)
+	}
+	for _, file := range files {
+		if err := archive.AddFile(file); err != nil {
+			archive.Close()
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func (a *archiveService) NewArchive(format, path string) (ArchiveWriter, error) {
+	if format == ArchiveFormatZip {
+		return a.zip.NewZipStream(path)
+	}
+	if a.encrypted {
+		return nil, errEncryptionZipOnly
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := newTarStream(format, file, a.reproducible)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	archive.closers = append(archive.closers, file)
+	return archive, nil
+}
+
+// NewArchiveTo escreve o arquivo no writer informado. Close finaliza o
+// arquivo, mas não fecha o writer.
+func (a *archiveService) NewArchiveTo(format string, w io.Writer) (ArchiveWriter, error) {
+	if format == ArchiveFormatZip {
+		return a.zip.NewZipStreamTo(w), nil
+	}
+	if a.encrypted {
+		return nil, errEncryptionZipOnly
+	}
+	return newTarStream(format, w, a.reproducible)
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseArchiveFormat(t *testing.T) {
+	testCases := map[string]string{
+		"zip":     ArchiveFormatZip,
+		" TAR ":   ArchiveFormatTar,
+		"tgz":     ArchiveFormatTarGz,
+		".tar.gz": ArchiveFormatTarGz,
+		"tzst":    ArchiveFormatTarZst,
+		"tar.zst": ArchiveFormatTarZst,
+	}
+	for value, expected := range testCases {
+		format, err := ParseArchiveFormat(value)
+		if err != nil || format != expected {
+			t.Errorf("%q: esperado %s, mas obteve %q (%v)", value, expected, format, err)
+		}
+	}
+
+	if _, err := ParseArchiveFormat("rar"); err == nil {
+		t.Error("esperado erro para formato não suportado")
+	}
+}
+
+func TestArchiveExtensionAndContentType(t *testing.T) {
+	testCases := []struct {
+		format, extension, contentType string
+	}{
+		{ArchiveFormatZip, ".zip", "application/zip"},
+		{ArchiveFormatTar, ".tar", "application/x-tar"},
+		{ArchiveFormatTarGz, ".tar.gz", "application/gzip"},
+		{ArchiveFormatTarZst, ".tar.zst", "application/zstd"},
+	}
+	for _, tc := range testCases {
+		if got := ArchiveExtension(tc.format); got != tc.extension {
+			t.Errorf("%s: esperado extensão %s, mas obteve %s", tc.format, tc.extension, got)
+		}
+		if got := ArchiveContentType(tc.format); got != tc.contentType {
+			t.Errorf("%s: esperado Content-Type %s, mas obteve %s", tc.format, tc.contentType, got)
+		}
+	}
+}
+
+// readTarEntries descomprime o TAR no formato informado e retorna as entradas
+func readTarEntries(t *testing.T, format string, data []byte) map[string]string {
+	t.Helper()
+	entries := make(map[string]string)
+	tr := newTarReader(t, format, data)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar inválido: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		entries[header.Name] = string(content)
+	}
+	return entries
+}
+
+func TestArchiveTarFormats(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "frame_0001.png")
+	if err := os.WriteFile(fileName, []byte("frame em disco"), 0644); err != nil {
+		t.Fatalf("erro ao criar arquivo temporário: %v", err)
+	}
+
+	service := NewArchiveService()
+	for _, format := range []string{ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatTarZst} {
+		var buf bytes.Buffer
+		archive, err := service.NewArchiveTo(format, &buf)
+		if err != nil {
+			t.Fatalf("%s: erro ao criar arquivo: %v", format, err)
+		}
+		if err := archive.AddFileAs(fileName, "thumb/frame_0001.png"); err != nil {
+			t.Fatalf("%s: erro ao adicionar arquivo: %v", format, err)
+		}
+		if err := archive.AddEntry("manifest.json", []byte("{}")); err != nil {
+			t.Fatalf("%s: erro ao adicionar entrada: %v", format, err)
+		}
+		if err := archive.Close(); err != nil {
+			t.Fatalf("%s: erro ao fechar arquivo: %v", format, err)
+		}
+
+		entries := readTarEntries(t, format, buf.Bytes())
+		if len(entries) != 2 || entries["thumb/frame_0001.png"] != "frame em disco" || entries["manifest.json"] != "{}" {
+			t.Errorf("%s: entradas inesperadas: %v", format, entries)
+		}
+	}
+}
+
+func TestCreateArchiveTarGzFile(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "frame_0001.png")
+	os.WriteFile(fileName, []byte("frame"), 0644)
+
+	archivePath := filepath.Join(dir, "frames.tar.gz")
+	if err := NewArchiveService().CreateArchive(ArchiveFormatTarGz, []string{fileName}, archivePath); err != nil {
+		t.Fatalf("erro inesperado ao criar arquivo: %v", err)
+	}
+
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatalf("arquivo não criado: %v", err)
+	}
+	if entries := readTarEntries(t, ArchiveFormatTarGz, data); entries["frame_0001.png"] != "frame" {
+		t.Errorf("entradas inesperadas: %v", entries)
+	}
+}
+
+func TestNewArchiveUnsupportedFormat(t *testing.T) {
+	if _, err := NewArchiveService().NewArchiveTo("rar", io.Discard); err == nil {
+		t.Error("esperado erro para formato não suportado")
+	}
+}
+
+func TestNewArchiveEncryptedTarRejected(t *testing.T) {
+	service := NewArchiveServiceWithConfig(ZipConfig{Password: testArchivePassword})
+	if _, err := service.NewArchiveTo(ArchiveFormatTarGz, io.Discard); err == nil {
+		t.Error("esperado erro para criptografia em formato TAR")
+	}
+	if _, err := service.NewArchive(ArchiveFormatTar, filepath.Join(t.TempDir(), "frames.tar")); err == nil {
+		t.Error("esperado erro para criptografia em formato TAR")
+	}
+	if _, err := service.NewArchiveTo(ArchiveFormatZip, io.Discard); err != nil {
+		t.Errorf("erro inesperado para ZIP criptografado: %v", err)
+	}
+}
+
+// buildReproducibleArchive cria o arquivo com os frames em files, na ordem
+// informada, mais uma entrada em memória, e retorna os bytes gerados
+func buildReproducibleArchive(t *testing.T, config ZipConfig, format string, files []string) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "frames"+ArchiveExtension(format))
+	service := NewArchiveServiceWithConfig(config)
+	if err := service.CreateArchive(format, files, path); err != nil {
+		t.Fatalf("erro inesperado ao criar arquivo: %v", err)
+	}
+
+	// Entradas em memória (manifesto) usam a data fixa em vez da atual
+	var buf bytes.Buffer
+	stream, err := service.NewArchiveTo(format, &buf)
+	if err != nil {
+		t.Fatalf("erro inesperado ao criar arquivo: %v", err)
+	}
+	stream.AddEntry("manifest.json", []byte(`{"frames":[]}`))
+	stream.Close()
+
+	data, _ := os.ReadFile(path)
+	return append(data, buf.Bytes()...)
+}
+
+func TestArchiveReproducible(t *testing.T) {
+	forceZipParallel(t)
+	dir := t.TempDir()
+	first := filepath.Join(dir, "frame_0001.png")
+	second := filepath.Join(dir, "frame_0002.png")
+	os.WriteFile(first, incompressibleData(2048), 0644)
+	os.WriteFile(second, bytes.Repeat([]byte("frame"), 500), 0644)
+
+	configs := map[string]ZipConfig{
+		"auto":        {Compression: ZipCompressionAuto},
+		"paralelo":    {Compression: ZipCompressionDeflate, Parallel: 2},
+		"criptografa": {Compression: ZipCompressionAuto, Password: testArchivePassword},
+	}
+	build := func(order []string) map[string][]byte {
+		archives := map[string][]byte{}
+		for name, config := range configs {
+			config.Reproducible = true
+			for _, format := range ArchiveFormats {
+				if config.Password == "" || format == ArchiveFormatZip {
+					archives[name+"/"+format] = buildReproducibleArchive(t, config, format, order)
+				}
+			}
+		}
+		return archives
+	}
+	original := build([]string{first, second})
+
+	// Outra data, outras permissões e outra ordem não alteram os bytes
+	later := time.Now().Add(48 * time.Hour)
+	os.Chtimes(first, later, later)
+	os.Chmod(second, 0600)
+	time.Sleep(1100 * time.Millisecond)
+	again := build([]string{second, first})
+
+	for key, data := range original {
+		if !bytes.Equal(data, again[key]) {
+			t.Errorf("%s: esperado arquivo idêntico no modo reprodutível", key)
+		}
+	}
+}
+
+func TestZipReproducibleHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	stream := NewZipServiceWithConfig(ZipConfig{Reproducible: true}).NewZipStreamTo(&buf)
+	stream.AddEntry("manifest.json", []byte("{}"))
+	stream.Close()
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ZIP inválido: %v", err)
+	}
+	file := reader.File[0]
+	if !file.Modified.Equal(ReproducibleModTime) || file.Mode().Perm() != 0644 {
+		t.Errorf("esperada data %v e permissão 0644, mas obteve %v e %v", ReproducibleModTime, file.Modified, file.Mode())
+	}
+}
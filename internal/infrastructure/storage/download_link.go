@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// DefaultDownloadURLExpiry é a validade padrão das URLs pré-assinadas
+	DefaultDownloadURLExpiry = 24 * time.Hour
+	// MaxDownloadURLExpiry é a maior validade aceita pela assinatura SigV4
+	MaxDownloadURLExpiry = 7 * 24 * time.Hour
+)
+
+// DownloadURLConfig define como gerar a URL HTTPS de download do pacote
+type DownloadURLConfig struct {
+	Expiry time.Duration // validade da URL pré-assinada
+	// BaseURL é um template de URL pública ou de CDN com {key} e,
+	// opcionalmente, {bucket} (ex.: https://cdn.exemplo.com/{key}); quando
+	// definido substitui a URL pré-assinada, que não expira
+	BaseURL string
+}
+
+// Validate confere a validade e o template da URL
+func (c DownloadURLConfig) Validate() error {
+	if c.BaseURL != "" {
+		if !strings.Contains(c.BaseURL, "{key}") {
+			return errors.New("template de URL sem {key}")
+		}
+		return nil
+	}
+	if c.Expiry <= 0 || c.Expiry > MaxDownloadURLExpiry {
+		return fmt.Errorf("validade da URL deve estar entre 1s e %s", MaxDownloadURLExpiry)
+	}
+	return nil
+}
+
+// DownloadLink identifica um objeto enviado: a URI s3:// e a URL HTTPS para
+// download, com o instante em que ela expira (zero para URLs públicas)
+type DownloadLink struct {
+	URI       string
+	URL       string
+	ExpiresAt time.Time
+}
+
+// ObjectURI retorna a URI s3://bucket/chave do objeto no bucket configurado
+func (s *S3Service) ObjectURI(key string) string {
+	return "s3://" + s.bucket + "/" + key
+}
+
+// PresignGetURL gera uma URL GET pré-assinada para o objeto, válida por expiry
+func (s *S3Service) PresignGetURL(key string, expiry time.Duration) (string, error) {
+	request, _ := s.s3Client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return request.Presign(expiry)
+}
+
+// DownloadLink monta a URI e a URL de download do objeto conforme a
+// configuração: o template público quando definido ou a URL pré-assinada
+func (s *S3Service) DownloadLink(key string, config DownloadURLConfig) (DownloadLink, error) {
+	link := DownloadLink{URI: s.ObjectURI(key)}
+	if config.BaseURL != "" {
+		link.URL = strings.NewReplacer("{bucket}", s.bucket, "{key}", escapeKeyPath(key)).Replace(config.BaseURL)
+		return link, nil
+	}
+
+	expiresAt := time.Now().UTC().Add(config.Expiry)
+	presigned, err := s.PresignGetURL(key, config.Expiry)
+	if err != nil {
+		return link, err
+	}
+	link.URL = presigned
+	link.ExpiresAt = expiresAt.Truncate(time.Second)
+	return link, nil
+}
+
+// escapeKeyPath escapa cada segmento da chave, preservando as barras
+func escapeKeyPath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadLinkPresigned(t *testing.T) {
+	t.Setenv("LOCALSTACK_ENDPOINT", testEndpoint)
+	service, err := NewS3Service(testBucket)
+	if err != nil {
+		t.Fatalf(errorCreateS3, err)
+	}
+
+	before := time.Now().UTC()
+	link, err := service.DownloadLink("processed/video_frames.zip", DownloadURLConfig{Expiry: time.Hour})
+	if err != nil {
+		t.Fatalf("erro inesperado ao gerar URL: %v", err)
+	}
+	if link.URI != "s3://"+testBucket+"/processed/video_frames.zip" {
+		t.Errorf("URI inesperada: %s", link.URI)
+	}
+	for _, expected := range []string{testEndpoint + "/" + testBucket + "/processed/video_frames.zip?", "X-Amz-Expires=3600", "X-Amz-Signature="} {
+		if !strings.Contains(link.URL, expected) {
+			t.Errorf("esperado %q na URL pré-assinada %s", expected, link.URL)
+		}
+	}
+	if link.ExpiresAt.Before(before.Add(time.Hour-time.Second)) || link.ExpiresAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("expiração inesperada: %s", link.ExpiresAt)
+	}
+}
+
+func TestDownloadLinkBaseURL(t *testing.T) {
+	service := &S3Service{bucket: testBucket}
+	link, err := service.DownloadLink("processed/vídeo 1.zip", DownloadURLConfig{BaseURL: "https://cdn.exemplo.com/{bucket}/{key}"})
+	if err != nil {
+		t.Fatalf("erro inesperado ao gerar URL: %v", err)
+	}
+	if link.URL != "https://cdn.exemplo.com/"+testBucket+"/processed/v%C3%ADdeo%201.zip" {
+		t.Errorf("URL pública inesperada: %s", link.URL)
+	}
+	if !link.ExpiresAt.IsZero() {
+		t.Errorf("esperada URL pública sem expiração, mas obteve %s", link.ExpiresAt)
+	}
+}
+
+func TestDownloadURLConfigValidate(t *testing.T) {
+	valid := []DownloadURLConfig{
+		{Expiry: DefaultDownloadURLExpiry},
+		{Expiry: MaxDownloadURLExpiry},
+		{BaseURL: "https://cdn.exemplo.com/{key}"},
+	}
+	for _, config := range valid {
+		if err := config.Validate(); err != nil {
+			t.Errorf("esperada configuração válida %+v, mas obteve %v", config, err)
+		}
+	}
+
+	invalid := []DownloadURLConfig{
+		{},
+		{Expiry: MaxDownloadURLExpiry + time.Second},
+		{BaseURL: "https://cdn.exemplo.com/"},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("esperado erro para %+v", config)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultOutputKeyTemplate mantém o layout original: processed/<vídeo>_frames.<formato>
+const DefaultOutputKeyTemplate = "processed/{basename}_frames{ext}"
+
+// Políticas para quando já existe um objeto na chave de saída
+const (
+	OutputCollisionOverwrite = "overwrite" // sobrescreve o objeto existente
+	OutputCollisionFail      = "fail"      // recusa o job
+	OutputCollisionVersion   = "version"   // usa a próxima chave livre: _v2, _v3...
+)
+
+// maxOutputKeyVersions limita as tentativas da política version
+const maxOutputKeyVersions = 100
+
+// maxOutputKeySegment limita o tamanho de cada valor inserido na chave
+const maxOutputKeySegment = 100
+
+// OutputKeyPlaceholders são os campos aceitos no template da chave de saída
+var OutputKeyPlaceholders = []string{"id_video", "id", "username", "autor", "basename", "format", "ext"}
+
+// ErrOutputKeyExists indica que a chave de saída já existe e a política
+// de colisão recusa sobrescrevê-la
+var ErrOutputKeyExists = errors.New("chave de saída já existe")
+
+// OutputKeyConfig define o layout das chaves do pacote de frames no bucket
+type OutputKeyConfig struct {
+	Template  string // chave com placeholders, ex.: processed/{username}/{id_video}/{basename}_{format}.zip; vazio usa a chave original
+	Collision string // overwrite, fail ou version
+}
+
+// DefaultOutputKeyConfig retorna o layout original, sobrescrevendo colisões
+func DefaultOutputKeyConfig() OutputKeyConfig {
+	return OutputKeyConfig{Collision: OutputCollisionOverwrite}
+}
+
+var outputKeyPlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// Validate confere os placeholders do template e a política de colisão
+func (c OutputKeyConfig) Validate() error {
+	if c.Template != "" && (strings.TrimSpace(c.Template) == "" || strings.HasPrefix(c.Template, "/")) {
+		return fmt.Errorf("template de chave inválido: %q", c.Template)
+	}
+	for _, match := range outputKeyPlaceholder.FindAllStringSubmatch(c.Template, -1) {
+		known := false
+		for _, placeholder := range OutputKeyPlaceholders {
+			known = known || match[1] == placeholder
+		}
+		if !known {
+			return fmt.Errorf("placeholder desconhecido no template de chave: {%s} (use %s)", match[1], strings.Join(OutputKeyPlaceholders, ", "))
+		}
+	}
+	switch c.Collision {
+	case OutputCollisionOverwrite, OutputCollisionFail, OutputCollisionVersion:
+		return nil
+	}
+	return fmt.Errorf("política de colisão inválida: %q (use %s, %s ou %s)", c.Collision, OutputCollisionOverwrite, OutputCollisionFail, OutputCollisionVersion)
+}
+
+// Render monta a chave a partir dos valores dos placeholders, que são
+// sanitizados por serem controlados pelo usuário. {format} (com - no lugar
+// de pontos) e {ext} vêm do formato do pacote, e a extensão final é sempre a
+// do formato, substituindo uma extensão de pacote fixa no template (ex.: .zip
+// em um job tar.gz). Sem template, a chave é a do layout original
+// (DefaultOutputKeyTemplate) e {basename} só tem as barras trocadas por _,
+// sem sanitização; um template explícito é sempre sanitizado.
+func (c OutputKeyConfig) Render(values map[string]string, format string) string {
+	template, legacy := c.Template, c.Template == ""
+	if legacy {
+		template = DefaultOutputKeyTemplate
+	}
+	key := outputKeyPlaceholder.ReplaceAllStringFunc(template, func(match string) string {
+		switch name := match[1 : len(match)-1]; name {
+		case "format":
+			// Sem pontos, para não se confundir com a extensão (tar.zst vira tar-zst)
+			return strings.ReplaceAll(format, ".", "-")
+		case "ext":
+			return ArchiveExtension(format)
+		case "basename":
+			if legacy {
+				return strings.ReplaceAll(values[name], "/", "_")
+			}
+			fallthrough
+		default:
+			return SanitizeKeySegment(values[name])
+		}
+	})
+
+	// Segmentos vazios ou relativos vindos do template são descartados
+	var segments []string
+	for _, segment := range strings.Split(key, "/") {
+		if segment != "" && segment != "." && segment != ".." {
+			segments = append(segments, segment)
+		}
+	}
+	key = strings.Join(segments, "/")
+
+	extension := ArchiveExtension(format)
+	if !strings.HasSuffix(key, extension) {
+		for _, other := range ArchiveFormats {
+			if strings.HasSuffix(key, ArchiveExtension(other)) {
+				key = strings.TrimSuffix(key, ArchiveExtension(other))
+				break
+			}
+		}
+		key += extension
+	}
+	return key
+}
+
+var keyAccents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "ç", "c", "ñ", "n",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A", "É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"Í", "I", "Ì", "I", "Î", "I", "Ï", "I", "Ó", "O", "Ò", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"Ú", "U", "Ù", "U", "Û", "U", "Ü", "U", "Ç", "C", "Ñ", "N",
+)
+
+var keyUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// keySeparators agrupa os separadores em volta de um caractere substituído
+var keySeparators = regexp.MustCompile(`[._-]*_[._-]*`)
+
+// SanitizeKeySegment torna um valor controlado pelo usuário seguro como
+// segmento de chave: remove acentos, troca barras, espaços e demais
+// caracteres por um único _, descarta pontos e separadores nas pontas (o
+// que impede segmentos como ..) e limita o tamanho. Valores vazios viram
+// "desconhecido".
+func SanitizeKeySegment(value string) string {
+	value = keyUnsafe.ReplaceAllString(keyAccents.Replace(value), "_")
+	value = keySeparators.ReplaceAllString(value, "_")
+	if len(value) > maxOutputKeySegment {
+		value = value[:maxOutputKeySegment]
+	}
+	value = strings.Trim(value, "._-")
+	if value == "" {
+		return "desconhecido"
+	}
+	return value
+}
+
+// ResolveOutputKey aplica a política de colisão à chave: exists informa se
+// já há um objeto na chave. A verificação não é atômica; dois jobs
+// simultâneos para a mesma chave ainda podem se sobrescrever.
+func ResolveOutputKey(key, policy string, exists func(key string) (bool, error)) (string, error) {
+	if policy == OutputCollisionOverwrite || policy == "" {
+		return key, nil
+	}
+
+	found, err := exists(key)
+	if err != nil || !found {
+		return key, err
+	}
+	if policy == OutputCollisionFail {
+		return "", fmt.Errorf("%w: %s", ErrOutputKeyExists, key)
+	}
+
+	extension := archiveKeyExtension(key)
+	base := strings.TrimSuffix(key, extension)
+	for version := 2; version <= maxOutputKeyVersions; version++ {
+		candidate := fmt.Sprintf("%s_v%d%s", base, version, extension)
+		found, err := exists(candidate)
+		if err != nil || !found {
+			return candidate, err
+		}
+	}
+	return "", fmt.Errorf("%w: %s (sem versão livre até _v%d)", ErrOutputKeyExists, key, maxOutputKeyVersions)
+}
+
+// archiveKeyExtension retorna a extensão de pacote da chave, incluindo as
+// compostas como .tar.gz
+func archiveKeyExtension(key string) string {
+	for _, format := range ArchiveFormats {
+		if extension := ArchiveExtension(format); strings.HasSuffix(key, extension) && strings.Contains(format, ".") {
+			return extension
+		}
+	}
+	return path.Ext(key)
+}
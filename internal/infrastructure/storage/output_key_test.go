@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOutputKeyRender(t *testing.T) {
+	values := map[string]string{
+		"id_video": "abc-123",
+		"id":       "42",
+		"username": "João Silva/../admin",
+		"autor":    "",
+		"basename": "meu vídeo",
+	}
+	tests := []struct {
+		template string
+		format   string
+		expected string
+	}{
+		// Sem template, a chave original; com template explícito, sanitizada
+		{"", ArchiveFormatZip, "processed/meu vídeo_frames.zip"},
+		{"", ArchiveFormatTarGz, "processed/meu vídeo_frames.tar.gz"},
+		{DefaultOutputKeyTemplate, ArchiveFormatZip, "processed/meu_video_frames.zip"},
+		{"processed/{basename}_frames.zip", ArchiveFormatZip, "processed/meu_video_frames.zip"},
+		{"processed/{username}/{id_video}/{basename}_{format}.zip", ArchiveFormatZip, "processed/Joao_Silva_admin/abc-123/meu_video_zip.zip"},
+		// A extensão fixa do template é trocada pela do formato do job
+		{"processed/{username}/{id_video}/{basename}_{format}.zip", ArchiveFormatTarZst, "processed/Joao_Silva_admin/abc-123/meu_video_tar-zst.tar.zst"},
+		{"processed/{basename}_{format}", ArchiveFormatTarGz, "processed/meu_video_tar-gz.tar.gz"},
+		{"processed/{autor}/{id}", ArchiveFormatTar, "processed/desconhecido/42.tar"},
+		{"processed//{id}/../{basename}", ArchiveFormatZip, "processed/42/meu_video.zip"},
+	}
+	for _, tt := range tests {
+		config := OutputKeyConfig{Template: tt.template, Collision: OutputCollisionOverwrite}
+		if key := config.Render(values, tt.format); key != tt.expected {
+			t.Errorf("%s (%s): esperado %q, mas obteve %q", tt.template, tt.format, tt.expected, key)
+		}
+	}
+}
+
+func TestOutputKeyRenderDefaultMatchesBaseline(t *testing.T) {
+	// Sem OUTPUT_KEY_TEMPLATE a chave é a do layout original:
+	// "processed/" + nome do vídeo sem extensão + "_frames.zip"
+	config := DefaultOutputKeyConfig()
+	for _, basename := range []string{"video", "Férias 2023 (final)", "aula.parte1", "..", "a\\b"} {
+		expected := "processed/" + basename + "_frames.zip"
+		if key := config.Render(map[string]string{"basename": basename}, ArchiveFormatZip); key != expected {
+			t.Errorf("%q: esperado %q, mas obteve %q", basename, expected, key)
+		}
+	}
+}
+
+func TestSanitizeKeySegment(t *testing.T) {
+	tests := map[string]string{
+		"..":                  "desconhecido",
+		"../../etc/passwd":    "etc_passwd",
+		"Ação Ñandú":          "Acao_Nandu",
+		"  nome  com espaço ": "nome_com_espaco",
+		"video.final.v2":      "video.final.v2",
+		"日本":                  "desconhecido",
+	}
+	for value, expected := range tests {
+		if sanitized := SanitizeKeySegment(value); sanitized != expected {
+			t.Errorf("%q: esperado %q, mas obteve %q", value, expected, sanitized)
+		}
+	}
+	if long := SanitizeKeySegment(string(make([]byte, 300)) + "a"); len(long) > maxOutputKeySegment {
+		t.Errorf("esperado segmento limitado a %d caracteres, mas obteve %d", maxOutputKeySegment, len(long))
+	}
+}
+
+func TestOutputKeyConfigValidate(t *testing.T) {
+	if err := DefaultOutputKeyConfig().Validate(); err != nil {
+		t.Errorf("esperada configuração padrão válida: %v", err)
+	}
+	invalid := []OutputKeyConfig{
+		{Template: "  ", Collision: OutputCollisionOverwrite},
+		{Template: "/processed/{basename}", Collision: OutputCollisionOverwrite},
+		{Template: "processed/{senha}/{basename}", Collision: OutputCollisionOverwrite},
+		{Template: DefaultOutputKeyTemplate, Collision: "ignore"},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("esperado erro para %+v", config)
+		}
+	}
+}
+
+func TestResolveOutputKey(t *testing.T) {
+	existing := map[string]bool{
+		"processed/video_frames.tar.gz":    true,
+		"processed/video_frames_v2.tar.gz": true,
+	}
+	exists := func(key string) (bool, error) { return existing[key], nil }
+
+	key, err := ResolveOutputKey("processed/video_frames.tar.gz", OutputCollisionOverwrite, exists)
+	if err != nil || key != "processed/video_frames.tar.gz" {
+		t.Errorf("overwrite: esperada a mesma chave, mas obteve %q (%v)", key, err)
+	}
+	key, err = ResolveOutputKey("processed/video_frames.tar.gz", OutputCollisionVersion, exists)
+	if err != nil || key != "processed/video_frames_v3.tar.gz" {
+		t.Errorf("version: esperada a próxima versão livre, mas obteve %q (%v)", key, err)
+	}
+	if _, err := ResolveOutputKey("processed/video_frames.tar.gz", OutputCollisionFail, exists); !errors.Is(err, ErrOutputKeyExists) {
+		t.Errorf("fail: esperado ErrOutputKeyExists, mas obteve %v", err)
+	}
+	key, err = ResolveOutputKey("processed/outro.zip", OutputCollisionFail, exists)
+	if err != nil || key != "processed/outro.zip" {
+		t.Errorf("fail: esperada chave livre aceita, mas obteve %q (%v)", key, err)
+	}
+
+	failure := errors.New("falha no S3")
+	if _, err := ResolveOutputKey("processed/outro.zip", OutputCollisionVersion, func(string) (bool, error) { return false, failure }); !errors.Is(err, failure) {
+		t.Errorf("esperado erro da consulta, mas obteve %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// ChecksumMetadataKey é a chave de metadados com o SHA-256 (hex) do objeto
+// enviado pelo worker; no upload em streaming é usada como tag
+const ChecksumMetadataKey = "sha256"
+
+// IntegrityError indica que o objeto transferido não confere com o S3
+// (tamanho, ETag ou checksum diferentes do esperado)
+type IntegrityError struct {
+	Key    string
+	Reason string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("falha de integridade em %s: %s", e.Key, e.Reason)
+}
+
+// objectDigest calcula MD5 e SHA-256 do conteúdo e o MD5 de cada parte de
+// partSize bytes, o que permite prever o ETag de um upload multipart
+type objectDigest struct {
+	md5      hash.Hash
+	sha256   hash.Hash
+	partSize int64
+	part     hash.Hash
+	partLen  int64
+	parts    [][]byte
+	size     int64
+}
+
+func newObjectDigest(partSize int64) *objectDigest {
+	return &objectDigest{md5: md5.New(), sha256: sha256.New(), partSize: partSize, part: md5.New()}
+}
+
+func (d *objectDigest) Write(p []byte) (int, error) {
+	written := len(p)
+	d.md5.Write(p)
+	d.sha256.Write(p)
+	d.size += int64(len(p))
+	for len(p) > 0 {
+		n := min(int64(len(p)), d.partSize-d.partLen)
+		d.part.Write(p[:n])
+		d.partLen += n
+		p = p[n:]
+		if d.partLen == d.partSize {
+			d.parts = append(d.parts, d.part.Sum(nil))
+			d.part.Reset()
+			d.partLen = 0
+		}
+	}
+	return written, nil
+}
+
+// SHA256 retorna o SHA-256 do conteúdo em hex
+func (d *objectDigest) SHA256() string {
+	return hex.EncodeToString(d.sha256.Sum(nil))
+}
+
+// etag retorna o ETag esperado: o MD5 do conteúdo em upload simples ou, em
+// multipart, o MD5 dos MD5 das partes seguido da quantidade de partes
+func (d *objectDigest) etag(multipart bool) string {
+	if !multipart {
+		return hex.EncodeToString(d.md5.Sum(nil))
+	}
+	parts := d.parts
+	if d.partLen > 0 {
+		parts = append(parts[:len(parts):len(parts)], d.part.Sum(nil))
+	}
+	combined := md5.New()
+	for _, part := range parts {
+		combined.Write(part)
+	}
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(combined.Sum(nil)), len(parts))
+}
+
+// hashFile calcula o digest do arquivo local com partes de partSize bytes
+func hashFile(path string, partSize int64) (*objectDigest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	digest := newObjectDigest(partSize)
+	if _, err := io.Copy(digest, file); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// uploadPartSize reproduz o tamanho de parte do uploader: o padrão, ampliado
+// quando o arquivo excederia o limite de partes
+func (s *S3Service) uploadPartSize(size int64) int64 {
+	partSize := s.uploader.PartSize
+	if partSize == 0 {
+		partSize = s3manager.DefaultUploadPartSize
+	}
+	maxParts := s.uploader.MaxUploadParts
+	if maxParts == 0 {
+		maxParts = s3manager.MaxUploadParts
+	}
+	if size/partSize >= int64(maxParts) {
+		partSize = size/int64(maxParts) + 1
+	}
+	return partSize
+}
+
+// metadataValue busca um metadado sem diferenciar maiúsculas, pois o SDK
+// normaliza as chaves retornadas pelo HeadObject (ex.: "Sha256")
+func metadataValue(metadata map[string]*string, key string) string {
+	for name, value := range metadata {
+		if strings.EqualFold(name, key) {
+			return aws.StringValue(value)
+		}
+	}
+	return ""
+}
+
+// verifyDownload confere o arquivo baixado com o HeadObject feito antes do
+// download: o tamanho sempre e, quando disponível, o SHA-256 gravado pelo
+// worker (sha256, dos metadados ou da tag), o checksum SHA-256 do S3 (se não
+// for composto) ou o ETag de upload simples (que só é o MD5 do conteúdo sem
+// criptografia KMS ou SSE-C)
+func verifyDownload(key string, head *s3.HeadObjectOutput, expected string, localPath string) error {
+	digest, err := hashFile(localPath, s3manager.DefaultUploadPartSize)
+	if err != nil {
+		return err
+	}
+
+	if expected := aws.Int64Value(head.ContentLength); digest.size != expected {
+		return &IntegrityError{Key: key, Reason: fmt.Sprintf("tamanho %d difere do esperado %d", digest.size, expected)}
+	}
+
+	etag := strings.Trim(aws.StringValue(head.ETag), `"`)
+	checksum := aws.StringValue(head.ChecksumSHA256)
+	switch {
+	case expected != "":
+		if !strings.EqualFold(digest.SHA256(), expected) {
+			return &IntegrityError{Key: key, Reason: "SHA-256 difere do registrado no objeto"}
+		}
+	case checksum != "" && !strings.Contains(checksum, "-"):
+		if base64.StdEncoding.EncodeToString(digest.sha256.Sum(nil)) != checksum {
+			return &IntegrityError{Key: key, Reason: "SHA-256 difere do checksum do S3"}
+		}
+	case etag != "" && !strings.Contains(etag, "-") && etagIsContentMD5(head):
+		if digest.etag(false) != etag {
+			return &IntegrityError{Key: key, Reason: "MD5 difere do ETag do objeto"}
+		}
+	}
+	return nil
+}
+
+// etagIsContentMD5 informa se o ETag do objeto deriva do MD5 do conteúdo,
+// o que não vale com criptografia KMS nem SSE-C
+func etagIsContentMD5(head *s3.HeadObjectOutput) bool {
+	return aws.StringValue(head.ServerSideEncryption) != s3.ServerSideEncryptionAwsKms && head.SSECustomerAlgorithm == nil
+}
+
+// verifyUpload confere via HeadObject o objeto enviado: tamanho, ETag
+// previsto a partir do conteúdo, o checksum SHA-256 do S3 quando não é
+// composto (upload simples com ChecksumSHA256) e, com withMetadata, o
+// SHA-256 gravado nos metadados. Com criptografia KMS ou SSE-C o ETag não é
+// o MD5 do conteúdo e não é comparado. O s3manager ignora os checksums
+// informados no upload multipart; cada UploadPart leva apenas o Content-MD5
+// que o SDK calcula sobre o buffer da parte, e um objeto multipart com KMS
+// só é conferido pelo tamanho (e pelo SHA-256 dos metadados, se houver).
+func (s *S3Service) verifyUpload(key string, digest *objectDigest, etag string, withMetadata bool) (*s3.HeadObjectOutput, error) {
+	head, err := s.s3Client.HeadObject(&s3.HeadObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		ChecksumMode: aws.String(s3.ChecksumModeEnabled),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if size := aws.Int64Value(head.ContentLength); size != digest.size {
+		return nil, &IntegrityError{Key: key, Reason: fmt.Sprintf("objeto com %d bytes, esperado %d", size, digest.size)}
+	}
+	actual := strings.Trim(aws.StringValue(head.ETag), `"`)
+	if etagIsContentMD5(head) && actual != etag {
+		return nil, &IntegrityError{Key: key, Reason: fmt.Sprintf("ETag %s difere do esperado %s", actual, etag)}
+	}
+	if checksum := aws.StringValue(head.ChecksumSHA256); checksum != "" && !strings.Contains(checksum, "-") &&
+		checksum != base64.StdEncoding.EncodeToString(digest.sha256.Sum(nil)) {
+		return nil, &IntegrityError{Key: key, Reason: "SHA-256 difere do checksum do S3"}
+	}
+	if checksum := metadataValue(head.Metadata, ChecksumMetadataKey); withMetadata && !strings.EqualFold(checksum, digest.SHA256()) {
+		return nil, &IntegrityError{Key: key, Reason: "SHA-256 dos metadados difere do conteúdo enviado"}
+	}
+	return head, nil
+}
@@ -1,11 +1,19 @@
 package storage
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -17,9 +25,16 @@ type S3Service struct {
 	uploader   *s3manager.Uploader
 	downloader *s3manager.Downloader
 	bucket     string
+	config     S3Config
 }
 
 func NewS3Service(bucket string) (*S3Service, error) {
+	return NewS3ServiceWithConfig(bucket, S3Config{})
+}
+
+// NewS3ServiceWithConfig cria o serviço aplicando a classe de armazenamento
+// e a criptografia informadas a todos os objetos enviados
+func NewS3ServiceWithConfig(bucket string, config S3Config) (*S3Service, error) {
 	endpoint := os.Getenv("LOCALSTACK_ENDPOINT")
 	if endpoint == "" {
 		endpoint = "http://localhost:4566"
@@ -43,38 +58,308 @@ func NewS3Service(bucket string) (*S3Service, error) {
 		uploader:   s3manager.NewUploader(sess),
 		downloader: s3manager.NewDownloader(sess),
 		bucket:     bucket,
+		config:     config,
 	}, nil
 }
 
 func (s *S3Service) DownloadVideo(key, localPath string) error {
+	return s.DownloadObject(s.bucket, key, localPath)
+}
+
+// DownloadObject baixa um objeto de qualquer bucket para o caminho local
+// informado e confere o arquivo com o HeadObject feito antes do download. O
+// download é condicionado ao ETag consultado, para que uma substituição do
+// objeto no meio da transferência falhe em vez de misturar versões. Em falha
+// de integridade o arquivo local é removido e retorna *IntegrityError.
+func (s *S3Service) DownloadObject(bucket, key, localPath string) error {
 	file, err := os.Create(localPath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
+	head, err := s.s3Client.HeadObject(&s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: aws.String(s3.ChecksumModeEnabled),
+	})
+	if err != nil {
+		return err
+	}
+
 	_, err = s.downloader.Download(file, &s3.GetObjectInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		IfMatch: head.ETag,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := verifyDownload(key, head, s.objectChecksum(bucket, key, head), localPath); err != nil {
+		os.Remove(localPath)
+		return err
+	}
+	return nil
+}
+
+// objectChecksum retorna o SHA-256 (hex) gravado pelo worker no objeto: nos
+// metadados (upload de arquivo) ou na tag de mesmo nome (upload em
+// streaming). Sem permissão para ler as tags, o objeto é conferido pelo
+// checksum do S3 ou pelo ETag.
+func (s *S3Service) objectChecksum(bucket, key string, head *s3.HeadObjectOutput) string {
+	if checksum := metadataValue(head.Metadata, ChecksumMetadataKey); checksum != "" {
+		return checksum
+	}
+	output, err := s.s3Client.GetObjectTagging(&s3.GetObjectTaggingInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: head.VersionId,
+	})
+	if err != nil {
+		return ""
+	}
+	for _, tag := range output.TagSet {
+		if aws.StringValue(tag.Key) == ChecksumMetadataKey {
+			return aws.StringValue(tag.Value)
+		}
+	}
+	return ""
+}
+
+// ParseS3URI separa uma URI no formato s3://bucket/chave em bucket e chave
+func ParseS3URI(uri string) (bucket, key string, ok bool) {
+	const prefix = "s3://"
+	if !strings.HasPrefix(uri, prefix) {
+		return "", "", false
+	}
+
+	bucket, key, found := strings.Cut(uri[len(prefix):], "/")
+	if !found || bucket == "" || key == "" {
+		return "", "", false
+	}
+	return bucket, key, true
+}
+
+// ObjectSize consulta o tamanho do objeto via HeadObject, sem baixá-lo
+func (s *S3Service) ObjectSize(key string) (int64, error) {
+	head, err := s.s3Client.HeadObject(&s3.HeadObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
 	})
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return aws.Int64Value(head.ContentLength), nil
+}
+
+// ObjectExists consulta via HeadObject se há um objeto na chave informada
+func (s *S3Service) ObjectExists(key string) (bool, error) {
+	_, err := s.s3Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if failure, ok := err.(awserr.RequestFailure); ok && failure.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
 }
 
 func (s *S3Service) UploadZip(localPath, key string) error {
+	return s.UploadArchive(localPath, key, UploadOptions{
+		ContentType: ArchiveContentType(ArchiveFormatZip),
+		Filename:    filepath.Base(key),
+	})
+}
+
+// UploadArchive envia o arquivo local com o tipo, nome, metadados e tags de
+// options, os checksums MD5 e SHA-256 (validados pelo S3 em upload simples)
+// e o SHA-256 nos metadados, e confere o objeto via HeadObject.
+// Divergências retornam *IntegrityError e removem o objeto enviado.
+func (s *S3Service) UploadArchive(localPath, key string, options UploadOptions) error {
 	file, err := os.Open(localPath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	_, err = s.uploader.Upload(&s3manager.UploadInput{
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	partSize := s.uploadPartSize(info.Size())
+	digest, err := hashFile(localPath, partSize)
+	if err != nil {
+		return err
+	}
+
+	input := &s3manager.UploadInput{
+		Bucket:             aws.String(s.bucket),
+		Key:                aws.String(key),
+		Body:               file,
+		ContentType:        aws.String(options.ContentType),
+		ContentDisposition: options.contentDisposition(),
+		ContentMD5:         aws.String(base64.StdEncoding.EncodeToString(digest.md5.Sum(nil))),
+		ChecksumSHA256:     aws.String(base64.StdEncoding.EncodeToString(digest.sha256.Sum(nil))),
+		Metadata:           options.metadata(digest.SHA256()),
+		Tagging:            options.tagging(),
+	}
+	s.config.applyTo(input)
+	if _, err := s.uploader.Upload(input); err != nil {
+		return err
+	}
+
+	// O uploader só usa multipart quando o arquivo excede uma parte
+	_, err = s.verifyUpload(key, digest, digest.etag(digest.size > partSize), true)
+	return s.discardCorrupted(key, err)
+}
+
+// discardCorrupted remove da chave de saída o objeto que não passou na
+// conferência, para que downloads posteriores não recebam dados corrompidos.
+// Retorna o erro da conferência, acrescido da falha na remoção se houver;
+// outros erros não removem o objeto.
+func (s *S3Service) discardCorrupted(key string, err error) error {
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) {
+		return err
+	}
+	if deleteErr := s.DeleteObject(key); deleteErr != nil {
+		return fmt.Errorf("%w (objeto não removido: %v)", err, deleteErr)
+	}
+	return err
+}
+
+// DeleteObject remove o objeto do bucket (ex.: partes de um pacote cujo
+// processamento falhou)
+func (s *S3Service) DeleteObject(key string) error {
+	_, err := s.s3Client.DeleteObject(&s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
-		Body:   file,
 	})
 	return err
 }
 
+// S3UploadWriter envia ao S3 os bytes escritos, via upload multipart
+// alimentado por um io.Pipe, sem gravar o objeto em disco. Diferente de
+// UploadArchive, o SHA-256 e os valores de SetTag (ex.: frame-count) não vão
+// nos metadados, fixados no início do upload, mas nas tags do objeto,
+// ocupando parte do limite de 10 tags; DownloadObject confere o SHA-256 nos
+// dois lugares.
+type S3UploadWriter struct {
+	pipe    *io.PipeWriter
+	done    chan error
+	once    sync.Once
+	err     error
+	written int64
+	digest  *objectDigest
+	options UploadOptions
+	tags    map[string]string // tags definidas durante a escrita
+}
+
+// NewUploadWriter inicia o upload em streaming para a chave informada, com o
+// tipo, nome, metadados e tags de options. O upload só é concluído em Close; Abort interrompe
+// o envio e o uploader aborta o upload multipart, descartando as partes já
+// enviadas. Os bytes são resumidos durante a escrita e, concluído o upload,
+// o objeto é conferido pelo tamanho e ETag (e removido se divergir) e recebe
+// o SHA-256 como tag.
+func (s *S3Service) NewUploadWriter(key string, options UploadOptions) *S3UploadWriter {
+	reader, writer := io.Pipe()
+	upload := &S3UploadWriter{
+		pipe:    writer,
+		done:    make(chan error, 1),
+		digest:  newObjectDigest(s.uploadPartSize(0)),
+		options: options,
+		tags:    map[string]string{},
+	}
+
+	input := &s3manager.UploadInput{
+		Bucket:             aws.String(s.bucket),
+		Key:                aws.String(key),
+		Body:               reader,
+		ContentType:        aws.String(options.ContentType),
+		ContentDisposition: options.contentDisposition(),
+		Metadata:           options.metadata(""),
+		Tagging:            options.tagging(),
+	}
+	s.config.applyTo(input)
+
+	go func() {
+		_, err := s.uploader.Upload(input)
+		// Falhas do upload interrompem as escritas pendentes
+		reader.CloseWithError(err)
+		if err == nil {
+			err = s.finishUploadWriter(key, upload.options, upload.tags, upload.digest)
+		}
+		upload.done <- err
+	}()
+	return upload
+}
+
+// finishUploadWriter confere o objeto enviado em streaming e grava como
+// tags o SHA-256 e as tags definidas durante a escrita, só conhecidos ao fim
+// dos dados. Os metadados são fixados no início do upload e só poderiam ser
+// alterados copiando o objeto, o que dobra a escrita e não é aceito pelo
+// CopyObject acima de 5 GiB; as tags são substituídas sem reescrever o
+// conteúdo, em qualquer tamanho.
+func (s *S3Service) finishUploadWriter(key string, options UploadOptions, tags map[string]string, digest *objectDigest) error {
+	// Sem tamanho conhecido, o uploader lê partes inteiras e só usa upload
+	// simples quando a primeira leitura termina antes de completar a parte
+	if _, err := s.verifyUpload(key, digest, digest.etag(len(digest.parts) > 0), false); err != nil {
+		return s.discardCorrupted(key, err)
+	}
+
+	extra := maps.Clone(tags)
+	extra[ChecksumMetadataKey] = digest.SHA256()
+	_, err := s.s3Client.PutObjectTagging(&s3.PutObjectTaggingInput{
+		Bucket:  aws.String(s.bucket),
+		Key:     aws.String(key),
+		Tagging: &s3.Tagging{TagSet: options.tagSet(extra)},
+	})
+	return err
+}
+
+// SetTag define uma tag do objeto conhecida apenas durante a escrita (ex.:
+// a quantidade de frames), gravada com o SHA-256 ao concluir o upload; deve
+// ser chamado antes de Close. Tem prioridade sobre as tags de UploadOptions
+// no limite de 10 tags do S3.
+func (u *S3UploadWriter) SetTag(name, value string) {
+	u.tags[name] = value
+}
+
+// Write envia os bytes ao upload; retorna o erro do upload se ele falhou
+func (u *S3UploadWriter) Write(p []byte) (int, error) {
+	n, err := u.pipe.Write(p)
+	u.digest.Write(p[:n])
+	u.written += int64(n)
+	return n, err
+}
+
+// Size retorna a quantidade de bytes enviados ao upload
+func (u *S3UploadWriter) Size() int64 {
+	return u.written
+}
+
+// Close sinaliza o fim dos dados e aguarda a conclusão do upload
+func (u *S3UploadWriter) Close() error {
+	u.pipe.Close()
+	return u.wait()
+}
+
+// Abort interrompe o upload com o erro informado e aguarda o uploader
+// abortar o upload multipart
+func (u *S3UploadWriter) Abort(err error) {
+	u.pipe.CloseWithError(err)
+	u.wait()
+}
+
+// wait aguarda o resultado do upload, que pode ser consultado mais de uma vez
+func (u *S3UploadWriter) wait() error {
+	u.once.Do(func() {
+		u.err = <-u.done
+	})
+	return u.err
+}
+
 func (s *S3Service) ListVideos() ([]string, error) {
 	result, err := s.s3Client.ListObjects(&s3.ListObjectsInput{
 		Bucket: aws.String(s.bucket),
@@ -1,9 +1,26 @@
 package storage
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 const (
@@ -356,6 +373,25 @@ func TestListVideosEmptyBucket(t *testing.T) {
 	}
 }
 
+func TestObjectSizeConnectionError(t *testing.T) {
+	os.Setenv("LOCALSTACK_ENDPOINT", testEndpoint)
+	defer os.Unsetenv("LOCALSTACK_ENDPOINT")
+
+	service, err := NewS3Service(testBucket)
+	if err != nil {
+		t.Fatalf(errorCreateS3, err)
+	}
+
+	// Sem conexão S3 real, esperamos erro
+	size, err := service.ObjectSize(testVideoFile)
+	if err == nil {
+		t.Error(errorS3Connection)
+	}
+	if size != 0 {
+		t.Errorf("esperado tamanho 0 em caso de erro, mas obteve %d", size)
+	}
+}
+
 func TestListVideosFilteringLogic(t *testing.T) {
 	// Testar a lógica de filtragem de vídeos sem S3 real
 	service := &S3Service{
@@ -465,6 +501,40 @@ func TestIsVideoFileExtensionHandling(t *testing.T) {
 	}
 }
 
+func TestParseS3URI(t *testing.T) {
+	testCases := []struct {
+		uri    string
+		bucket string
+		key    string
+		ok     bool
+	}{
+		{"s3://assets/watermarks/logo.png", "assets", "watermarks/logo.png", true},
+		{"s3://assets/logo.png", "assets", "logo.png", true},
+		{"s3://assets/", "", "", false},
+		{"s3://assets", "", "", false},
+		{"s3:///logo.png", "", "", false},
+		{"/local/logo.png", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		bucket, key, ok := ParseS3URI(tc.uri)
+		if bucket != tc.bucket || key != tc.key || ok != tc.ok {
+			t.Errorf("ParseS3URI(%q) = (%q, %q, %v), esperado (%q, %q, %v)",
+				tc.uri, bucket, key, ok, tc.bucket, tc.key, tc.ok)
+		}
+	}
+}
+
+func TestDownloadObjectCreateFileError(t *testing.T) {
+	service := &S3Service{bucket: testBucket}
+
+	err := service.DownloadObject("outro-bucket", "test-key", string([]byte{0}))
+	if err == nil {
+		t.Errorf("esperado erro ao tentar criar arquivo em caminho inválido")
+	}
+}
+
 // Benchmark para testar performance da validação de arquivos
 func BenchmarkIsVideoFile(b *testing.B) {
 	service := &S3Service{}
@@ -483,3 +553,634 @@ func BenchmarkIsVideoFile(b *testing.B) {
 		}
 	}
 }
+
+// fakeS3Object é um objeto gravado no fakeS3
+type fakeS3Object struct {
+	data     []byte
+	etag     string
+	metadata http.Header
+	headers  http.Header // cabeçalhos do objeto: tipo, nome, tags, classe e criptografia
+}
+
+// fakeS3ObjectHeaders são os cabeçalhos da requisição guardados com o objeto
+var fakeS3ObjectHeaders = []string{"Content-Type", "Content-Disposition", "X-Amz-Tagging", "X-Amz-Storage-Class", "X-Amz-Server-Side-Encryption"}
+
+// objectHeaders separa os cabeçalhos guardados com o objeto
+func objectHeaders(header http.Header) http.Header {
+	headers := http.Header{}
+	for _, name := range fakeS3ObjectHeaders {
+		if value := header.Get(name); value != "" {
+			headers.Set(name, value)
+		}
+	}
+	return headers
+}
+
+// fakeS3Tagging é o corpo do PutObjectTagging
+type fakeS3Tagging struct {
+	Tags []struct {
+		Key   string
+		Value string
+	} `xml:"TagSet>Tag"`
+}
+
+// fakeS3 simula o S3 para PutObject, Put/GetObjectTagging, HeadObject,
+// GetObject (com Range) e DeleteObject, guardando os objetos por caminho (/bucket/chave)
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]*fakeS3Object
+}
+
+// put grava o objeto com o ETag do conteúdo e os metadados informados
+func (f *fakeS3) put(path string, data []byte, metadata http.Header) {
+	sum := md5.Sum(data)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[path] = &fakeS3Object{data: data, etag: `"` + hex.EncodeToString(sum[:]) + `"`, metadata: metadata}
+}
+
+func (f *fakeS3) get(path string) *fakeS3Object {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.objects[path]
+}
+
+// objectMetadata separa os cabeçalhos de metadados (x-amz-meta-*) da requisição
+func objectMetadata(header http.Header) http.Header {
+	metadata := http.Header{}
+	for name, values := range header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+			metadata[name] = values
+		}
+	}
+	return metadata
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		if r.URL.Query().Has("tagging") {
+			object := f.get(r.URL.Path)
+			if object == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var tagging fakeS3Tagging
+			if err := xml.NewDecoder(r.Body).Decode(&tagging); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			// Como no S3, o conjunto de tags é substituído por inteiro
+			values := url.Values{}
+			for _, tag := range tagging.Tags {
+				values.Set(tag.Key, tag.Value)
+			}
+			f.mu.Lock()
+			object.headers.Set("X-Amz-Tagging", values.Encode())
+			f.mu.Unlock()
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		if expected := r.Header.Get("Content-Md5"); expected != "" {
+			sum := md5.Sum(data)
+			if base64.StdEncoding.EncodeToString(sum[:]) != expected {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`<Error><Code>BadDigest</Code><Message>MD5 divergente</Message></Error>`))
+				return
+			}
+		}
+		f.put(r.URL.Path, data, objectMetadata(r.Header))
+		f.get(r.URL.Path).headers = objectHeaders(r.Header)
+		w.Header().Set("ETag", f.get(r.URL.Path).etag)
+	case http.MethodHead, http.MethodGet:
+		object := f.get(r.URL.Path)
+		if object == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Has("tagging") {
+			values, _ := url.ParseQuery(object.headers.Get("X-Amz-Tagging"))
+			fmt.Fprint(w, "<Tagging><TagSet>")
+			for name := range values {
+				fmt.Fprintf(w, "<Tag><Key>%s</Key><Value>%s</Value></Tag>", name, values.Get(name))
+			}
+			fmt.Fprint(w, "</TagSet></Tagging>")
+			return
+		}
+		for name, values := range object.metadata {
+			w.Header()[name] = values
+		}
+		w.Header().Set("ETag", object.etag)
+		data := object.data
+		status := http.StatusOK
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil && r.Method == http.MethodGet {
+			end = min(end, len(data)-1)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+			data = data[start : end+1]
+			status = http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		f.mu.Lock()
+		delete(f.objects, r.URL.Path)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newFakeS3 cria o S3Service apontando para um fakeS3
+func newFakeS3(t *testing.T, handler func(http.ResponseWriter, *http.Request, *fakeS3) bool) (*S3Service, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{objects: map[string]*fakeS3Object{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler != nil && handler(w, r, fake) {
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("LOCALSTACK_ENDPOINT", server.URL)
+	service, err := NewS3Service(testBucket)
+	if err != nil {
+		t.Fatalf(errorCreateS3, err)
+	}
+	return service, fake
+}
+
+// newTestS3Server simula o endpoint do S3, respondendo com o status
+// informado e registrando o corpo recebido no PutObject
+func newTestS3Server(t *testing.T, status int, body *[]byte) *S3Service {
+	t.Helper()
+	service, _ := newFakeS3(t, func(w http.ResponseWriter, r *http.Request, _ *fakeS3) bool {
+		if status != http.StatusOK {
+			io.ReadAll(r.Body)
+			w.WriteHeader(status)
+			w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>negado</Message></Error>`))
+			return true
+		}
+		if r.Method == http.MethodPut && !r.URL.Query().Has("tagging") {
+			data, _ := io.ReadAll(r.Body)
+			*body = data
+			r.Body = io.NopCloser(bytes.NewReader(data))
+		}
+		return false
+	})
+	return service
+}
+
+func TestUploadWriterSuccess(t *testing.T) {
+	var received []byte
+	service := newTestS3Server(t, http.StatusOK, &received)
+
+	upload := service.NewUploadWriter("processed/video_frames.zip", UploadOptions{ContentType: "application/zip"})
+	if _, err := upload.Write([]byte("conteúdo ")); err != nil {
+		t.Fatalf("erro inesperado na escrita: %v", err)
+	}
+	if _, err := upload.Write([]byte("em streaming")); err != nil {
+		t.Fatalf("erro inesperado na escrita: %v", err)
+	}
+	if err := upload.Close(); err != nil {
+		t.Fatalf("erro inesperado ao concluir upload: %v", err)
+	}
+	if string(received) != "conteúdo em streaming" {
+		t.Errorf("corpo inesperado no S3: %q", received)
+	}
+	if upload.Size() != int64(len(received)) {
+		t.Errorf("esperado tamanho %d, mas obteve %d", len(received), upload.Size())
+	}
+}
+
+func TestUploadWriterUploadError(t *testing.T) {
+	var received []byte
+	service := newTestS3Server(t, http.StatusForbidden, &received)
+
+	upload := service.NewUploadWriter("processed/video_frames.zip", UploadOptions{ContentType: "application/zip"})
+	upload.Write([]byte("dados"))
+	if err := upload.Close(); err == nil {
+		t.Error("esperado erro do upload ao concluir")
+	}
+
+	// Após a falha, novas escritas recebem o erro do upload
+	if _, err := upload.Write([]byte("mais dados")); err == nil {
+		t.Error("esperado erro ao escrever após falha do upload")
+	}
+}
+
+func TestUploadWriterAbort(t *testing.T) {
+	var received []byte
+	service := newTestS3Server(t, http.StatusOK, &received)
+
+	upload := service.NewUploadWriter("processed/video_frames.zip", UploadOptions{ContentType: "application/zip"})
+	upload.Write([]byte("parcial"))
+	upload.Abort(errors.New("falha na extração"))
+
+	if received != nil {
+		t.Errorf("esperado upload abortado sem objeto gravado, mas recebeu %q", received)
+	}
+}
+
+func TestDeleteObject(t *testing.T) {
+	var received []byte
+	service := newTestS3Server(t, http.StatusOK, &received)
+
+	if err := service.DeleteObject("processed/video_frames.part001.zip"); err != nil {
+		t.Errorf("erro inesperado ao remover objeto: %v", err)
+	}
+}
+
+func TestUploadArchiveChecksumMetadata(t *testing.T) {
+	service, fake := newFakeS3(t, nil)
+	localPath := filepath.Join(t.TempDir(), "video_frames.zip")
+	content := []byte("conteúdo do pacote")
+	os.WriteFile(localPath, content, 0644)
+
+	if err := service.UploadArchive(localPath, "processed/video_frames.zip", UploadOptions{ContentType: "application/zip"}); err != nil {
+		t.Fatalf("erro inesperado no upload: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	object := fake.get("/" + testBucket + "/processed/video_frames.zip")
+	if object == nil || object.metadata.Get("X-Amz-Meta-Sha256") != hex.EncodeToString(sum[:]) {
+		t.Errorf("esperado SHA-256 nos metadados do objeto, mas obteve %v", object)
+	}
+}
+
+func TestUploadArchiveIntegrityError(t *testing.T) {
+	// O S3 responde ao HeadObject com um ETag diferente do conteúdo enviado
+	var deleted []string
+	service, fake := newFakeS3(t, func(w http.ResponseWriter, r *http.Request, _ *fakeS3) bool {
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Content-Length", "19")
+			w.Header().Set("ETag", `"00000000000000000000000000000000"`)
+			return true
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+		}
+		return false
+	})
+	localPath := filepath.Join(t.TempDir(), "video_frames.zip")
+	os.WriteFile(localPath, []byte("conteúdo do pacote"), 0644)
+
+	err := service.UploadArchive(localPath, "processed/video_frames.zip", UploadOptions{ContentType: "application/zip"})
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) {
+		t.Fatalf("esperado IntegrityError, mas obteve %v", err)
+	}
+	if integrityErr.Key != "processed/video_frames.zip" {
+		t.Errorf("chave inesperada no erro: %s", integrityErr.Key)
+	}
+	// O objeto que não passou na conferência é removido da chave de saída
+	if len(deleted) != 1 || deleted[0] != "/"+testBucket+"/processed/video_frames.zip" {
+		t.Errorf("esperada remoção do objeto corrompido, mas removeu %v", deleted)
+	}
+	if fake.get("/"+testBucket+"/processed/video_frames.zip") != nil {
+		t.Error("esperado objeto corrompido fora do bucket")
+	}
+}
+
+func TestUploadWriterIntegrityError(t *testing.T) {
+	var deleted []string
+	service, fake := newFakeS3(t, func(w http.ResponseWriter, r *http.Request, _ *fakeS3) bool {
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Content-Length", "27")
+			w.Header().Set("ETag", `"00000000000000000000000000000000"`)
+			return true
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+		}
+		return false
+	})
+
+	upload := service.NewUploadWriter("processed/video_frames.zip", UploadOptions{ContentType: "application/zip"})
+	upload.Write([]byte("pacote enviado em streaming"))
+	var integrityErr *IntegrityError
+	if err := upload.Close(); !errors.As(err, &integrityErr) {
+		t.Fatalf("esperado IntegrityError, mas obteve %v", err)
+	}
+	if len(deleted) != 1 || fake.get("/"+testBucket+"/processed/video_frames.zip") != nil {
+		t.Errorf("esperada remoção do objeto corrompido, mas removeu %v", deleted)
+	}
+}
+
+func TestUploadWriterChecksumTag(t *testing.T) {
+	service, fake := newFakeS3(t, nil)
+	content := []byte("pacote enviado em streaming")
+
+	upload := service.NewUploadWriter("processed/video_frames.zip", UploadOptions{ContentType: "application/zip"})
+	upload.Write(content)
+	if err := upload.Close(); err != nil {
+		t.Fatalf("erro inesperado ao concluir upload: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	object := fake.get("/" + testBucket + "/processed/video_frames.zip")
+	if object == nil || object.headers.Get("X-Amz-Tagging") != "sha256="+hex.EncodeToString(sum[:]) {
+		t.Errorf("esperado SHA-256 nas tags após o upload em streaming, mas obteve %v", object)
+	}
+	if !bytes.Equal(object.data, content) {
+		t.Errorf("conteúdo alterado ao gravar as tags: %q", object.data)
+	}
+}
+
+func TestFinishUploadWriterLargeObject(t *testing.T) {
+	// Objeto acima de 5 GiB, limite do CopyObject: o HeadObject confirma o
+	// tamanho e o ETag multipart e as tags são gravadas sem copiar o objeto
+	digest := newObjectDigest(5 << 20)
+	digest.Write([]byte("início do pacote"))
+	digest.size = 6 << 30
+	digest.parts = append(digest.parts, make([]byte, md5.Size))
+	var copied bool
+	var tagging fakeS3Tagging
+	service, _ := newFakeS3(t, func(w http.ResponseWriter, r *http.Request, _ *fakeS3) bool {
+		switch {
+		case r.Header.Get("X-Amz-Copy-Source") != "":
+			copied = true
+		case r.Method == http.MethodHead:
+			w.Header().Set("Content-Length", strconv.FormatInt(digest.size, 10))
+			w.Header().Set("ETag", `"`+digest.etag(true)+`"`)
+		case r.Method == http.MethodPut && r.URL.Query().Has("tagging"):
+			xml.NewDecoder(r.Body).Decode(&tagging)
+		default:
+			return false
+		}
+		return true
+	})
+
+	options := UploadOptions{ContentType: "application/zip", Tags: map[string]string{"type": "frames"}}
+	if err := service.finishUploadWriter("processed/video_frames.zip", options, map[string]string{"frame-count": "120000"}, digest); err != nil {
+		t.Fatalf("erro inesperado ao concluir upload: %v", err)
+	}
+	if copied {
+		t.Error("esperado objeto concluído sem CopyObject")
+	}
+	tags := map[string]string{}
+	for _, tag := range tagging.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if tags[ChecksumMetadataKey] != digest.SHA256() || tags["frame-count"] != "120000" || tags["type"] != "frames" {
+		t.Errorf("tags inesperadas: %v", tags)
+	}
+}
+
+func TestDownloadObjectVerified(t *testing.T) {
+	service, fake := newFakeS3(t, nil)
+	content := []byte("conteúdo do vídeo")
+	sum := sha256.Sum256(content)
+	fake.put("/"+testBucket+"/videos/a.mp4", content, http.Header{"X-Amz-Meta-Sha256": {hex.EncodeToString(sum[:])}})
+	fake.put("/"+testBucket+"/videos/b.mp4", content, http.Header{})
+
+	for _, key := range []string{"videos/a.mp4", "videos/b.mp4"} {
+		localPath := filepath.Join(t.TempDir(), testVideoFile)
+		if err := service.DownloadVideo(key, localPath); err != nil {
+			t.Fatalf("%s: erro inesperado no download: %v", key, err)
+		}
+		if data, _ := os.ReadFile(localPath); !bytes.Equal(data, content) {
+			t.Errorf("%s: conteúdo baixado diferente: %q", key, data)
+		}
+	}
+}
+
+func TestDownloadObjectChecksumTag(t *testing.T) {
+	// Pacotes enviados em streaming têm o SHA-256 na tag, não nos metadados
+	service, fake := newFakeS3(t, nil)
+	content := []byte("pacote enviado em streaming")
+	sum := sha256.Sum256(content)
+	for name, checksum := range map[string]string{"ok.zip": hex.EncodeToString(sum[:]), "corrompido.zip": strings.Repeat("0", 64)} {
+		fake.put("/"+testBucket+"/processed/"+name, content, http.Header{})
+		fake.get("/" + testBucket + "/processed/" + name).headers = http.Header{"X-Amz-Tagging": {"sha256=" + checksum}}
+	}
+
+	if err := service.DownloadVideo("processed/ok.zip", filepath.Join(t.TempDir(), "ok.zip")); err != nil {
+		t.Errorf("erro inesperado com o SHA-256 da tag: %v", err)
+	}
+	err := service.DownloadVideo("processed/corrompido.zip", filepath.Join(t.TempDir(), "corrompido.zip"))
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) {
+		t.Errorf("esperado IntegrityError pelo SHA-256 da tag, mas obteve %v", err)
+	}
+}
+
+func TestDownloadObjectIntegrityError(t *testing.T) {
+	service, fake := newFakeS3(t, nil)
+	content := []byte("conteúdo do vídeo")
+	// SHA-256 dos metadados não confere com o conteúdo
+	fake.put("/"+testBucket+"/videos/a.mp4", content, http.Header{"X-Amz-Meta-Sha256": {strings.Repeat("0", 64)}})
+	// Sem metadados, o ETag de upload simples deve ser o MD5 do conteúdo
+	fake.put("/"+testBucket+"/videos/b.mp4", content, http.Header{})
+	fake.get("/" + testBucket + "/videos/b.mp4").etag = `"00000000000000000000000000000000"`
+
+	for _, key := range []string{"videos/a.mp4", "videos/b.mp4"} {
+		localPath := filepath.Join(t.TempDir(), testVideoFile)
+		err := service.DownloadVideo(key, localPath)
+		var integrityErr *IntegrityError
+		if !errors.As(err, &integrityErr) {
+			t.Fatalf("%s: esperado IntegrityError, mas obteve %v", key, err)
+		}
+		if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+			t.Errorf("%s: esperado arquivo corrompido removido", key)
+		}
+	}
+}
+
+func TestObjectDigestETag(t *testing.T) {
+	data := []byte("0123456789")
+	digest := newObjectDigest(4)
+	digest.Write(data[:3])
+	digest.Write(data[3:])
+
+	single := md5.Sum(data)
+	if digest.etag(false) != hex.EncodeToString(single[:]) {
+		t.Errorf("ETag simples inesperado: %s", digest.etag(false))
+	}
+
+	combined := md5.New()
+	for _, part := range [][]byte{data[:4], data[4:8], data[8:]} {
+		sum := md5.Sum(part)
+		combined.Write(sum[:])
+	}
+	expected := hex.EncodeToString(combined.Sum(nil)) + "-3"
+	if digest.etag(true) != expected {
+		t.Errorf("esperado ETag multipart %s, mas obteve %s", expected, digest.etag(true))
+	}
+
+	// Conteúdo múltiplo do tamanho da parte não gera parte vazia no fim
+	exact := newObjectDigest(5)
+	exact.Write(data)
+	if etag := exact.etag(true); !strings.HasSuffix(etag, "-2") {
+		t.Errorf("esperadas 2 partes, mas obteve %s", etag)
+	}
+}
+
+func TestObjectExists(t *testing.T) {
+	service, fake := newFakeS3(t, nil)
+	fake.put("/"+testBucket+"/processed/video_frames.zip", []byte("zip"), http.Header{})
+
+	if found, err := service.ObjectExists("processed/video_frames.zip"); err != nil || !found {
+		t.Errorf("esperado objeto existente, mas obteve %v (%v)", found, err)
+	}
+	if found, err := service.ObjectExists("processed/outro.zip"); err != nil || found {
+		t.Errorf("esperado objeto inexistente sem erro, mas obteve %v (%v)", found, err)
+	}
+}
+
+func TestUploadArchiveOptions(t *testing.T) {
+	service, fake := newFakeS3(t, nil)
+	service.config = S3Config{StorageClass: s3.StorageClassStandardIa, ServerSideEncryption: s3.ServerSideEncryptionAes256}
+	localPath := filepath.Join(t.TempDir(), "video_frames.zip")
+	os.WriteFile(localPath, []byte("conteúdo do pacote"), 0644)
+
+	options := UploadOptions{
+		ContentType: "application/zip",
+		Filename:    "Férias 2023_frames.zip",
+		Metadata:    map[string]string{"video-id": "abc-123", "user": "joão"},
+		Tags:        map[string]string{"type": "frames", "user": "joão <admin>"},
+	}
+	if err := service.UploadArchive(localPath, "processed/video_frames.zip", options); err != nil {
+		t.Fatalf("erro inesperado no upload: %v", err)
+	}
+
+	object := fake.get("/" + testBucket + "/processed/video_frames.zip")
+	expected := map[string]string{
+		"Content-Type":                 "application/zip",
+		"Content-Disposition":          `attachment; filename*=utf-8''F%C3%A9rias%202023_frames.zip`,
+		"X-Amz-Tagging":                "type=frames&user=jo%C3%A3o+_admin_",
+		"X-Amz-Storage-Class":          s3.StorageClassStandardIa,
+		"X-Amz-Server-Side-Encryption": s3.ServerSideEncryptionAes256,
+	}
+	for name, value := range expected {
+		if object.headers.Get(name) != value {
+			t.Errorf("%s: esperado %q, mas obteve %q", name, value, object.headers.Get(name))
+		}
+	}
+	if object.metadata.Get("X-Amz-Meta-Video-Id") != "abc-123" || object.metadata.Get("X-Amz-Meta-User") != "=?utf-8?q?jo=C3=A3o?=" {
+		t.Errorf("metadados inesperados: %v", object.metadata)
+	}
+}
+
+func TestUploadWriterOptions(t *testing.T) {
+	service, fake := newFakeS3(t, nil)
+	service.config = S3Config{StorageClass: s3.StorageClassStandardIa}
+
+	upload := service.NewUploadWriter("processed/video_frames.zip", UploadOptions{
+		ContentType: "application/zip",
+		Filename:    "video_frames.zip",
+		Metadata:    map[string]string{"video-id": "abc-123"},
+		Tags:        map[string]string{"type": "frames"},
+	})
+	upload.Write([]byte("pacote enviado em streaming"))
+	upload.SetTag("frame-count", "3")
+	if err := upload.Close(); err != nil {
+		t.Fatalf("erro inesperado ao concluir upload: %v", err)
+	}
+
+	// As tags finais mantêm as do upload e as configurações não são alteradas
+	object := fake.get("/" + testBucket + "/processed/video_frames.zip")
+	if object.metadata.Get("X-Amz-Meta-Video-Id") != "abc-123" {
+		t.Errorf("metadados inesperados: %v", object.metadata)
+	}
+	tags, _ := url.ParseQuery(object.headers.Get("X-Amz-Tagging"))
+	if tags.Get("type") != "frames" || tags.Get("frame-count") != "3" || tags.Get("sha256") == "" {
+		t.Errorf("tags inesperadas: %v", tags)
+	}
+	if object.headers.Get("X-Amz-Storage-Class") != s3.StorageClassStandardIa ||
+		object.headers.Get("Content-Disposition") != `attachment; filename=video_frames.zip` {
+		t.Errorf("cabeçalhos inesperados: %v", object.headers)
+	}
+}
+
+func TestS3ConfigValidate(t *testing.T) {
+	valid := []S3Config{
+		{},
+		{StorageClass: s3.StorageClassIntelligentTiering},
+		{ServerSideEncryption: s3.ServerSideEncryptionAwsKms, KMSKeyID: "alias/frames"},
+	}
+	for _, config := range valid {
+		if err := config.Validate(); err != nil {
+			t.Errorf("esperada configuração válida %+v, mas obteve %v", config, err)
+		}
+	}
+
+	invalid := []S3Config{
+		{StorageClass: "BARATO"},
+		{ServerSideEncryption: "rot13"},
+		{ServerSideEncryption: s3.ServerSideEncryptionAes256, KMSKeyID: "alias/frames"},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("esperado erro para %+v", config)
+		}
+	}
+}
+
+func TestUploadOptionsTagSetLimit(t *testing.T) {
+	options := UploadOptions{Tags: map[string]string{}}
+	for i := range maxObjectTags {
+		options.Tags[fmt.Sprintf("tag%02d", i)] = "valor"
+	}
+
+	// As tags do fim do upload entram mesmo com as tags do job no limite
+	tags := options.tagSet(map[string]string{ChecksumMetadataKey: "abc", "tag00": "substituído"})
+	if len(tags) != maxObjectTags {
+		t.Fatalf("esperadas %d tags, mas obteve %d", maxObjectTags, len(tags))
+	}
+	values := map[string]string{}
+	for _, tag := range tags {
+		values[*tag.Key] = *tag.Value
+	}
+	if values[ChecksumMetadataKey] != "abc" || values["tag00"] != "substituído" || values["tag08"] != "valor" {
+		t.Errorf("tags inesperadas: %v", values)
+	}
+}
+
+func TestVerifyUploadEncryptedObject(t *testing.T) {
+	content := []byte("pacote criptografado")
+	digest := newObjectDigest(5 << 20)
+	digest.Write(content)
+	sum := sha256.Sum256(content)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected bool // IntegrityError esperado
+	}{
+		// Com SSE-C e KMS o ETag não é o MD5 do conteúdo e é ignorado
+		{"sse-c", map[string]string{"X-Amz-Server-Side-Encryption-Customer-Algorithm": "AES256"}, false},
+		{"kms", map[string]string{"X-Amz-Server-Side-Encryption": s3.ServerSideEncryptionAwsKms}, false},
+		// O checksum SHA-256 do S3 é conferido mesmo com criptografia
+		{"kms-checksum", map[string]string{
+			"X-Amz-Server-Side-Encryption": s3.ServerSideEncryptionAwsKms,
+			"X-Amz-Checksum-Sha256":        base64.StdEncoding.EncodeToString(sum[:]),
+		}, false},
+		{"kms-checksum-divergente", map[string]string{
+			"X-Amz-Server-Side-Encryption": s3.ServerSideEncryptionAwsKms,
+			"X-Amz-Checksum-Sha256":        base64.StdEncoding.EncodeToString(make([]byte, sha256.Size)),
+		}, true},
+	}
+	for _, tt := range tests {
+		var checksumMode string
+		service, _ := newFakeS3(t, func(w http.ResponseWriter, r *http.Request, _ *fakeS3) bool {
+			checksumMode = r.Header.Get("X-Amz-Checksum-Mode")
+			for name, value := range tt.headers {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Header().Set("ETag", `"00000000000000000000000000000000"`)
+			return true
+		})
+
+		_, err := service.verifyUpload("processed/video_frames.zip", digest, digest.etag(false), false)
+		var integrityErr *IntegrityError
+		if errors.As(err, &integrityErr) != tt.expected {
+			t.Errorf("%s: esperado IntegrityError %v, mas obteve %v", tt.name, tt.expected, err)
+		}
+		if checksumMode != s3.ChecksumModeEnabled {
+			t.Errorf("%s: esperado HeadObject com checksum mode, mas obteve %q", tt.name, checksumMode)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveEntryOverhead estima os bytes extras por entrada: cabeçalhos local e
+// do diretório central do ZIP ou, no TAR, o bloco de cabeçalho e o
+// preenchimento até múltiplo de 512
+const archiveEntryOverhead = 1024
+
+// archiveEndOverhead reserva o fim do arquivo (diretório central do ZIP ou
+// blocos finais do TAR)
+const archiveEndOverhead = 1024
+
+// ArchivePartName insere o número da parte antes da extensão do formato:
+// video_frames.zip -> video_frames.part001.zip
+func ArchivePartName(name, format string, part int) string {
+	extension := ArchiveExtension(format)
+	return fmt.Sprintf("%s.part%03d%s", strings.TrimSuffix(name, extension), part, extension)
+}
+
+// SplitArchive divide as entradas em partes de até maxSize bytes, cada uma um
+// arquivo válido por si só. O tamanho é estimado pelo conteúdo sem
+// compressão, então as partes comprimidas podem ficar abaixo do limite; uma
+// entrada (ou um grupo aberto por Fit) maior que o limite ocupa uma parte
+// sozinha, acima do limite.
+type SplitArchive struct {
+	maxSize int64
+	open    func(part int) (ArchiveWriter, error)
+
+	current ArchiveWriter
+	part    int
+	size    int64 // bytes estimados da parte atual
+	entries int   // entradas na parte atual
+	group   int   // entradas restantes do grupo aberto por Fit, mantidas na parte atual
+}
+
+// NewSplitArchive cria o arquivo dividido; open cria a parte de número
+// informado (a partir de 1) e o Close da parte a conclui. As partes são
+// abertas sob demanda, na primeira entrada que não cabe na anterior.
+func NewSplitArchive(maxSize int64, open func(part int) (ArchiveWriter, error)) *SplitArchive {
+	return &SplitArchive{maxSize: maxSize, open: open}
+}
+
+// Part retorna o número da parte atual (0 antes da primeira entrada)
+func (s *SplitArchive) Part() int {
+	return s.part
+}
+
+// Fit garante que entries entradas somando size bytes caibam na parte atual,
+// concluindo-a e abrindo a próxima quando necessário, e abre um grupo: as
+// próximas entries entradas vão para a parte atual mesmo que excedam o
+// limite. Mantém entradas relacionadas (ex.: as rendições de um frame) na
+// mesma parte.
+func (s *SplitArchive) Fit(entries int, size int64) error {
+	s.group = entries
+	size += int64(entries) * archiveEntryOverhead
+	if s.current != nil && (s.entries == 0 || s.size+size+archiveEndOverhead <= s.maxSize) {
+		return nil
+	}
+	if s.current != nil {
+		current := s.current
+		s.current = nil
+		if err := current.Close(); err != nil {
+			return err
+		}
+	}
+
+	current, err := s.open(s.part + 1)
+	if err != nil {
+		return err
+	}
+	s.current = current
+	s.part++
+	s.size = 0
+	s.entries = 0
+	return nil
+}
+
+func (s *SplitArchive) AddFile(filename string) error {
+	return s.AddFileAs(filename, filepath.Base(filename))
+}
+
+func (s *SplitArchive) AddFileAs(filename, name string) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	if err := s.reserve(info.Size()); err != nil {
+		return err
+	}
+	return s.current.AddFileAs(filename, name)
+}
+
+func (s *SplitArchive) AddEntry(name string, data []byte) error {
+	if err := s.reserve(int64(len(data))); err != nil {
+		return err
+	}
+	return s.current.AddEntry(name, data)
+}
+
+// reserve abre a parte que receberá a entrada e contabiliza o seu tamanho.
+// Entradas de um grupo aberto por Fit não trocam de parte.
+func (s *SplitArchive) reserve(size int64) error {
+	if s.group == 0 {
+		if err := s.Fit(1, size); err != nil {
+			return err
+		}
+	}
+	s.group--
+	s.size += size + archiveEntryOverhead
+	s.entries++
+	return nil
+}
+
+// Close conclui a última parte
+func (s *SplitArchive) Close() error {
+	if s.current == nil {
+		return nil
+	}
+	current := s.current
+	s.current = nil
+	return current.Close()
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchivePartName(t *testing.T) {
+	testCases := []struct {
+		name, format string
+		part         int
+		expected     string
+	}{
+		{"video_frames.zip", ArchiveFormatZip, 1, "video_frames.part001.zip"},
+		{"video_frames.tar.gz", ArchiveFormatTarGz, 12, "video_frames.part012.tar.gz"},
+		{"processed/video_frames.tar.zst", ArchiveFormatTarZst, 3, "processed/video_frames.part003.tar.zst"},
+	}
+	for _, tc := range testCases {
+		if got := ArchivePartName(tc.name, tc.format, tc.part); got != tc.expected {
+			t.Errorf("esperado %s, mas obteve %s", tc.expected, got)
+		}
+	}
+}
+
+// newTestSplitArchive cria um arquivo dividido em partes ZIP no diretório
+// temporário e retorna os caminhos das partes criadas
+func newTestSplitArchive(t *testing.T, maxSize int64) (*SplitArchive, *[]string) {
+	t.Helper()
+	dir := t.TempDir()
+	zipService := NewZipServiceWithConfig(ZipConfig{Compression: ZipCompressionStore})
+	var paths []string
+	split := NewSplitArchive(maxSize, func(part int) (ArchiveWriter, error) {
+		path := filepath.Join(dir, ArchivePartName("video_frames.zip", ArchiveFormatZip, part))
+		paths = append(paths, path)
+		return zipService.NewZipStream(path)
+	})
+	return split, &paths
+}
+
+// zipEntryNames abre a parte como um ZIP independente e lista as entradas
+func zipEntryNames(t *testing.T, path string) []string {
+	t.Helper()
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("parte %s não é um ZIP válido: %v", path, err)
+	}
+	defer reader.Close()
+
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	return names
+}
+
+func TestSplitArchiveRotatesParts(t *testing.T) {
+	split, paths := newTestSplitArchive(t, 10<<10)
+
+	frame := bytes.Repeat([]byte{0xAB}, 3<<10)
+	for i := 1; i <= 5; i++ {
+		if err := split.AddEntry(fmt.Sprintf("frame_%04d.png", i), frame); err != nil {
+			t.Fatalf("erro inesperado ao adicionar frame: %v", err)
+		}
+	}
+	if err := split.Close(); err != nil {
+		t.Fatalf("erro inesperado ao concluir: %v", err)
+	}
+
+	if len(*paths) != 3 || split.Part() != 3 {
+		t.Fatalf("esperadas 3 partes, mas obteve %d", len(*paths))
+	}
+	expected := [][]string{
+		{"frame_0001.png", "frame_0002.png"},
+		{"frame_0003.png", "frame_0004.png"},
+		{"frame_0005.png"},
+	}
+	for i, path := range *paths {
+		names := zipEntryNames(t, path)
+		if fmt.Sprint(names) != fmt.Sprint(expected[i]) {
+			t.Errorf("parte %d: esperado %v, mas obteve %v", i+1, expected[i], names)
+		}
+	}
+}
+
+func TestSplitArchiveOversizedEntry(t *testing.T) {
+	split, paths := newTestSplitArchive(t, 4<<10)
+
+	split.AddEntry("manifest.json", []byte("{}"))
+	if err := split.AddEntry("frame_0001.png", bytes.Repeat([]byte{1}, 16<<10)); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	split.AddEntry("SHA256SUMS", []byte("soma"))
+	split.Close()
+
+	// A entrada maior que o limite ocupa uma parte sozinha
+	if len(*paths) != 3 {
+		t.Fatalf("esperadas 3 partes, mas obteve %d", len(*paths))
+	}
+	if names := zipEntryNames(t, (*paths)[1]); len(names) != 1 || names[0] != "frame_0001.png" {
+		t.Errorf("esperado apenas o frame grande na parte 2, mas obteve %v", names)
+	}
+}
+
+func TestSplitArchiveFitKeepsGroupTogether(t *testing.T) {
+	split, paths := newTestSplitArchive(t, 12<<10)
+
+	rendition := bytes.Repeat([]byte{2}, 2<<10)
+	split.AddEntry("full/frame_0001.png", rendition)
+	split.AddEntry("thumb/frame_0001.png", rendition)
+
+	// O segundo frame caberia parcialmente; Fit o move inteiro para a próxima parte
+	if err := split.Fit(2, int64(2*len(rendition))); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	split.AddEntry("full/frame_0002.png", rendition)
+	split.AddEntry("thumb/frame_0002.png", rendition)
+	split.Close()
+
+	if len(*paths) != 2 {
+		t.Fatalf("esperadas 2 partes, mas obteve %d", len(*paths))
+	}
+	if names := zipEntryNames(t, (*paths)[1]); len(names) != 2 || names[0] != "full/frame_0002.png" {
+		t.Errorf("esperado o segundo frame inteiro na parte 2, mas obteve %v", names)
+	}
+}
+
+func TestSplitArchiveFitOversizedGroup(t *testing.T) {
+	split, paths := newTestSplitArchive(t, 8<<10)
+
+	small := bytes.Repeat([]byte{1}, 1<<10)
+	split.AddEntry("full/frame_0001.png", small)
+	split.AddEntry("thumb/frame_0001.png", small)
+
+	// As rendições do segundo frame somam mais que o limite: ficam juntas em
+	// uma parte acima do limite, sem serem divididas
+	rendition := bytes.Repeat([]byte{2}, 6<<10)
+	if err := split.Fit(2, int64(2*len(rendition))); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	split.AddEntry("full/frame_0002.png", rendition)
+	split.AddEntry("thumb/frame_0002.png", rendition)
+
+	// Concluído o grupo, a divisão volta a valer
+	split.AddEntry("full/frame_0003.png", small)
+	split.Close()
+
+	if len(*paths) != 3 {
+		t.Fatalf("esperadas 3 partes, mas obteve %d", len(*paths))
+	}
+	if names := zipEntryNames(t, (*paths)[1]); len(names) != 2 || names[0] != "full/frame_0002.png" || names[1] != "thumb/frame_0002.png" {
+		t.Errorf("esperado o segundo frame inteiro na parte 2, mas obteve %v", names)
+	}
+	if names := zipEntryNames(t, (*paths)[2]); len(names) != 1 || names[0] != "full/frame_0003.png" {
+		t.Errorf("esperado o terceiro frame na parte 3, mas obteve %v", names)
+	}
+}
+
+func TestSplitArchiveEmpty(t *testing.T) {
+	split, paths := newTestSplitArchive(t, 1<<20)
+	if err := split.Close(); err != nil {
+		t.Errorf("erro inesperado: %v", err)
+	}
+	if len(*paths) != 0 || split.Part() != 0 {
+		t.Errorf("esperado nenhuma parte sem entradas, mas obteve %d", len(*paths))
+	}
+}
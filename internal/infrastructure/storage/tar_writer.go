@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// tarStream escreve um TAR, opcionalmente comprimido, lido sequencialmente
+// pelos consumidores sem precisar de um índice no fim como o ZIP
+type tarStream struct {
+	writer       *tar.Writer
+	closers      []io.Closer // compressor e arquivo, fechados nessa ordem
+	reproducible bool
+}
+
+// newTarStream cria o TAR no formato informado sobre w. No modo reprodutível
+// os cabeçalhos são normalizados e o zstd usa um único encoder.
+func newTarStream(format string, w io.Writer, reproducible bool) (*tarStream, error) {
+	stream := &tarStream{reproducible: reproducible}
+	switch format {
+	case ArchiveFormatTar:
+	case ArchiveFormatTarGz:
+		gz := gzip.NewWriter(w)
+		stream.closers = append(stream.closers, gz)
+		w = gz
+	case ArchiveFormatTarZst:
+		var options []zstd.EOption
+		if reproducible {
+			options = append(options, zstd.WithEncoderConcurrency(1))
+		}
+		zw, err := zstd.NewWriter(w, options...)
+		if err != nil {
+			return nil, err
+		}
+		stream.closers = append(stream.closers, zw)
+		w = zw
+	default:
+		return nil, fmt.Errorf("formato de arquivo não suportado: %q", format)
+	}
+
+	stream.writer = tar.NewWriter(w)
+	return stream, nil
+}
+
+func (s *tarStream) AddFile(filename string) error {
+	return s.AddFileAs(filename, filepath.Base(filename))
+}
+
+// AddFileAs adiciona o arquivo com o nome informado, que pode incluir
+// subpastas separadas por "/"
+func (s *tarStream) AddFileAs(filename, name string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	s.normalizeHeader(header)
+
+	if err := s.writer.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(s.writer, file)
+	return err
+}
+
+func (s *tarStream) AddEntry(name string, data []byte) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}
+	s.normalizeHeader(header)
+	if err := s.writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := s.writer.Write(data)
+	return err
+}
+
+// normalizeHeader aplica o modo reprodutível: data fixa, permissões 0644 e
+// sem dono ou datas de acesso
+func (s *tarStream) normalizeHeader(header *tar.Header) {
+	if !s.reproducible {
+		return
+	}
+	header.ModTime = ReproducibleModTime
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Mode = 0644
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+}
+
+// Close finaliza o TAR e fecha o compressor e o arquivo, se houver
+func (s *tarStream) Close() error {
+	err := s.writer.Close()
+	for _, closer := range s.closers {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// tarPayload retorna o leitor do TAR descomprimido
+func tarPayload(t *testing.T, format string, data []byte) io.Reader {
+	t.Helper()
+	switch format {
+	case ArchiveFormatTarGz:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("gzip inválido: %v", err)
+		}
+		return gz
+	case ArchiveFormatTarZst:
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("zstd inválido: %v", err)
+		}
+		t.Cleanup(zr.Close)
+		return zr
+	}
+	return bytes.NewReader(data)
+}
+
+// newTarReader descomprime o TAR no formato informado
+func newTarReader(t *testing.T, format string, data []byte) *tar.Reader {
+	t.Helper()
+	return tar.NewReader(tarPayload(t, format, data))
+}
+
+// readTarHeaders retorna os cabeçalhos das entradas do TAR, na ordem
+func readTarHeaders(t *testing.T, format string, data []byte) []*tar.Header {
+	t.Helper()
+	var headers []*tar.Header
+	tr := newTarReader(t, format, data)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return headers
+		}
+		if err != nil {
+			t.Fatalf("tar inválido: %v", err)
+		}
+		headers = append(headers, header)
+	}
+}
+
+// writeTestTar cria o TAR com o arquivo informado e uma entrada em memória
+func writeTestTar(t *testing.T, format string, reproducible bool, filename, name string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	stream, err := newTarStream(format, &buf, reproducible)
+	if err != nil {
+		t.Fatalf("%s: erro ao criar TAR: %v", format, err)
+	}
+	if err := stream.AddFileAs(filename, name); err != nil {
+		t.Fatalf("%s: erro ao adicionar arquivo: %v", format, err)
+	}
+	if err := stream.AddEntry("manifest.json", []byte("{}")); err != nil {
+		t.Fatalf("%s: erro ao adicionar entrada: %v", format, err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("%s: erro ao fechar TAR: %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestTarStreamReproducibleHeaders(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "frame_0001.png")
+	os.WriteFile(fileName, []byte("frame"), 0600)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(fileName, modTime, modTime)
+
+	headers := readTarHeaders(t, ArchiveFormatTar, writeTestTar(t, ArchiveFormatTar, true, fileName, "frame_0001.png"))
+	if len(headers) != 2 {
+		t.Fatalf("esperado 2 entradas, mas obteve %d", len(headers))
+	}
+	for _, header := range headers {
+		if !header.ModTime.Equal(ReproducibleModTime) || header.Mode != 0644 {
+			t.Errorf("%s: esperada data %v e permissão 0644, mas obteve %v e %o", header.Name, ReproducibleModTime, header.ModTime, header.Mode)
+		}
+		if header.Uid != 0 || header.Gid != 0 || header.Uname != "" || header.Gname != "" {
+			t.Errorf("%s: esperado sem dono, mas obteve %d:%d (%s:%s)", header.Name, header.Uid, header.Gid, header.Uname, header.Gname)
+		}
+		if !header.AccessTime.IsZero() || !header.ChangeTime.IsZero() {
+			t.Errorf("%s: esperado sem datas de acesso, mas obteve %v e %v", header.Name, header.AccessTime, header.ChangeTime)
+		}
+	}
+
+	// Fora do modo reprodutível o arquivo mantém data e permissões
+	headers = readTarHeaders(t, ArchiveFormatTar, writeTestTar(t, ArchiveFormatTar, false, fileName, "frame_0001.png"))
+	if !headers[0].ModTime.Equal(modTime) || headers[0].Mode&0777 != 0600 {
+		t.Errorf("esperada data %v e permissão 0600, mas obteve %v e %o", modTime, headers[0].ModTime, headers[0].Mode)
+	}
+}
+
+func TestTarStreamLongNames(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "frame_0001.png")
+	os.WriteFile(fileName, []byte("frame com nome longo"), 0644)
+
+	// Nomes acima de 100 caracteres exigem extensões PAX ou GNU do TAR
+	longName := strings.Repeat("rendicao_", 15) + "/" + strings.Repeat("f", 120) + ".png"
+	for _, format := range []string{ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatTarZst} {
+		for _, reproducible := range []bool{false, true} {
+			entries := readTarEntries(t, format, writeTestTar(t, format, reproducible, fileName, longName))
+			if len(entries) != 2 || entries[longName] != "frame com nome longo" || entries["manifest.json"] != "{}" {
+				t.Errorf("%s (reprodutível=%v): entradas inesperadas: %v", format, reproducible, entries)
+			}
+		}
+	}
+}
+
+func TestTarStreamCompressedRoundTrip(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "frame_0001.png")
+	content := bytes.Repeat([]byte("frame comprimível "), 4096)
+	os.WriteFile(fileName, content, 0644)
+
+	plain := writeTestTar(t, ArchiveFormatTar, true, fileName, "frame_0001.png")
+	for _, format := range []string{ArchiveFormatTarGz, ArchiveFormatTarZst} {
+		data := writeTestTar(t, format, true, fileName, "frame_0001.png")
+		if len(data) >= len(plain) {
+			t.Errorf("%s: esperado arquivo comprimido menor que o TAR (%d bytes), mas obteve %d", format, len(plain), len(data))
+		}
+
+		// O conteúdo descomprimido é o mesmo TAR gerado sem compressão
+		decompressed, err := io.ReadAll(tarPayload(t, format, data))
+		if err != nil {
+			t.Fatalf("%s: erro ao descomprimir: %v", format, err)
+		}
+		if !bytes.Equal(decompressed, plain) {
+			t.Errorf("%s: conteúdo descomprimido difere do TAR sem compressão", format)
+		}
+	}
+}
+
+func TestNewTarStreamUnsupportedFormat(t *testing.T) {
+	if _, err := newTarStream(ArchiveFormatZip, io.Discard, false); err == nil {
+		t.Error("esperado erro para formato não TAR")
+	}
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// maxObjectTags é o limite de tags por objeto do S3
+const maxObjectTags = 10
+
+// S3Config define as configurações aplicadas a todos os objetos enviados
+type S3Config struct {
+	StorageClass         string // ex.: STANDARD_IA, INTELLIGENT_TIERING; vazio usa o padrão do bucket
+	ServerSideEncryption string // AES256 ou aws:kms; vazio usa a criptografia padrão do bucket
+	KMSKeyID             string // chave KMS para aws:kms; vazio usa a chave gerenciada pela AWS
+}
+
+// Validate confere a classe de armazenamento e a criptografia
+func (c S3Config) Validate() error {
+	if c.StorageClass != "" && !containsString(s3.StorageClass_Values(), c.StorageClass) {
+		return fmt.Errorf("classe de armazenamento inválida: %q (use %s)", c.StorageClass, strings.Join(s3.StorageClass_Values(), ", "))
+	}
+	if c.ServerSideEncryption != "" && !containsString(s3.ServerSideEncryption_Values(), c.ServerSideEncryption) {
+		return fmt.Errorf("criptografia inválida: %q (use %s)", c.ServerSideEncryption, strings.Join(s3.ServerSideEncryption_Values(), ", "))
+	}
+	if c.KMSKeyID != "" && c.ServerSideEncryption != s3.ServerSideEncryptionAwsKms {
+		return fmt.Errorf("chave KMS exige a criptografia %s", s3.ServerSideEncryptionAwsKms)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadOptions descreve o objeto enviado: tipo, nome para download,
+// metadados e tags (usáveis em regras de ciclo de vida do bucket)
+type UploadOptions struct {
+	ContentType string
+	Filename    string            // nome sugerido no Content-Disposition; vazio omite o cabeçalho
+	Metadata    map[string]string // gravados como x-amz-meta-*
+	Tags        map[string]string // até 10; valores são ajustados aos caracteres aceitos pelo S3
+}
+
+// contentDisposition monta o Content-Disposition de anexo com o nome
+// informado, codificando nomes fora do ASCII conforme a RFC 2231
+func (o UploadOptions) contentDisposition() *string {
+	if o.Filename == "" {
+		return nil
+	}
+	return aws.String(mime.FormatMediaType("attachment", map[string]string{"filename": o.Filename}))
+}
+
+// metadata monta os metadados do objeto, incluindo o SHA-256 do conteúdo
+// quando informado. Valores fora do ASCII são codificados conforme a RFC
+// 2047, como o próprio S3 faz ao retorná-los.
+func (o UploadOptions) metadata(sha256 string) map[string]*string {
+	metadata := make(map[string]*string, len(o.Metadata)+1)
+	for name, value := range o.Metadata {
+		metadata[name] = aws.String(mime.QEncoding.Encode("utf-8", value))
+	}
+	if sha256 != "" {
+		metadata[ChecksumMetadataKey] = aws.String(sha256)
+	}
+	return metadata
+}
+
+var tagUnsafe = regexp.MustCompile(`[^\p{L}\p{N} +\-=._:/@]+`)
+
+// tagging monta as tags no formato de query string do cabeçalho
+// x-amz-tagging, respeitando os limites de quantidade, tamanho e
+// caracteres do S3
+func (o UploadOptions) tagging() *string {
+	tags := o.tagSet(nil)
+	if len(tags) == 0 {
+		return nil
+	}
+	values := url.Values{}
+	for _, tag := range tags {
+		values.Set(aws.StringValue(tag.Key), aws.StringValue(tag.Value))
+	}
+	return aws.String(values.Encode())
+}
+
+// tagSet monta as tags do objeto com os mesmos ajustes de tagging; as tags
+// de extra têm prioridade sobre as de o.Tags no limite de quantidade
+func (o UploadOptions) tagSet(extra map[string]string) []*s3.Tag {
+	tags := make([]*s3.Tag, 0, min(len(extra)+len(o.Tags), maxObjectTags))
+	seen := map[string]bool{}
+	for _, source := range []map[string]string{extra, o.Tags} {
+		names := make([]string, 0, len(source))
+		for name := range source {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			key := truncate(tagUnsafe.ReplaceAllString(name, "_"), 128)
+			if seen[key] || len(tags) == maxObjectTags {
+				continue
+			}
+			seen[key] = true
+			tags = append(tags, &s3.Tag{Key: aws.String(key), Value: aws.String(truncate(tagUnsafe.ReplaceAllString(source[name], "_"), 256))})
+		}
+	}
+	return tags
+}
+
+// truncate limita o texto a max runas
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) > max {
+		return string(runes[:max])
+	}
+	return value
+}
+
+// applyTo preenche os campos de configuração do S3 na entrada do upload
+func (c S3Config) applyTo(input *s3manager.UploadInput) {
+	if c.StorageClass != "" {
+		input.StorageClass = aws.String(c.StorageClass)
+	}
+	if c.ServerSideEncryption != "" {
+		input.ServerSideEncryption = aws.String(c.ServerSideEncryption)
+	}
+	if c.KMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(c.KMSKeyID)
+	}
+}
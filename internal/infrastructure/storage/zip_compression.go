@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Métodos de compressão das entradas do ZIP
+const (
+	ZipCompressionAuto    = "auto"    // Store ou Deflate por entrada, pelo tipo ou por amostragem
+	ZipCompressionStore   = "store"   // sem compressão
+	ZipCompressionDeflate = "deflate" // Deflate em todas as entradas
+)
+
+// Valores padrão da compressão do ZIP
+const (
+	DefaultZipCompressionLevel = flate.DefaultCompression
+	DefaultZipMinSavings       = 0.05 // ganho mínimo da amostra para usar Deflate
+)
+
+// zipSampleSize é o tamanho da amostra comprimida para estimar o ganho do Deflate
+const zipSampleSize = 64 << 10
+
+// storedExtensions são formatos já comprimidos, gravados sem recompressão
+var storedExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".webp": true, ".gif": true,
+	".gz": true, ".zst": true, ".zip": true, ".mp4": true,
+}
+
+// deflatedExtensions são formatos de texto, sempre comprimidos
+var deflatedExtensions = map[string]bool{
+	".json": true, ".txt": true, ".csv": true,
+}
+
+// ZipConfig controla a compressão das entradas do ZIP
+type ZipConfig struct {
+	Compression string  // ZipCompressionAuto (padrão), ZipCompressionStore ou ZipCompressionDeflate
+	Level       int     // nível do Deflate (1 a 9); 0 usa DefaultZipCompressionLevel
+	MinSavings  float64 // no modo auto, fração mínima economizada na amostra para usar Deflate
+	Parallel    int     // entradas comprimidas em paralelo, limitado aos núcleos disponíveis; até 1 comprime durante a escrita
+	Password    string  // criptografa as entradas com AES-256 (WinZip AE-2); nunca registrar em log
+
+	// Reproducible gera bytes idênticos para as mesmas entradas e
+	// configurações: data fixa, permissões 0644 e, com senha, salt derivado
+	// do conteúdo em vez de aleatório
+	Reproducible bool
+}
+
+// DefaultZipConfig retorna a seleção automática com o nível padrão do Deflate
+func DefaultZipConfig() ZipConfig {
+	return ZipConfig{
+		Compression: ZipCompressionAuto,
+		Level:       DefaultZipCompressionLevel,
+		MinSavings:  DefaultZipMinSavings,
+	}
+}
+
+// Validate verifica o método e o nível de compressão
+func (c ZipConfig) Validate() error {
+	switch strings.ToLower(c.Compression) {
+	case "", ZipCompressionAuto, ZipCompressionStore, ZipCompressionDeflate:
+	default:
+		return fmt.Errorf("método de compressão inválido: %q", c.Compression)
+	}
+	if c.Level != 0 && (c.Level < flate.HuffmanOnly || c.Level > flate.BestCompression) {
+		return fmt.Errorf("nível de compressão inválido: %d", c.Level)
+	}
+	return nil
+}
+
+// normalizeHeader aplica o modo reprodutível ao cabeçalho da entrada
+func (c ZipConfig) normalizeHeader(header *zip.FileHeader) {
+	if !c.Reproducible {
+		return
+	}
+	header.Modified = ReproducibleModTime
+	header.SetMode(0644)
+}
+
+// maxZipParallel limita as compressões simultâneas aos núcleos disponíveis:
+// com um único núcleo o modo paralelo só acrescentaria cópias e trocas de
+// contexto. Variável para que os testes exercitem o modo paralelo.
+var maxZipParallel = runtime.NumCPU()
+
+// parallelism retorna as entradas comprimidas em paralelo. O ganho só
+// aparece com Deflate em conteúdo compressível (manifestos, frames sem
+// compressão própria); PNGs no modo auto são gravados com Store e não
+// dependem da CPU.
+func (c ZipConfig) parallelism() int {
+	return min(c.Parallel, maxZipParallel)
+}
+
+func (c ZipConfig) level() int {
+	if c.Level == 0 {
+		return DefaultZipCompressionLevel
+	}
+	return c.Level
+}
+
+// method escolhe Store ou Deflate para a entrada a partir do nome e de uma
+// amostra do início do conteúdo
+func (c ZipConfig) method(name string, sample []byte) uint16 {
+	switch strings.ToLower(c.Compression) {
+	case ZipCompressionStore:
+		return zip.Store
+	case ZipCompressionDeflate:
+		return zip.Deflate
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	switch {
+	case storedExtensions[ext]:
+		return zip.Store
+	case deflatedExtensions[ext], ext == "" && len(sample) < 1024:
+		// Textos e arquivos pequenos sem extensão (ex.: SHA256SUMS)
+		return zip.Deflate
+	}
+	if deflateSavings(sample) < c.MinSavings {
+		return zip.Store
+	}
+	return zip.Deflate
+}
+
+// deflateSavings estima a fração economizada pelo Deflate comprimindo a
+// amostra no nível mais rápido
+func deflateSavings(sample []byte) float64 {
+	if len(sample) == 0 {
+		return 0
+	}
+	if len(sample) > zipSampleSize {
+		sample = sample[:zipSampleSize]
+	}
+
+	var counter countingWriter
+	fw, _ := flate.NewWriter(&counter, flate.BestSpeed)
+	fw.Write(sample)
+	fw.Close()
+	return 1 - float64(counter)/float64(len(sample))
+}
+
+// countingWriter descarta os bytes escritos, apenas contando-os
+type countingWriter int64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+// compressEntry prepara a entrada para zip.Writer.CreateRaw: calcula o CRC e
+// os tamanhos, comprime os dados quando o método é Deflate e os criptografa
+// quando há senha
+func compressEntry(header *zip.FileHeader, data []byte, config ZipConfig) ([]byte, error) {
+	prepareRawHeader(header)
+	header.CRC32 = crc32.ChecksumIEEE(data)
+	header.UncompressedSize64 = uint64(len(data))
+
+	compressed := data
+	if header.Method == zip.Deflate {
+		var buf bytes.Buffer
+		fw, err := flate.NewWriter(&buf, config.level())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := fw.Close(); err != nil {
+			return nil, err
+		}
+		compressed = buf.Bytes()
+	}
+	header.CompressedSize64 = uint64(len(compressed))
+
+	if config.Password == "" {
+		return compressed, nil
+	}
+	return encryptEntry(header, compressed, config.Password, config.Reproducible)
+}
+
+// prepareRawHeader preenche os campos que o CreateHeader calcularia e o
+// CreateRaw não: as versões do formato e a data no padrão MS-DOS
+func prepareRawHeader(header *zip.FileHeader) {
+	header.ReaderVersion = zipVersionDefault
+	header.CreatorVersion = header.CreatorVersion&0xff00 | zipVersionDefault
+	if !header.Modified.IsZero() {
+		header.SetModTime(header.Modified)
+	}
+}
+
+// compressedEntry é o resultado da compressão de uma entrada em paralelo
+type compressedEntry struct {
+	header *zip.FileHeader
+	data   []byte
+	err    error
+}
+
+// parallelZipWriter comprime as entradas em goroutines e as grava com
+// CreateRaw na ordem em que foram adicionadas
+type parallelZipWriter struct {
+	writer *zip.Writer
+	config ZipConfig
+	slots  chan struct{}             // limita as compressões simultâneas
+	queue  chan chan compressedEntry // resultados pendentes, em ordem
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error // primeiro erro, retornado nas adições seguintes e em Close
+}
+
+func newParallelZipWriter(writer *zip.Writer, config ZipConfig) *parallelZipWriter {
+	p := &parallelZipWriter{
+		writer: writer,
+		config: config,
+		slots:  make(chan struct{}, config.parallelism()),
+		queue:  make(chan chan compressedEntry, config.parallelism()),
+		done:   make(chan struct{}),
+	}
+	go p.writeLoop()
+	return p
+}
+
+// submit agenda a compressão da entrada; a gravação ocorre em ordem
+func (p *parallelZipWriter) submit(header *zip.FileHeader, data []byte) error {
+	if err := p.failure(); err != nil {
+		return err
+	}
+
+	result := make(chan compressedEntry, 1)
+	p.slots <- struct{}{}
+	p.queue <- result
+	go func() {
+		defer func() { <-p.slots }()
+		compressed, err := compressEntry(header, data, p.config)
+		result <- compressedEntry{header: header, data: compressed, err: err}
+	}()
+	return nil
+}
+
+// writeLoop grava as entradas comprimidas na ordem de submissão
+func (p *parallelZipWriter) writeLoop() {
+	defer close(p.done)
+	for result := range p.queue {
+		entry := <-result
+		if p.failure() != nil {
+			continue
+		}
+		if entry.err != nil {
+			p.fail(entry.err)
+			continue
+		}
+		w, err := p.writer.CreateRaw(entry.header)
+		if err == nil {
+			_, err = w.Write(entry.data)
+		}
+		if err != nil {
+			p.fail(err)
+		}
+	}
+}
+
+// wait aguarda a gravação das entradas pendentes
+func (p *parallelZipWriter) wait() error {
+	close(p.queue)
+	<-p.done
+	return p.failure()
+}
+
+func (p *parallelZipWriter) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *parallelZipWriter) failure() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// readSample lê o início do conteúdo sem consumi-lo
+func readSample(r io.Reader) ([]byte, io.Reader, error) {
+	sample := make([]byte, zipSampleSize)
+	n, err := io.ReadFull(r, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	sample = sample[:n]
+	return sample, io.MultiReader(bytes.NewReader(sample), r), nil
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// incompressibleData simula o conteúdo de um PNG: bytes sem redundância
+func incompressibleData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(42)).Read(data)
+	return data
+}
+
+func TestZipConfigMethod(t *testing.T) {
+	config := DefaultZipConfig()
+	text := bytes.Repeat([]byte("frame_0001.png 0.000\n"), 200)
+	random := incompressibleData(8192)
+
+	testCases := []struct {
+		name     string
+		sample   []byte
+		expected uint16
+	}{
+		{"frame_0001.png", text, zip.Store},
+		{"thumb/frame_0001.jpg", text, zip.Store},
+		{"manifest.json", random, zip.Deflate},
+		{"SHA256SUMS", []byte("abc  frame_0001.png\n"), zip.Deflate},
+		{"dados.bin", random, zip.Store},
+		{"dados.bin", text, zip.Deflate},
+	}
+	for _, tc := range testCases {
+		if got := config.method(tc.name, tc.sample); got != tc.expected {
+			t.Errorf("%s: esperado método %d, mas obteve %d", tc.name, tc.expected, got)
+		}
+	}
+
+	config.Compression = ZipCompressionDeflate
+	if got := config.method("frame_0001.png", random); got != zip.Deflate {
+		t.Errorf("esperado Deflate forçado, mas obteve %d", got)
+	}
+	config.Compression = ZipCompressionStore
+	if got := config.method("manifest.json", text); got != zip.Store {
+		t.Errorf("esperado Store forçado, mas obteve %d", got)
+	}
+}
+
+func TestZipConfigValidate(t *testing.T) {
+	if err := DefaultZipConfig().Validate(); err != nil {
+		t.Errorf("configuração padrão deveria ser válida: %v", err)
+	}
+	if err := (ZipConfig{Compression: "brotli"}).Validate(); err == nil {
+		t.Error("esperado erro para método desconhecido")
+	}
+	if err := (ZipConfig{Level: 12}).Validate(); err == nil {
+		t.Error("esperado erro para nível fora do intervalo")
+	}
+}
+
+// readZipEntries lê o ZIP e retorna o conteúdo e o método de cada entrada, em ordem
+func readZipEntries(t *testing.T, data []byte) ([]string, map[string][]byte, map[string]uint16) {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip gerado inválido: %v", err)
+	}
+
+	var names []string
+	contents := make(map[string][]byte)
+	methods := make(map[string]uint16)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("erro ao abrir entrada %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("erro ao ler entrada %s (CRC ou tamanho incorretos?): %v", file.Name, err)
+		}
+		names = append(names, file.Name)
+		contents[file.Name] = content
+		methods[file.Name] = file.Method
+	}
+	return names, contents, methods
+}
+
+// forceZipParallel permite o modo paralelo mesmo em máquinas com um núcleo
+func forceZipParallel(t *testing.T) {
+	t.Helper()
+	previous := maxZipParallel
+	maxZipParallel = 8
+	t.Cleanup(func() { maxZipParallel = previous })
+}
+
+func TestZipConfigParallelism(t *testing.T) {
+	previous := maxZipParallel
+	defer func() { maxZipParallel = previous }()
+
+	maxZipParallel = 1
+	if got := (ZipConfig{Parallel: 4}).parallelism(); got != 1 {
+		t.Errorf("esperado modo sequencial com um núcleo, mas obteve %d", got)
+	}
+	maxZipParallel = 8
+	if got := (ZipConfig{Parallel: 4}).parallelism(); got != 4 {
+		t.Errorf("esperado 4 compressões simultâneas, mas obteve %d", got)
+	}
+}
+
+func TestZipStreamParallelPreservesOrder(t *testing.T) {
+	forceZipParallel(t)
+	config := DefaultZipConfig()
+	config.Parallel = 4
+
+	var buf bytes.Buffer
+	stream := NewZipServiceWithConfig(config).NewZipStreamTo(&buf)
+	expected := make(map[string][]byte)
+	for i := 1; i <= 20; i++ {
+		name := fmt.Sprintf("frame_%04d.png", i)
+		expected[name] = incompressibleData(1000 + i)
+		if err := stream.AddEntry(name, expected[name]); err != nil {
+			t.Fatalf("erro ao adicionar entrada: %v", err)
+		}
+	}
+	expected["manifest.json"] = bytes.Repeat([]byte(`{"file":"frame_0001.png"}`), 100)
+	stream.AddEntry("manifest.json", expected["manifest.json"])
+	if err := stream.Close(); err != nil {
+		t.Fatalf("erro ao fechar zip: %v", err)
+	}
+
+	names, contents, methods := readZipEntries(t, buf.Bytes())
+	if len(names) != 21 || names[0] != "frame_0001.png" || names[19] != "frame_0020.png" || names[20] != "manifest.json" {
+		t.Fatalf("ordem inesperada das entradas: %v", names)
+	}
+	for name, data := range expected {
+		if !bytes.Equal(contents[name], data) {
+			t.Errorf("conteúdo divergente em %s", name)
+		}
+	}
+	if methods["frame_0001.png"] != zip.Store || methods["manifest.json"] != zip.Deflate {
+		t.Errorf("métodos inesperados: %v", methods)
+	}
+}
+
+func TestZipStreamParallelFileNotFound(t *testing.T) {
+	forceZipParallel(t)
+	config := DefaultZipConfig()
+	config.Parallel = 2
+
+	stream := NewZipServiceWithConfig(config).NewZipStreamTo(io.Discard)
+	if err := stream.AddFile("arquivo_inexistente.png"); err == nil {
+		t.Error("esperado erro ao adicionar arquivo inexistente")
+	}
+	stream.Close()
+}
+
+// flatFrame simula um frame de cor chapada gravado em PNG sem compressão
+// própria: conteúdo altamente compressível, ao contrário dos PNGs do ffmpeg
+func flatFrame(b *testing.B, shade uint8) []byte {
+	img := image.NewGray(image.Rect(0, 0, 640, 360))
+	for i := range img.Pix {
+		img.Pix[i] = shade + uint8(i%640/80)
+	}
+	var buf bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.NoCompression}).Encode(&buf, img); err != nil {
+		b.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// benchmarkZip grava os frames e um manifesto, reportando o tamanho final
+// do arquivo por operação e a fração do tamanho original
+func benchmarkZip(b *testing.B, config ZipConfig, frames [][]byte) {
+	manifest := bytes.Repeat([]byte(`{"file":"frame_0001.png","timestamp":1.5,"sha256":"abc"}`), 2000)
+	total := len(manifest)
+	for _, frame := range frames {
+		total += len(frame)
+	}
+
+	b.SetBytes(int64(total))
+	b.ResetTimer()
+	var size int
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		stream := NewZipServiceWithConfig(config).NewZipStreamTo(&buf)
+		for j, frame := range frames {
+			stream.AddEntry(fmt.Sprintf("frame_%04d.bin", j+1), frame)
+		}
+		stream.AddEntry("manifest.json", manifest)
+		if err := stream.Close(); err != nil {
+			b.Fatal(err)
+		}
+		size = buf.Len()
+	}
+	b.ReportMetric(float64(size), "bytes/op")
+	b.ReportMetric(float64(size)/float64(total), "ratio")
+}
+
+// BenchmarkZip compara as compressões com frames incompressíveis (como os
+// PNGs do ffmpeg) e compressíveis (cor chapada sem compressão própria). As
+// entradas usam a extensão .bin para que o modo auto decida pela amostra.
+// O modo paralelo é limitado aos núcleos disponíveis e, com um só núcleo,
+// equivale ao sequencial.
+func BenchmarkZip(b *testing.B) {
+	incompressible := make([][]byte, 16)
+	compressible := make([][]byte, 16)
+	for i := range incompressible {
+		incompressible[i] = incompressibleData(512 << 10)
+		compressible[i] = flatFrame(b, uint8(i*8))
+	}
+
+	fixtures := []struct {
+		name   string
+		frames [][]byte
+	}{
+		{"incompressivel", incompressible},
+		{"compressivel", compressible},
+	}
+	configs := []struct {
+		name   string
+		config ZipConfig
+	}{
+		{"store", ZipConfig{Compression: ZipCompressionStore}},
+		{"auto", DefaultZipConfig()},
+		{"deflate", ZipConfig{Compression: ZipCompressionDeflate}},
+		{"deflate_rapido", ZipConfig{Compression: ZipCompressionDeflate, Level: 1}},
+		{"deflate_paralelo", ZipConfig{Compression: ZipCompressionDeflate, Parallel: 4}},
+	}
+	for _, fixture := range fixtures {
+		for _, c := range configs {
+			b.Run(fixture.name+"/"+c.name, func(b *testing.B) {
+				benchmarkZip(b, c.config, fixture.frames)
+			})
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"archive/zip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+)
+
+// Criptografia AES-256 no formato WinZip AE-2: o método da entrada passa a ser
+// 99, o método real vai no campo extra 0x9901 e o CRC-32 é zerado, pois a
+// integridade é garantida pelo código de autenticação HMAC-SHA1
+const (
+	zipMethodAES      = 99
+	aesExtraID        = 0x9901
+	aesVendorVersion  = 2 // AE-2
+	aesStrength256    = 3
+	aesSaltSize       = 16
+	aesKeySize        = 32
+	aesVerifierSize   = 2
+	aesAuthCodeSize   = 10
+	aesIterations     = 1000
+	zipEncryptedFlag  = 0x1
+	zipVersionAES     = 51
+	zipVersionDefault = 20
+)
+
+// DeriveArchivePassword deriva a senha do pacote de um job a partir do
+// segredo do worker e do ID do vídeo (HMAC-SHA256 em base64 URL), permitindo
+// que quem conhece o segredo a recalcule sem que ela trafegue no evento
+func DeriveArchivePassword(secret, videoID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(videoID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encryptEntry criptografa os dados já comprimidos da entrada, ajustando o
+// cabeçalho para o formato AE-2. O resultado contém o salt, o verificador da
+// senha, os dados criptografados e o código de autenticação. Com
+// deterministicSalt o salt é derivado da senha, do nome e do conteúdo, de
+// modo que só entradas idênticas geram os mesmos bytes.
+func encryptEntry(header *zip.FileHeader, data []byte, password string, deterministicSalt bool) ([]byte, error) {
+	salt := make([]byte, aesSaltSize)
+	if deterministicSalt {
+		mac := hmac.New(sha256.New, []byte(password))
+		mac.Write([]byte(header.Name))
+		mac.Write([]byte{0})
+		mac.Write(data)
+		copy(salt, mac.Sum(nil))
+	} else if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	keys, err := pbkdf2.Key(sha1.New, password, salt, aesIterations, 2*aesKeySize+aesVerifierSize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(keys[:aesKeySize])
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, aesSaltSize+aesVerifierSize+len(data)+aesAuthCodeSize)
+	out = append(out, salt...)
+	out = append(out, keys[2*aesKeySize:]...)
+	encrypted := out[len(out) : len(out)+len(data)]
+	aesCTR(block, encrypted, data)
+	out = out[:len(out)+len(data)]
+
+	mac := hmac.New(sha1.New, keys[aesKeySize:2*aesKeySize])
+	mac.Write(encrypted)
+	out = append(out, mac.Sum(nil)[:aesAuthCodeSize]...)
+
+	header.Extra = append(header.Extra, aesExtra(header.Method)...)
+	header.Method = zipMethodAES
+	header.Flags |= zipEncryptedFlag
+	header.ReaderVersion = zipVersionAES
+	header.CRC32 = 0
+	header.CompressedSize64 = uint64(len(out))
+	return out, nil
+}
+
+// aesExtra monta o campo extra 0x9901 com o método de compressão real
+func aesExtra(method uint16) []byte {
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], aesExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], aesVendorVersion)
+	copy(extra[6:], "AE")
+	extra[8] = aesStrength256
+	binary.LittleEndian.PutUint16(extra[9:], method)
+	return extra
+}
+
+// aesCTR aplica o AES em modo contador como o WinZip: o contador começa em 1
+// e é incrementado em little-endian, diferente do cipher.NewCTR
+func aesCTR(block cipher.Block, dst, src []byte) {
+	var counter, stream [aes.BlockSize]byte
+	for i := 0; i < len(src); i += aes.BlockSize {
+		for j := range counter {
+			counter[j]++
+			if counter[j] != 0 {
+				break
+			}
+		}
+		block.Encrypt(stream[:], counter[:])
+		subtle.XORBytes(dst[i:], src[i:], stream[:])
+	}
+}
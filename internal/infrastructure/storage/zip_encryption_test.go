@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+const testArchivePassword = "s3nha-do-job"
+
+// decryptAESEntry lê uma entrada AE-2 como um leitor WinZip: confere o campo
+// extra, o verificador da senha e o código de autenticação e retorna os dados
+// descriptografados e descomprimidos
+func decryptAESEntry(t *testing.T, file *zip.File, password string) ([]byte, bool) {
+	t.Helper()
+	if file.Method != zipMethodAES || file.Flags&zipEncryptedFlag == 0 || file.CRC32 != 0 {
+		t.Fatalf("%s: cabeçalho AE-2 inválido (método %d, flags %#x, CRC %#x)", file.Name, file.Method, file.Flags, file.CRC32)
+	}
+	extra := file.Extra
+	for len(extra) >= 4 && binary.LittleEndian.Uint16(extra) != aesExtraID {
+		extra = extra[4+binary.LittleEndian.Uint16(extra[2:]):]
+	}
+	if len(extra) < 11 || string(extra[6:8]) != "AE" || binary.LittleEndian.Uint16(extra[4:]) != aesVendorVersion || extra[8] != aesStrength256 {
+		t.Fatalf("%s: campo extra 0x9901 ausente ou inválido: %x", file.Name, file.Extra)
+	}
+	method := binary.LittleEndian.Uint16(extra[9:])
+
+	reader, err := file.OpenRaw()
+	if err != nil {
+		t.Fatalf("erro ao abrir entrada: %v", err)
+	}
+	raw, _ := io.ReadAll(reader)
+	salt := raw[:aesSaltSize]
+	verifier := raw[aesSaltSize : aesSaltSize+aesVerifierSize]
+	encrypted := raw[aesSaltSize+aesVerifierSize : len(raw)-aesAuthCodeSize]
+	authCode := raw[len(raw)-aesAuthCodeSize:]
+
+	keys, _ := pbkdf2.Key(sha1.New, password, salt, aesIterations, 2*aesKeySize+aesVerifierSize)
+	if !bytes.Equal(keys[2*aesKeySize:], verifier) {
+		return nil, false
+	}
+	mac := hmac.New(sha1.New, keys[aesKeySize:2*aesKeySize])
+	mac.Write(encrypted)
+	if !bytes.Equal(mac.Sum(nil)[:aesAuthCodeSize], authCode) {
+		t.Fatalf("%s: código de autenticação inválido", file.Name)
+	}
+
+	block, _ := aes.NewCipher(keys[:aesKeySize])
+	data := make([]byte, len(encrypted))
+	aesCTR(block, data, encrypted)
+	if method == zip.Deflate {
+		data, err = io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatalf("%s: erro ao descomprimir: %v", file.Name, err)
+		}
+	}
+	return data, true
+}
+
+func TestZipStreamEncrypted(t *testing.T) {
+	forceZipParallel(t)
+	frame := incompressibleData(40 << 10)
+	manifest := bytes.Repeat([]byte(`{"frame":"frame_0001.png"}`), 100)
+
+	for _, parallel := range []int{0, 3} {
+		var buf bytes.Buffer
+		service := NewZipServiceWithConfig(ZipConfig{Compression: ZipCompressionAuto, Parallel: parallel, Password: testArchivePassword})
+		stream := service.NewZipStreamTo(&buf)
+		stream.AddEntry("frame_0001.png", frame)
+		stream.AddEntry("manifest.json", manifest)
+		if err := stream.Close(); err != nil {
+			t.Fatalf("erro inesperado ao fechar: %v", err)
+		}
+
+		reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("ZIP criptografado inválido: %v", err)
+		}
+		expected := map[string][]byte{"frame_0001.png": frame, "manifest.json": manifest}
+		for _, file := range reader.File {
+			if file.ReaderVersion != zipVersionAES {
+				t.Errorf("%s: esperada versão %d, mas obteve %d", file.Name, zipVersionAES, file.ReaderVersion)
+			}
+			data, ok := decryptAESEntry(t, file, testArchivePassword)
+			if !ok || !bytes.Equal(data, expected[file.Name]) {
+				t.Errorf("%s: conteúdo descriptografado diferente do original (paralelo %d)", file.Name, parallel)
+			}
+			if _, ok := decryptAESEntry(t, file, "outra-senha"); ok {
+				t.Errorf("%s: esperado verificador inválido para outra senha", file.Name)
+			}
+		}
+
+		// Sem a senha, os dados não aparecem em claro no arquivo
+		if bytes.Contains(buf.Bytes(), []byte(`"frame":"frame_0001.png"`)) {
+			t.Error("esperado manifesto criptografado no ZIP")
+		}
+	}
+}
+
+func TestAESCTRLittleEndianCounter(t *testing.T) {
+	block, _ := aes.NewCipher(make([]byte, aesKeySize))
+	stream := make([]byte, 2*aes.BlockSize)
+	aesCTR(block, stream, make([]byte, len(stream)))
+
+	// O fluxo é AES(1), AES(2), com o contador em little-endian
+	for i := 0; i < 2; i++ {
+		counter := make([]byte, aes.BlockSize)
+		counter[0] = byte(i + 1)
+		expected := make([]byte, aes.BlockSize)
+		block.Encrypt(expected, counter)
+		if !bytes.Equal(stream[i*aes.BlockSize:(i+1)*aes.BlockSize], expected) {
+			t.Errorf("bloco %d do fluxo diferente de AES(contador %d)", i, i+1)
+		}
+	}
+}
+
+func TestDeriveArchivePassword(t *testing.T) {
+	password := DeriveArchivePassword("segredo", "video-1")
+	if password != DeriveArchivePassword("segredo", "video-1") {
+		t.Error("esperada senha determinística para o mesmo vídeo")
+	}
+	if password == DeriveArchivePassword("segredo", "video-2") || password == DeriveArchivePassword("outro", "video-1") {
+		t.Error("esperada senha diferente para outro vídeo ou segredo")
+	}
+	if len(password) != 43 {
+		t.Errorf("esperada senha de 43 caracteres, mas obteve %d", len(password))
+	}
+}
@@ -2,42 +2,96 @@ package storage
 
 import (
 	"archive/zip"
+	"compress/flate"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type ZipService interface {
 	CreateZipFile(files []string, zipPath string) error
+	NewZipStream(zipPath string) (ArchiveWriter, error)
+	NewZipStreamTo(w io.Writer) ArchiveWriter
 }
 
-type zipService struct{}
+type zipService struct {
+	config ZipConfig
+}
 
 func NewZipService() ZipService {
-	return &zipService{}
+	return NewZipServiceWithConfig(DefaultZipConfig())
+}
+
+// NewZipServiceWithConfig cria o serviço com a compressão informada
+func NewZipServiceWithConfig(config ZipConfig) ZipService {
+	return &zipService{config: config}
 }
 
 func (z *zipService) CreateZipFile(files []string, zipPath string) error {
-	zipFile, err := os.Create(zipPath)
+	stream, err := z.NewZipStream(zipPath)
 	if err != nil {
 		return err
 	}
-	defer zipFile.Close()
-
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
 
+	if z.config.Reproducible {
+		files = sortedByEntryName(files)
+	}
 	for _, file := range files {
-		err := z.addFileToZip(zipWriter, file)
-		if err != nil {
+		if err := stream.AddFile(file); err != nil {
+			stream.Close()
 			return err
 		}
 	}
 
-	return nil
+	return stream.Close()
+}
+
+func (z *zipService) NewZipStream(zipPath string) (ArchiveWriter, error) {
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := z.newZipStream(zipFile)
+	stream.file = zipFile
+	return stream, nil
+}
+
+// NewZipStreamTo escreve o ZIP no writer informado (ex.: um upload em
+// streaming). Close finaliza o ZIP, mas não fecha o writer.
+func (z *zipService) NewZipStreamTo(w io.Writer) ArchiveWriter {
+	return z.newZipStream(w)
 }
 
-func (z *zipService) addFileToZip(zipWriter *zip.Writer, filename string) error {
+func (z *zipService) newZipStream(w io.Writer) *zipStream {
+	writer := zip.NewWriter(w)
+	level := z.config.level()
+	writer.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+
+	stream := &zipStream{writer: writer, config: z.config}
+	if z.config.parallelism() > 1 {
+		stream.parallel = newParallelZipWriter(writer, z.config)
+	}
+	return stream
+}
+
+type zipStream struct {
+	file     io.Closer // nil quando o destino pertence a quem chamou
+	writer   *zip.Writer
+	config   ZipConfig
+	parallel *parallelZipWriter // nil quando a compressão ocorre durante a escrita
+}
+
+func (s *zipStream) AddFile(filename string) error {
+	return s.AddFileAs(filename, filepath.Base(filename))
+}
+
+// AddFileAs adiciona o arquivo com o nome informado, que pode incluir
+// subpastas separadas por "/"
+func (s *zipStream) AddFileAs(filename, name string) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return err
@@ -53,15 +107,97 @@ func (z *zipService) addFileToZip(zipWriter *zip.Writer, filename string) error
 	if err != nil {
 		return err
 	}
+	header.Name = name
+	s.config.normalizeHeader(header)
+
+	// Em paralelo ou com criptografia o arquivo é lido aqui e a entrada é
+	// preparada em memória
+	if s.raw() {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		header.Method = s.config.method(name, data)
+		return s.addRaw(header, data)
+	}
+
+	sample, reader, err := readSample(file)
+	if err != nil {
+		return err
+	}
+	header.Method = s.config.method(name, sample)
+
+	writer, err := s.writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(writer, reader)
+	return err
+}
 
-	header.Name = filepath.Base(filename)
-	header.Method = zip.Deflate
+// AddEntry adiciona os dados como uma entrada. Com compressão em paralelo,
+// data não pode ser alterado após a chamada.
+func (s *zipStream) AddEntry(name string, data []byte) error {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   s.config.method(name, data),
+		Modified: time.Now(),
+	}
+	s.config.normalizeHeader(header)
+	if s.raw() {
+		return s.addRaw(header, data)
+	}
 
-	writer, err := zipWriter.CreateHeader(header)
+	writer, err := s.writer.CreateHeader(header)
 	if err != nil {
 		return err
 	}
 
-	_, err = io.Copy(writer, file)
+	_, err = writer.Write(data)
+	return err
+}
+
+// raw indica se as entradas são preparadas em memória e gravadas com
+// CreateRaw, em vez de comprimidas durante a escrita
+func (s *zipStream) raw() bool {
+	return s.parallel != nil || s.config.Password != ""
+}
+
+// addRaw comprime e criptografa a entrada em memória e a grava com CreateRaw,
+// ou a envia ao parallelZipWriter
+func (s *zipStream) addRaw(header *zip.FileHeader, data []byte) error {
+	if s.parallel != nil {
+		return s.parallel.submit(header, data)
+	}
+
+	raw, err := compressEntry(header, data, s.config)
+	if err != nil {
+		return err
+	}
+	writer, err := s.writer.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(raw)
+	return err
+}
+
+// Close grava as entradas pendentes, finaliza o diretório central do ZIP e
+// fecha o arquivo
+func (s *zipStream) Close() error {
+	var err error
+	if s.parallel != nil {
+		err = s.parallel.wait()
+	}
+	if closeErr := s.writer.Close(); err == nil {
+		err = closeErr
+	}
+	if s.file == nil {
+		return err
+	}
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
 	return err
 }
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"archive/zip"
+	"bytes"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -56,3 +58,104 @@ func TestCreateZipFile_ErrorOnCreateZip(t *testing.T) {
 		t.Errorf("esperado erro ao criar arquivo zip em caminho inválido")
 	}
 }
+
+func TestZipStreamAddEntryAndFile(t *testing.T) {
+	os.MkdirAll("temp", 0755)
+	fileName := filepath.Join("temp", "file_test_stream.txt")
+	if err := os.WriteFile(fileName, []byte("arquivo"), 0644); err != nil {
+		t.Fatalf("erro ao criar arquivo temporário: %v", err)
+	}
+	defer os.Remove(fileName)
+
+	zipPath := filepath.Join("temp", "test_stream.zip")
+	defer os.Remove(zipPath)
+
+	stream, err := NewZipService().NewZipStream(zipPath)
+	if err != nil {
+		t.Fatalf("erro ao criar zip em streaming: %v", err)
+	}
+	if err := stream.AddEntry("frame_0001.png", []byte("frame em memória")); err != nil {
+		t.Fatalf("erro ao adicionar entrada: %v", err)
+	}
+	if err := stream.AddFile(fileName); err != nil {
+		t.Fatalf("erro ao adicionar arquivo: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("erro ao fechar zip: %v", err)
+	}
+
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("zip gerado inválido: %v", err)
+	}
+	defer reader.Close()
+
+	if len(reader.File) != 2 {
+		t.Fatalf("esperado 2 entradas no zip, mas obteve %d", len(reader.File))
+	}
+	if reader.File[0].Name != "frame_0001.png" || reader.File[1].Name != "file_test_stream.txt" {
+		t.Errorf("entradas inesperadas: %s, %s", reader.File[0].Name, reader.File[1].Name)
+	}
+}
+
+func TestNewZipStreamInvalidPath(t *testing.T) {
+	stream, err := NewZipService().NewZipStream(string([]byte{0}))
+	if err == nil {
+		t.Errorf("esperado erro ao criar zip em caminho inválido")
+	}
+	if stream != nil {
+		t.Errorf("esperado stream nil em caso de erro")
+	}
+}
+
+func TestZipStreamAddFileAs(t *testing.T) {
+	os.MkdirAll("temp", 0755)
+	fileName := filepath.Join("temp", "frame_test_rendition.png")
+	if err := os.WriteFile(fileName, []byte("frame"), 0644); err != nil {
+		t.Fatalf("erro ao criar arquivo temporário: %v", err)
+	}
+	defer os.Remove(fileName)
+
+	zipPath := filepath.Join("temp", "test_rendition.zip")
+	defer os.Remove(zipPath)
+
+	stream, err := NewZipService().NewZipStream(zipPath)
+	if err != nil {
+		t.Fatalf("erro ao criar zip em streaming: %v", err)
+	}
+	if err := stream.AddFileAs(fileName, "thumb/frame_0001.png"); err != nil {
+		t.Fatalf("erro ao adicionar arquivo: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("erro ao fechar zip: %v", err)
+	}
+
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("zip gerado inválido: %v", err)
+	}
+	defer reader.Close()
+
+	if len(reader.File) != 1 || reader.File[0].Name != "thumb/frame_0001.png" {
+		t.Errorf("esperado entrada na subpasta da rendição, mas obteve %v", reader.File)
+	}
+}
+
+func TestZipStreamTo(t *testing.T) {
+	var buf bytes.Buffer
+	stream := NewZipService().NewZipStreamTo(&buf)
+	if err := stream.AddEntry("frame_0001.png", []byte("frame em memória")); err != nil {
+		t.Fatalf("erro ao adicionar entrada: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("erro ao fechar zip: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip gerado inválido: %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != "frame_0001.png" {
+		t.Errorf("entradas inesperadas: %v", reader.File)
+	}
+}
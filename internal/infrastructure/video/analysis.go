@@ -0,0 +1,286 @@
+package video
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AnalysisFileName é o nome do relatório de análise incluído no arquivo de saída
+const AnalysisFileName = "analysis.json"
+
+// Limites padrão da análise do vídeo
+const (
+	DefaultBlackMinDuration   = 2.0   // segundos de tela preta para registrar um trecho
+	DefaultFreezeMinDuration  = 2.0   // segundos de imagem parada para registrar um trecho
+	DefaultFreezeNoise        = -60.0 // dB; diferença máxima entre frames considerados iguais
+	DefaultSilenceMinDuration = 2.0   // segundos de silêncio para registrar um trecho
+	DefaultSilenceNoise       = -50.0 // dB; abaixo disso o áudio é considerado silêncio
+)
+
+// Problemas apontados pela análise, resumidos no evento de sucesso
+const (
+	FindingBlackSegments  = "black_segments"
+	FindingFrozenSegments = "frozen_segments"
+	FindingSilentSegments = "silent_segments"
+	FindingNoAudio        = "no_audio"
+	FindingClipping       = "clipping" // true peak acima de 0 dBTP
+)
+
+// AnalysisConfig define os limites dos detectores do ffmpeg
+type AnalysisConfig struct {
+	BlackMinDuration   float64 `json:"black_min_duration"`
+	FreezeMinDuration  float64 `json:"freeze_min_duration"`
+	FreezeNoise        float64 `json:"freeze_noise_db"`
+	SilenceMinDuration float64 `json:"silence_min_duration"`
+	SilenceNoise       float64 `json:"silence_noise_db"`
+}
+
+// DefaultAnalysisConfig retorna os limites padrão da análise
+func DefaultAnalysisConfig() AnalysisConfig {
+	return AnalysisConfig{
+		BlackMinDuration:   DefaultBlackMinDuration,
+		FreezeMinDuration:  DefaultFreezeMinDuration,
+		FreezeNoise:        DefaultFreezeNoise,
+		SilenceMinDuration: DefaultSilenceMinDuration,
+		SilenceNoise:       DefaultSilenceNoise,
+	}
+}
+
+// MediaSegment é um trecho do vídeo, em segundos
+type MediaSegment struct {
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	Duration float64 `json:"duration"`
+}
+
+// LoudnessReport contém a medição de loudness EBU R128 do áudio. Valores
+// indefinidos (áudio totalmente silencioso) ficam ausentes.
+type LoudnessReport struct {
+	Integrated *float64 `json:"integrated_lufs,omitempty"`
+	Range      *float64 `json:"range_lu,omitempty"`
+	TruePeak   *float64 `json:"true_peak_dbtp,omitempty"`
+}
+
+// AnalysisReport descreve os problemas encontrados no vídeo de origem
+type AnalysisReport struct {
+	Duration       float64         `json:"duration"`
+	HasAudio       bool            `json:"has_audio"`
+	BlackSegments  []MediaSegment  `json:"black_segments"`
+	FrozenSegments []MediaSegment  `json:"frozen_segments"`
+	SilentSegments []MediaSegment  `json:"silent_segments"`
+	Loudness       *LoudnessReport `json:"loudness,omitempty"`
+	Findings       []string        `json:"findings"`
+	Settings       AnalysisConfig  `json:"settings"`
+}
+
+// Marshal serializa o relatório em JSON indentado
+func (r *AnalysisReport) Marshal() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// TotalDuration soma a duração dos trechos
+func TotalDuration(segments []MediaSegment) float64 {
+	total := 0.0
+	for _, seg := range segments {
+		total += seg.Duration
+	}
+	return total
+}
+
+// VideoAnalyzer executa os detectores do ffmpeg em uma única decodificação
+// do vídeo e consolida a saída em um relatório
+type VideoAnalyzer struct {
+	config  AnalysisConfig
+	sandbox SandboxConfig
+}
+
+// NewVideoAnalyzer cria um analisador com os limites e o sandbox informados
+func NewVideoAnalyzer(config AnalysisConfig, sandbox SandboxConfig) *VideoAnalyzer {
+	return &VideoAnalyzer{config: config, sandbox: sandbox}
+}
+
+// Analyze analisa o vídeo. info é usado para saber se há áudio e a duração
+// dos trechos que chegam ao fim do vídeo; quando nil, o vídeo é inspecionado.
+func (a *VideoAnalyzer) Analyze(videoPath string, info *VideoInfo) (*AnalysisReport, error) {
+	if info == nil {
+		probed, err := ProbeVideoWithSandbox(videoPath, a.sandbox)
+		if err != nil {
+			return nil, err
+		}
+		info = probed
+	}
+
+	output, err := a.sandbox.runSandboxed(exec.Command("ffmpeg", a.buildArgs(videoPath, info.AudioCodec != "")...))
+	if err != nil {
+		return nil, wrapFFmpegError(err, output)
+	}
+	return a.parseOutput(string(output), info), nil
+}
+
+// buildArgs monta o comando do ffmpeg com os detectores de vídeo e, quando
+// há áudio, os de áudio. A saída é descartada; os resultados vêm do log.
+func (a *VideoAnalyzer) buildArgs(videoPath string, hasAudio bool) []string {
+	args := []string{"-nostdin", "-nostats"}
+	args = append(args, a.sandbox.inputArgs(true)...)
+	args = append(args,
+		"-i", videoPath,
+		"-map", "0:v:0",
+		"-vf", fmt.Sprintf("blackdetect=d=%s:pix_th=0.10,freezedetect=n=%sdB:d=%s",
+			formatFloat(a.config.BlackMinDuration), formatFloat(a.config.FreezeNoise), formatFloat(a.config.FreezeMinDuration)),
+	)
+	if hasAudio {
+		args = append(args,
+			"-map", "0:a:0",
+			"-af", fmt.Sprintf("silencedetect=n=%sdB:d=%s,ebur128=peak=true:framelog=verbose",
+				formatFloat(a.config.SilenceNoise), formatFloat(a.config.SilenceMinDuration)),
+		)
+	}
+	return append(args, "-f", "null", "-")
+}
+
+// Padrões das linhas impressas pelos detectores do ffmpeg
+var (
+	blackDetectLine  = regexp.MustCompile(`black_start:\s*(-?[\d.]+)\s+black_end:\s*(-?[\d.]+)`)
+	freezeStartLine  = regexp.MustCompile(`freeze_start:\s*(-?[\d.]+)`)
+	freezeEndLine    = regexp.MustCompile(`freeze_end:\s*(-?[\d.]+)`)
+	silenceStartLine = regexp.MustCompile(`silence_start:\s*(-?[\d.]+)`)
+	silenceEndLine   = regexp.MustCompile(`silence_end:\s*(-?[\d.]+)`)
+	loudnessLine     = regexp.MustCompile(`^\s*(I|LRA|Peak):\s*(-?inf|-?[\d.]+)\s`)
+)
+
+// parseOutput interpreta o log do ffmpeg. Trechos ainda abertos no fim do
+// vídeo são encerrados na duração informada pelo probe.
+func (a *VideoAnalyzer) parseOutput(output string, info *VideoInfo) *AnalysisReport {
+	report := &AnalysisReport{
+		Duration:       info.Duration,
+		HasAudio:       info.AudioCodec != "",
+		BlackSegments:  []MediaSegment{},
+		FrozenSegments: []MediaSegment{},
+		SilentSegments: []MediaSegment{},
+		Settings:       a.config,
+	}
+
+	freeze := openSegment{}
+	silence := openSegment{}
+	inSummary := false
+	loudness := &LoudnessReport{}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := blackDetectLine.FindStringSubmatch(line); m != nil {
+			report.BlackSegments = append(report.BlackSegments, newMediaSegment(parseSeconds(m[1]), parseSeconds(m[2])))
+		}
+		if m := freezeStartLine.FindStringSubmatch(line); m != nil {
+			freeze.begin(parseSeconds(m[1]))
+		}
+		if m := freezeEndLine.FindStringSubmatch(line); m != nil {
+			report.FrozenSegments = freeze.end(report.FrozenSegments, parseSeconds(m[1]))
+		}
+		if m := silenceStartLine.FindStringSubmatch(line); m != nil {
+			silence.begin(parseSeconds(m[1]))
+		}
+		if m := silenceEndLine.FindStringSubmatch(line); m != nil {
+			report.SilentSegments = silence.end(report.SilentSegments, parseSeconds(m[1]))
+		}
+
+		// O resumo do ebur128 é impresso no fim, em linhas próprias
+		if strings.Contains(line, "Summary:") {
+			inSummary = true
+			continue
+		}
+		if m := loudnessLine.FindStringSubmatch(line); inSummary && m != nil {
+			value, ok := parseLevel(m[2])
+			if !ok {
+				continue
+			}
+			switch m[1] {
+			case "I":
+				loudness.Integrated = &value
+			case "LRA":
+				loudness.Range = &value
+			case "Peak":
+				loudness.TruePeak = &value
+			}
+		}
+	}
+
+	report.FrozenSegments = freeze.end(report.FrozenSegments, info.Duration)
+	report.SilentSegments = silence.end(report.SilentSegments, info.Duration)
+	if inSummary {
+		report.Loudness = loudness
+	}
+	report.Findings = report.findings()
+	return report
+}
+
+// findings lista os problemas encontrados, na ordem das constantes Finding*
+func (r *AnalysisReport) findings() []string {
+	findings := []string{}
+	if len(r.BlackSegments) > 0 {
+		findings = append(findings, FindingBlackSegments)
+	}
+	if len(r.FrozenSegments) > 0 {
+		findings = append(findings, FindingFrozenSegments)
+	}
+	if len(r.SilentSegments) > 0 {
+		findings = append(findings, FindingSilentSegments)
+	}
+	if !r.HasAudio {
+		findings = append(findings, FindingNoAudio)
+	}
+	if r.Loudness != nil && r.Loudness.TruePeak != nil && *r.Loudness.TruePeak > 0 {
+		findings = append(findings, FindingClipping)
+	}
+	return findings
+}
+
+// openSegment acompanha um trecho cujo fim ainda não foi impresso
+type openSegment struct {
+	start float64
+	open  bool
+}
+
+func (s *openSegment) begin(start float64) {
+	s.start = start
+	s.open = true
+}
+
+// end encerra o trecho aberto, se houver, e o inclui na lista
+func (s *openSegment) end(segments []MediaSegment, end float64) []MediaSegment {
+	if !s.open {
+		return segments
+	}
+	s.open = false
+	if end <= s.start {
+		return segments
+	}
+	return append(segments, newMediaSegment(s.start, end))
+}
+
+func newMediaSegment(start, end float64) MediaSegment {
+	start = math.Max(start, 0)
+	return MediaSegment{Start: start, End: end, Duration: math.Max(end-start, 0)}
+}
+
+// parseSeconds interpreta um instante impresso pelo ffmpeg
+func parseSeconds(value string) float64 {
+	seconds, _ := strconv.ParseFloat(value, 64)
+	return seconds
+}
+
+// parseLevel interpreta um nível em dB; "-inf" (silêncio absoluto) é descartado
+func parseLevel(value string) (float64, bool) {
+	level, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(level, 0) || math.IsNaN(level) {
+		return 0, false
+	}
+	return level, true
+}
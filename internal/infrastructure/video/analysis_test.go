@@ -0,0 +1,102 @@
+package video
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const sampleAnalysisOutput = `Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'video.mp4':
+[blackdetect @ 0x5581] black_start:0 black_end:2.5 black_duration:2.5
+[freezedetect @ 0x5582] lavfi.freezedetect.freeze_start: 10.01
+[freezedetect @ 0x5582] lavfi.freezedetect.freeze_duration: 3.2
+[freezedetect @ 0x5582] lavfi.freezedetect.freeze_end: 13.21
+[silencedetect @ 0x5583] silence_start: 20
+[silencedetect @ 0x5583] silence_end: 24.5 | silence_duration: 4.5
+[freezedetect @ 0x5582] lavfi.freezedetect.freeze_start: 55
+[silencedetect @ 0x5583] silence_start: 58
+[Parsed_ebur128_1 @ 0x5584] Summary:
+
+  Integrated loudness:
+    I:         -16.4 LUFS
+    Threshold: -26.7 LUFS
+
+  Loudness range:
+    LRA:         6.1 LU
+    Threshold: -36.8 LUFS
+    LRA low:   -20.3 LUFS
+    LRA high:  -14.2 LUFS
+
+  True peak:
+    Peak:        0.8 dBFS
+`
+
+func TestAnalysisParseOutput(t *testing.T) {
+	analyzer := NewVideoAnalyzer(DefaultAnalysisConfig(), SandboxConfig{})
+	report := analyzer.parseOutput(sampleAnalysisOutput, &VideoInfo{Duration: 60, AudioCodec: "aac"})
+
+	if len(report.BlackSegments) != 1 || report.BlackSegments[0] != (MediaSegment{Start: 0, End: 2.5, Duration: 2.5}) {
+		t.Errorf("trechos pretos inesperados: %+v", report.BlackSegments)
+	}
+
+	// Trechos abertos no fim do vídeo são encerrados na duração
+	if len(report.FrozenSegments) != 2 || report.FrozenSegments[1] != (MediaSegment{Start: 55, End: 60, Duration: 5}) {
+		t.Errorf("trechos congelados inesperados: %+v", report.FrozenSegments)
+	}
+	if len(report.SilentSegments) != 2 || report.SilentSegments[0].Duration != 4.5 || report.SilentSegments[1].End != 60 {
+		t.Errorf("trechos silenciosos inesperados: %+v", report.SilentSegments)
+	}
+
+	loudness := report.Loudness
+	if loudness == nil || *loudness.Integrated != -16.4 || *loudness.Range != 6.1 || *loudness.TruePeak != 0.8 {
+		t.Fatalf("loudness inesperado: %+v", loudness)
+	}
+
+	expected := []string{FindingBlackSegments, FindingFrozenSegments, FindingSilentSegments, FindingClipping}
+	if strings.Join(report.Findings, ",") != strings.Join(expected, ",") {
+		t.Errorf("esperado %v, mas obteve %v", expected, report.Findings)
+	}
+	if TotalDuration(report.SilentSegments) != 6.5 {
+		t.Errorf("esperado 6.5s de silêncio, mas obteve %v", TotalDuration(report.SilentSegments))
+	}
+}
+
+func TestAnalysisParseOutputSilentAudio(t *testing.T) {
+	output := "[Parsed_ebur128_1 @ 0x1] Summary:\n  Integrated loudness:\n    I:         -70.0 LUFS\n  True peak:\n    Peak:       -inf dBFS\n"
+	report := NewVideoAnalyzer(DefaultAnalysisConfig(), SandboxConfig{}).parseOutput(output, &VideoInfo{Duration: 10, AudioCodec: "aac"})
+
+	if report.Loudness == nil || report.Loudness.TruePeak != nil || *report.Loudness.Integrated != -70 {
+		t.Fatalf("esperado pico indefinido omitido, mas obteve %+v", report.Loudness)
+	}
+	if _, err := report.Marshal(); err != nil {
+		t.Errorf("relatório deveria ser serializável: %v", err)
+	}
+}
+
+func TestAnalysisNoAudio(t *testing.T) {
+	analyzer := NewVideoAnalyzer(DefaultAnalysisConfig(), SandboxConfig{})
+	args := strings.Join(analyzer.buildArgs("video.mp4", false), " ")
+	if strings.Contains(args, "-af") || !strings.Contains(args, "-vf blackdetect=d=2:pix_th=0.10,freezedetect=n=-60dB:d=2") {
+		t.Errorf("argumentos inesperados sem áudio: %s", args)
+	}
+
+	report := analyzer.parseOutput("", &VideoInfo{Duration: 10})
+	data, _ := report.Marshal()
+	var decoded map[string]any
+	json.Unmarshal(data, &decoded)
+	if decoded["loudness"] != nil || len(report.Findings) != 1 || report.Findings[0] != FindingNoAudio {
+		t.Errorf("relatório inesperado sem áudio: %s", data)
+	}
+}
+
+func TestAnalysisBuildArgsWithAudio(t *testing.T) {
+	analyzer := NewVideoAnalyzer(DefaultAnalysisConfig(), DefaultSandboxConfig())
+	args := strings.Join(analyzer.buildArgs("video.mp4", true), " ")
+
+	if !strings.Contains(args, "-map 0:a:0 -af silencedetect=n=-50dB:d=2,ebur128=peak=true:framelog=verbose -f null -") {
+		t.Errorf("esperado detectores de áudio, mas obteve: %s", args)
+	}
+	if !strings.Contains(args, "-format_whitelist") {
+		t.Errorf("esperado entrada restrita pelo sandbox, mas obteve: %s", args)
+	}
+}
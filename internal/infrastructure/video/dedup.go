@@ -0,0 +1,104 @@
+package video
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"os"
+)
+
+// DefaultDedupMaxDistance é a distância de Hamming padrão abaixo da qual dois
+// frames são considerados quase idênticos
+const DefaultDedupMaxDistance = 5
+
+// DedupResult representa o resultado da remoção de frames duplicados
+type DedupResult struct {
+	Frames  []string // frames mantidos, na ordem original
+	Removed int      // quantidade de frames descartados
+}
+
+// FrameDeduplicator descarta frames quase idênticos ao último frame mantido,
+// comparando hashes perceptuais (dHash) de 64 bits. Implementado em Go puro
+// para rodar na imagem Alpine sem bibliotecas nativas adicionais.
+type FrameDeduplicator struct {
+	maxDistance int
+	lastHash    uint64
+	hasLast     bool
+}
+
+// NewFrameDeduplicator cria um deduplicador com a distância de Hamming máxima informada
+func NewFrameDeduplicator(maxDistance int) *FrameDeduplicator {
+	if maxDistance < 0 {
+		maxDistance = DefaultDedupMaxDistance
+	}
+	return &FrameDeduplicator{maxDistance: maxDistance}
+}
+
+// Reset descarta o último hash mantido, iniciando uma nova sequência de frames
+func (d *FrameDeduplicator) Reset() {
+	d.lastHash = 0
+	d.hasLast = false
+}
+
+// Accept retorna true se o frame deve ser mantido, ou seja, se ele difere do
+// último frame mantido por mais que a distância máxima configurada
+func (d *FrameDeduplicator) Accept(img image.Image) bool {
+	hash := DHash(img)
+	if d.hasLast && HammingDistance(hash, d.lastHash) <= d.maxDistance {
+		return false
+	}
+	d.lastHash = hash
+	d.hasLast = true
+	return true
+}
+
+// Filter percorre os frames em ordem, remove do disco os quase duplicados e
+// retorna a lista de frames mantidos
+func (d *FrameDeduplicator) Filter(frames []string) (*DedupResult, error) {
+	d.Reset()
+
+	result := &DedupResult{Frames: make([]string, 0, len(frames))}
+	for _, frame := range frames {
+		img, err := decodeFrame(frame)
+		if err != nil {
+			return nil, err
+		}
+
+		if d.Accept(img) {
+			result.Frames = append(result.Frames, frame)
+			continue
+		}
+
+		if err := os.Remove(frame); err != nil {
+			return nil, fmt.Errorf("erro ao remover frame duplicado %s: %s", frame, err.Error())
+		}
+		result.Removed++
+	}
+
+	return result, nil
+}
+
+// DHash calcula o hash de diferença (dHash) da imagem: a imagem é reduzida
+// para 9x8 em escala de cinza e cada bit indica se um pixel é mais claro que
+// o vizinho à direita
+func DHash(img image.Image) uint64 {
+	const width, height = 9, 8
+
+	gray := grayThumbnail(img, width, height)
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if gray[y*width+x] > gray[y*width+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HammingDistance retorna a quantidade de bits diferentes entre dois hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
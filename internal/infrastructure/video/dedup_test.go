@@ -0,0 +1,141 @@
+package video
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFrame grava um PNG gerado pela função de cor informada
+func writeTestFrame(t *testing.T, path string, width, height int, fill func(x, y int) color.Color) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill(x, y))
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("erro ao criar frame de teste: %v", err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("erro ao codificar frame de teste: %v", err)
+	}
+}
+
+func horizontalGradient(x, y int) color.Color {
+	v := uint8(x * 4)
+	return color.RGBA{v, v, v, 255}
+}
+
+func verticalStripes(x, y int) color.Color {
+	if (x/8)%2 == 0 {
+		return color.RGBA{255, 255, 255, 255}
+	}
+	return color.RGBA{0, 0, 0, 255}
+}
+
+func TestHammingDistance(t *testing.T) {
+	testCases := []struct {
+		a, b     uint64
+		expected int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xFF, 0x00, 8},
+		{^uint64(0), 0, 64},
+	}
+
+	for _, tc := range testCases {
+		if got := HammingDistance(tc.a, tc.b); got != tc.expected {
+			t.Errorf("HammingDistance(%x, %x) = %d, esperado %d", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}
+
+func TestDHashIdenticalImages(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 48; y++ {
+			img.Set(x, y, horizontalGradient(x, y))
+		}
+	}
+
+	if DHash(img) != DHash(img) {
+		t.Errorf("esperado hash idêntico para a mesma imagem")
+	}
+}
+
+func TestFrameDeduplicatorFilter(t *testing.T) {
+	outputDir := "temp_dedup"
+	os.MkdirAll(outputDir, 0755)
+	defer os.RemoveAll(outputDir)
+
+	fills := []func(x, y int) color.Color{horizontalGradient, horizontalGradient, verticalStripes, verticalStripes}
+	var frames []string
+	for i, fill := range fills {
+		name := filepath.Join(outputDir, fmt.Sprintf("frame_%04d.png", i+1))
+		writeTestFrame(t, name, 64, 48, fill)
+		frames = append(frames, name)
+	}
+
+	result, err := NewFrameDeduplicator(DefaultDedupMaxDistance).Filter(frames)
+	if err != nil {
+		t.Fatalf("erro inesperado ao filtrar frames: %v", err)
+	}
+
+	if result.Removed != 2 {
+		t.Errorf("esperado 2 frames removidos, mas obteve %d", result.Removed)
+	}
+	if len(result.Frames) != 2 || result.Frames[0] != frames[0] || result.Frames[1] != frames[2] {
+		t.Errorf("frames mantidos inesperados: %v", result.Frames)
+	}
+	if _, err := os.Stat(frames[1]); !os.IsNotExist(err) {
+		t.Errorf("esperado que o frame duplicado fosse removido do disco")
+	}
+}
+
+func TestFrameDeduplicatorZeroDistanceKeepsDifferentFrames(t *testing.T) {
+	d := NewFrameDeduplicator(0)
+
+	a := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	b := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for x := 0; x < 32; x++ {
+		for y := 0; y < 32; y++ {
+			a.Set(x, y, horizontalGradient(x, y))
+			b.Set(x, y, verticalStripes(x, y))
+		}
+	}
+
+	if !d.Accept(a) || !d.Accept(b) {
+		t.Errorf("esperado que frames diferentes fossem mantidos")
+	}
+	if d.Accept(b) {
+		t.Errorf("esperado que frame idêntico ao anterior fosse descartado")
+	}
+}
+
+func TestFrameDeduplicatorInvalidFrame(t *testing.T) {
+	outputDir := "temp_dedup_invalid"
+	os.MkdirAll(outputDir, 0755)
+	defer os.RemoveAll(outputDir)
+
+	name := filepath.Join(outputDir, "frame_0001.png")
+	os.WriteFile(name, []byte("fake"), 0644)
+
+	result, err := NewFrameDeduplicator(DefaultDedupMaxDistance).Filter([]string{name})
+	if err == nil {
+		t.Errorf("esperado erro ao decodificar frame inválido")
+	}
+	if result != nil {
+		t.Errorf("esperado resultado nil em caso de erro")
+	}
+}
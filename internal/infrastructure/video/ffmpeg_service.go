@@ -2,40 +2,347 @@ package video
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
-	"path/filepath"
+	"strings"
 )
 
 type FrameExtractor interface {
 	ExtractFrames(videoPath, outputDir string) ([]string, error)
+	ExtractFramesWithOptions(videoPath, outputDir string, opts ExtractOptions) ([]Frame, error)
+	StreamFrames(videoPath string, opts ExtractOptions, handle FrameHandler) (int, error)
+	Plan(videoPath string, opts ExtractOptions) (ExtractOptions, error)
 }
 
-type ffmpegExtractor struct{}
+// defaultFrameRate é a taxa de extração de frames (frames por segundo de vídeo)
+const defaultFrameRate = 1.0
+
+// Frame representa um frame extraído e seu instante no vídeo de origem
+type Frame struct {
+	Path      string  // caminho do arquivo (no modo streaming, nome da entrada no ZIP)
+	Timestamp float64 // instante de apresentação em segundos
+
+	// Com rendições, Path aponta para a rendição de referência e Renditions
+	// lista os arquivos de todas elas. No modo streaming cada rendição é
+	// entregue separadamente, identificada por Rendition.
+	Rendition  string
+	Renditions []RenditionFile
+}
+
+// FramePaths retorna os caminhos dos frames, na mesma ordem
+func FramePaths(frames []Frame) []string {
+	paths := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		paths = append(paths, frame.Path)
+	}
+	return paths
+}
+
+// RetainFrames mantém apenas os frames cujos caminhos constam em paths,
+// preservando os instantes. Usado após filtros que operam sobre caminhos.
+func RetainFrames(frames []Frame, paths []string) []Frame {
+	keep := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		keep[path] = true
+	}
+
+	retained := make([]Frame, 0, len(paths))
+	for _, frame := range frames {
+		if keep[frame.Path] {
+			retained = append(retained, frame)
+		}
+	}
+	return retained
+}
+
+// frameTimestamp calcula o instante do frame de índice index (base 0) de uma
+// extração à taxa frameRate iniciada em start
+func frameTimestamp(index int, start, frameRate float64) float64 {
+	return start + float64(index)/frameRate
+}
+
+// ExtractOptions define parâmetros opcionais da extração de frames
+type ExtractOptions struct {
+	Watermark *Watermark // marca d'água aplicada a cada frame (nil desativa)
+
+	// FrameCount > 0 extrai exatamente essa quantidade de frames distribuídos
+	// uniformemente pelo vídeo, em vez de usar a taxa fixa de frames.
+	// TrimStart e TrimEnd descartam segundos do início e do fim do vídeo.
+	FrameCount int
+	TrimStart  float64
+	TrimEnd    float64
+
+	// Ranges limita a extração à taxa padrão aos trechos informados e
+	// Timestamps extrai um frame em cada instante (segundos). Sem eles o
+	// vídeo inteiro é processado.
+	Ranges     []TimeRange
+	Timestamps []float64
+
+	// Deinterlace força (true) ou impede (false) o desentrelaçamento; nil
+	// deixa a decisão para a detecção automática, quando configurada
+	Deinterlace *bool
+
+	// Renditions gera várias versões de cada frame na mesma execução do
+	// ffmpeg, uma por subpasta. Vazio grava apenas o frame original.
+	Renditions []Rendition
+
+	// Intervalo de entrada (seek rápido via -ss/-t), usado internamente
+	// pela extração por segmentos
+	start    float64
+	duration float64
+
+	// singleFrame extrai apenas o frame no instante start (seek preciso)
+	singleFrame bool
+
+	// Definidos por Plan: taxa efetiva (0 usa a padrão), largura da
+	// numeração dos arquivos (0 usa a mínima), metadados do vídeo e correção
+	// de orientação/proporção
+	frameRate  float64
+	digits     int
+	info       *VideoInfo
+	correction *FrameCorrection
+	interlace  *InterlaceDecision
+	planned    bool
+}
+
+// Interlace retorna a decisão de desentrelaçamento definida por Plan, ou nil
+// quando nenhuma decisão foi tomada
+func (o ExtractOptions) Interlace() *InterlaceDecision {
+	return o.interlace
+}
+
+// Correction retorna a correção de orientação e proporção definida por Plan,
+// ou nil quando os frames não precisam de ajuste
+func (o ExtractOptions) Correction() *FrameCorrection {
+	return o.correction
+}
+
+// FrameRate retorna a taxa de extração efetiva, em frames por segundo. Pode
+// ser menor que a padrão quando Plan reduz a taxa para respeitar o limite.
+func (o ExtractOptions) FrameRate() float64 {
+	if o.frameRate > 0 {
+		return o.frameRate
+	}
+	return defaultFrameRate
+}
+
+// nameDigits retorna a largura da numeração dos arquivos de frame
+func (o ExtractOptions) nameDigits() int {
+	return max(o.digits, minFrameNameDigits)
+}
+
+// ExtractorConfig agrupa as configurações do extrator definidas pelo worker
+type ExtractorConfig struct {
+	Parallel   ParallelConfig
+	Sandbox    SandboxConfig
+	FrameLimit FrameLimitConfig
+	Interlace  InterlaceConfig
+}
+
+// DefaultExtractorConfig retorna a configuração padrão: extração sequencial
+// com o ffmpeg restrito pelo sandbox
+func DefaultExtractorConfig() ExtractorConfig {
+	return ExtractorConfig{Sandbox: DefaultSandboxConfig()}
+}
+
+type ffmpegExtractor struct {
+	config ExtractorConfig
+}
 
 func NewFFmpegExtractor() FrameExtractor {
-	return &ffmpegExtractor{}
+	return &ffmpegExtractor{config: DefaultExtractorConfig()}
+}
+
+// NewFFmpegExtractorWithConfig cria um extrator com as configurações informadas
+func NewFFmpegExtractorWithConfig(config ExtractorConfig) FrameExtractor {
+	return &ffmpegExtractor{config: config}
 }
 
 func (f *ffmpegExtractor) ExtractFrames(videoPath string, outputDir string) ([]string, error) {
-	framePattern := filepath.Join(outputDir, "frame_%04d.png")
+	frames, err := f.ExtractFramesWithOptions(videoPath, outputDir, ExtractOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return FramePaths(frames), nil
+}
+
+func (f *ffmpegExtractor) ExtractFramesWithOptions(videoPath, outputDir string, opts ExtractOptions) ([]Frame, error) {
+	opts, err := f.Plan(videoPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Mode() {
+	case ExtractionModeTimestamps, ExtractionModeCount:
+		return f.extractSampledFrames(videoPath, outputDir, opts)
+	case ExtractionModeRange:
+		return f.extractRanges(videoPath, outputDir, opts)
+	}
+
+	// Vídeos longos são divididos em segmentos extraídos em paralelo
+	if segments := f.planParallelExtraction(videoPath, opts.info, opts.FrameRate()); segments != nil {
+		return f.extractSegments(videoPath, outputDir, opts, segments)
+	}
+
+	outputs, err := opts.fileOutputs(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	textFile, cleanup, err := prepareWatermarkText(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
 
-	cmd := exec.Command("ffmpeg",
-		"-i", videoPath,
-		"-vf", "fps=1",
-		"-y",
-		framePattern,
-	)
+	cmd := exec.Command("ffmpeg", f.buildOutputArgs(videoPath, opts, textFile, outputs)...)
 
-	output, err := cmd.CombinedOutput()
+	output, err := f.config.Sandbox.runSandboxed(cmd)
 	if err != nil {
-		return nil, fmt.Errorf("erro no ffmpeg: %s\nOutput: %s", err.Error(), string(output))
+		return nil, wrapFFmpegError(err, output)
 	}
 
-	// Listar arquivos gerados
-	frames, err := filepath.Glob(filepath.Join(outputDir, "*.png"))
+	// Listar arquivos gerados em ordem numérica
+	collected, err := collectFrames(outputDir, opts)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao listar frames: %s", err.Error())
+		return nil, err
+	}
+	if err := f.checkFrameLimit(len(collected)); err != nil {
+		return nil, err
 	}
 
+	frames := make([]Frame, 0, len(collected))
+	for i, paths := range collected {
+		frames = append(frames, newFrame(paths, frameTimestamp(i, opts.start, opts.FrameRate()), opts))
+	}
 	return frames, nil
 }
+
+// prepareWatermarkText grava o texto da marca d'água em um arquivo temporário,
+// lido pelo drawtext para evitar problemas de escape dentro do filtergraph
+func prepareWatermarkText(opts ExtractOptions) (string, func(), error) {
+	if opts.Watermark == nil || opts.Watermark.ImagePath != "" {
+		return "", func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "watermark-*.txt")
+	if err != nil {
+		return "", nil, fmt.Errorf("erro ao preparar marca d'água: %s", err.Error())
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+
+	_, err = tmp.WriteString(opts.Watermark.Text)
+	tmp.Close()
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("erro ao preparar marca d'água: %s", err.Error())
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+// wrapFFmpegError adiciona a saída do ffmpeg ao erro, preservando
+// ResourceLimitError para que o chamador identifique violações do sandbox
+func wrapFFmpegError(err error, output []byte) error {
+	if _, ok := err.(*ResourceLimitError); ok {
+		return err
+	}
+	return fmt.Errorf("erro no ffmpeg: %s\nOutput: %s", err.Error(), string(output))
+}
+
+// buildExtractArgs monta os argumentos do ffmpeg para a extração de frames
+// com uma única saída, terminando com os argumentos informados (arquivos ou pipe)
+func (f *ffmpegExtractor) buildExtractArgs(videoPath string, opts ExtractOptions, watermarkTextFile string, output ...string) []string {
+	return f.buildOutputArgs(videoPath, opts, watermarkTextFile, [][]string{output})
+}
+
+// buildOutputArgs monta os argumentos do ffmpeg com um conjunto de argumentos
+// de saída por rendição (ou um único, sem rendições). As rendições saem de um
+// split após os filtros e a marca d'água, cada uma com sua própria escala.
+func (f *ffmpegExtractor) buildOutputArgs(videoPath string, opts ExtractOptions, watermarkTextFile string, outputs [][]string) []string {
+	var filters []string
+	if opts.interlace != nil && opts.interlace.Deinterlace {
+		filters = append(filters, deinterlaceFilter)
+	}
+	if !opts.singleFrame {
+		filters = append(filters, "fps="+formatFloat(opts.FrameRate()))
+	}
+	if opts.correction != nil {
+		filters = append(filters, opts.correction.Filters...)
+	}
+
+	// -nostdin impede que o ffmpeg leia do terminal/stdin do worker
+	args := []string{"-nostdin"}
+	args = append(args, f.config.Sandbox.inputArgs(true)...)
+	if opts.correction != nil {
+		// A rotação é aplicada explicitamente pelos filtros de correção
+		args = append(args, "-noautorotate")
+	}
+	switch {
+	case opts.singleFrame:
+		// -ss antes da entrada com decodificação até o instante exato
+		args = append(args, "-accurate_seek", "-ss", formatFloat(opts.start))
+	case opts.duration > 0:
+		args = append(args, "-ss", formatFloat(opts.start), "-t", formatFloat(opts.duration))
+	}
+	args = append(args, "-i", videoPath)
+
+	imageWatermark := opts.Watermark != nil && opts.Watermark.ImagePath != ""
+	if opts.Watermark != nil && !imageWatermark {
+		filters = append(filters, opts.Watermark.textFilter(watermarkTextFile))
+	}
+	if imageWatermark {
+		args = append(args, f.config.Sandbox.inputArgs(false)...)
+		args = append(args, "-i", opts.Watermark.ImagePath)
+	}
+
+	frameLimit := func(args []string) []string {
+		if opts.singleFrame {
+			return append(args, "-frames:v", "1")
+		}
+		return args
+	}
+
+	// Sem marca d'água em imagem nem rendições basta uma cadeia simples
+	if !imageWatermark && len(opts.Renditions) == 0 {
+		if len(filters) > 0 {
+			args = append(args, "-vf", strings.Join(filters, ","))
+		}
+		return append(frameLimit(args), outputs[0]...)
+	}
+
+	var graph []string
+	current := "[0:v]"
+	if len(filters) > 0 {
+		graph = append(graph, current+strings.Join(filters, ",")+"[base]")
+		current = "[base]"
+	}
+	if imageWatermark {
+		marked := ""
+		if len(opts.Renditions) > 0 {
+			marked = "[marked]"
+		}
+		graph = append(graph, opts.Watermark.imageFilter(current, marked))
+		current = marked
+	}
+
+	if len(opts.Renditions) == 0 {
+		args = append(args, "-filter_complex", strings.Join(graph, ";"))
+		return append(frameLimit(args), outputs[0]...)
+	}
+
+	split := fmt.Sprintf("%ssplit=%d", current, len(opts.Renditions))
+	for i := range opts.Renditions {
+		split += fmt.Sprintf("[s%d]", i)
+	}
+	graph = append(graph, split)
+	for i, r := range opts.Renditions {
+		graph = append(graph, fmt.Sprintf("[s%d]%s[r%d]", i, r.filter(), i))
+	}
+
+	args = append(args, "-filter_complex", strings.Join(graph, ";"))
+	for i := range opts.Renditions {
+		args = append(args, "-map", fmt.Sprintf("[r%d]", i))
+		args = append(frameLimit(args), outputs[i]...)
+	}
+	return args
+}
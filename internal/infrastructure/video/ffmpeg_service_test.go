@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -39,3 +40,46 @@ func TestExtractFrames_ErrorOnGlob(t *testing.T) {
 		t.Errorf("esperado frames nil em caso de erro")
 	}
 }
+
+func TestBuildExtractArgsDefault(t *testing.T) {
+	args := (&ffmpegExtractor{}).buildExtractArgs("video.mp4", ExtractOptions{}, "", "-y", "out/frame_%04d.png")
+
+	expected := []string{"-nostdin", "-i", "video.mp4", "-vf", "fps=1", "-y", "out/frame_%04d.png"}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("argumentos inesperados: %v", args)
+	}
+}
+
+func TestBuildExtractArgsImageWatermark(t *testing.T) {
+	opts := ExtractOptions{Watermark: validWatermark()}
+	args := strings.Join((&ffmpegExtractor{}).buildExtractArgs("video.mp4", opts, "", "-y", "frame_%04d.png"), " ")
+
+	if !strings.Contains(args, "-i video.mp4 -i watermark.png -filter_complex [0:v]fps=1[base];") {
+		t.Errorf("esperado segunda entrada e filter_complex com a marca d'água, mas obteve: %s", args)
+	}
+}
+
+func TestBuildExtractArgsTextWatermark(t *testing.T) {
+	w := validWatermark()
+	w.ImagePath = ""
+	w.Text = "confidencial"
+	args := strings.Join((&ffmpegExtractor{}).buildExtractArgs("video.mp4", ExtractOptions{Watermark: w}, "/tmp/wm.txt", "-y", "frame_%04d.png"), " ")
+
+	if !strings.Contains(args, "-vf fps=1,drawtext=textfile=/tmp/wm.txt:") {
+		t.Errorf("esperado filtro drawtext após fps, mas obteve: %s", args)
+	}
+}
+
+func TestBuildExtractArgsSandbox(t *testing.T) {
+	extractor := &ffmpegExtractor{config: DefaultExtractorConfig()}
+	opts := ExtractOptions{Watermark: validWatermark()}
+	args := strings.Join(extractor.buildExtractArgs("video.mp4", opts, "", "-y", "frame_%04d.png"), " ")
+
+	expectedVideoInput := "-nostdin -protocol_whitelist file -format_whitelist mov,matroska,webm,avi,flv,mpegts,asf -i video.mp4"
+	if !strings.HasPrefix(args, expectedVideoInput) {
+		t.Errorf("esperado vídeo restrito pelo sandbox, mas obteve: %s", args)
+	}
+	if !strings.Contains(args, "-protocol_whitelist file -i watermark.png") {
+		t.Errorf("esperado marca d'água restrita a arquivos locais, mas obteve: %s", args)
+	}
+}
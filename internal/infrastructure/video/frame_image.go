@@ -0,0 +1,75 @@
+package video
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // registra decoder JPEG para image.Decode
+	_ "image/png"  // registra decoder PNG para image.Decode
+	"os"
+)
+
+// decodeFrame abre e decodifica um frame extraído (PNG ou JPEG)
+func decodeFrame(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar frame %s: %s", path, err.Error())
+	}
+	return img, nil
+}
+
+// luminance retorna a luminância (0-255) de um pixel usando os pesos Rec. 601
+func luminance(img image.Image, x, y int) float64 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	// RGBA() retorna valores de 16 bits
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257.0
+}
+
+// grayThumbnail reduz a imagem para width x height em escala de cinza,
+// calculando a média de uma amostra de pixels de cada célula. A amostragem
+// limita o custo em frames de alta resolução sem depender de bibliotecas nativas.
+func grayThumbnail(img image.Image, width, height int) []float64 {
+	const maxSamplesPerAxis = 8
+
+	bounds := img.Bounds()
+	out := make([]float64, width*height)
+	if bounds.Empty() {
+		return out
+	}
+
+	for cy := 0; cy < height; cy++ {
+		y0 := bounds.Min.Y + cy*bounds.Dy()/height
+		y1 := bounds.Min.Y + (cy+1)*bounds.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for cx := 0; cx < width; cx++ {
+			x0 := bounds.Min.X + cx*bounds.Dx()/width
+			x1 := bounds.Min.X + (cx+1)*bounds.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			stepY := max(1, (y1-y0)/maxSamplesPerAxis)
+			stepX := max(1, (x1-x0)/maxSamplesPerAxis)
+
+			var sum float64
+			var count int
+			for y := y0; y < y1 && y < bounds.Max.Y; y += stepY {
+				for x := x0; x < x1 && x < bounds.Max.X; x += stepX {
+					sum += luminance(img, x, y)
+					count++
+				}
+			}
+			if count > 0 {
+				out[cy*width+cx] = sum / float64(count)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package video
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Políticas aplicadas quando o job excede o limite de frames
+const (
+	FrameLimitPolicyError  = "error"  // rejeita o job
+	FrameLimitPolicyReduce = "reduce" // reduz a taxa (ou a quantidade) para caber no limite
+)
+
+// minFrameNameDigits é a largura mínima da numeração dos frames (frame_0001.png)
+const minFrameNameDigits = 4
+
+// FrameLimitConfig define o limite de frames gerados por job
+type FrameLimitConfig struct {
+	MaxFrames int    // limite de frames por job; 0 desativa
+	Policy    string // FrameLimitPolicyError (padrão) ou FrameLimitPolicyReduce
+}
+
+// reduce indica se a taxa deve ser reduzida em vez de rejeitar o job
+func (c FrameLimitConfig) reduce() bool {
+	return strings.EqualFold(c.Policy, FrameLimitPolicyReduce)
+}
+
+// FrameLimitError indica que o job geraria mais frames que o permitido
+type FrameLimitError struct {
+	Expected int
+	Max      int
+}
+
+func (e *FrameLimitError) Error() string {
+	return fmt.Sprintf("o job geraria %d frames e excede o limite de %d frames por job", e.Expected, e.Max)
+}
+
+// Plan analisa o vídeo uma única vez, define a correção de orientação e
+// proporção e o desentrelaçamento, calcula a quantidade esperada de frames do job, escolhe a
+// largura da numeração dos arquivos e aplica o limite de frames configurado,
+// reduzindo a taxa ou a quantidade quando a política permite. As opções
+// retornadas podem ser passadas diretamente para a extração.
+func (f *ffmpegExtractor) Plan(videoPath string, opts ExtractOptions) (ExtractOptions, error) {
+	if opts.planned {
+		return opts, nil
+	}
+	limit := f.config.FrameLimit
+
+	// Falhas do probe são reportadas apenas pelos modos que dependem dele;
+	// nos demais o próprio ffmpeg reporta o problema
+	info, probeErr := ProbeVideoWithSandbox(videoPath, f.config.Sandbox)
+	if probeErr == nil {
+		opts.info = info
+		opts.correction = correctionFor(info)
+	}
+	opts.interlace = f.decideInterlace(videoPath, opts)
+
+	var expected int
+	switch opts.Mode() {
+	case ExtractionModeTimestamps:
+		expected = len(opts.Timestamps)
+		if limit.MaxFrames > 0 && expected > limit.MaxFrames {
+			// Instantes pedidos explicitamente não podem ser descartados
+			return opts, &FrameLimitError{Expected: expected, Max: limit.MaxFrames}
+		}
+
+	case ExtractionModeCount:
+		expected = opts.FrameCount
+		if limit.MaxFrames > 0 && expected > limit.MaxFrames {
+			if !limit.reduce() {
+				return opts, &FrameLimitError{Expected: expected, Max: limit.MaxFrames}
+			}
+			opts.FrameCount = limit.MaxFrames
+			expected = limit.MaxFrames
+		}
+
+	case ExtractionModeRange:
+		segments, err := rangeSegments(opts.Ranges)
+		if err != nil {
+			return opts, err
+		}
+		span := 0.0
+		for _, seg := range segments {
+			span += seg.Duration
+			expected += expectedFrameCount(seg.Duration, opts.FrameRate())
+		}
+		if limit.MaxFrames > 0 && expected > limit.MaxFrames {
+			// Cada trecho pode gerar um frame a mais que span*taxa
+			rate := float64(limit.MaxFrames-len(segments)) / span
+			if !limit.reduce() || rate <= 0 {
+				return opts, &FrameLimitError{Expected: expected, Max: limit.MaxFrames}
+			}
+			opts.frameRate = rate
+			expected = limit.MaxFrames
+		}
+
+	case ExtractionModeRate:
+		if probeErr != nil {
+			// Sem limite configurado a falha é reportada pelo próprio ffmpeg
+			if limit.MaxFrames > 0 {
+				return opts, probeErr
+			}
+			break
+		}
+		expected = expectedFrameCount(info.Duration, opts.FrameRate())
+		if limit.MaxFrames > 0 && expected > limit.MaxFrames {
+			rate := float64(limit.MaxFrames-1) / info.Duration
+			if !limit.reduce() || rate <= 0 {
+				return opts, &FrameLimitError{Expected: expected, Max: limit.MaxFrames}
+			}
+			opts.frameRate = rate
+			expected = limit.MaxFrames
+		}
+	}
+
+	opts.digits = frameNameDigits(expected)
+	opts.planned = true
+	return opts, nil
+}
+
+// checkFrameLimit garante o limite mesmo quando o vídeo gera mais frames que
+// o estimado (ex.: duração informada pelo contêiner menor que a real)
+func (f *ffmpegExtractor) checkFrameLimit(count int) error {
+	if limit := f.config.FrameLimit.MaxFrames; limit > 0 && count > limit {
+		return &FrameLimitError{Expected: count, Max: limit}
+	}
+	return nil
+}
+
+// expectedFrameCount estima quantos frames o filtro fps gera em duration
+// segundos: um no instante zero e um a cada 1/frameRate segundos
+func expectedFrameCount(duration, frameRate float64) int {
+	if duration <= 0 || frameRate <= 0 {
+		return 0
+	}
+	return int(math.Floor(duration*frameRate)) + 1
+}
+
+// frameNameDigits escolhe a largura da numeração para que todos os nomes
+// tenham o mesmo tamanho e a ordem alfabética coincida com a numérica
+func frameNameDigits(expected int) int {
+	return max(len(strconv.Itoa(expected)), minFrameNameDigits)
+}
+
+// frameName monta o nome do frame de número index (base 1)
+func frameName(index, digits int) string {
+	return fmt.Sprintf("frame_%0*d.png", digits, index)
+}
+
+// framePattern monta o padrão de saída do ffmpeg com a largura informada
+func framePattern(digits int) string {
+	return fmt.Sprintf("frame_%%0%dd.png", digits)
+}
+
+// frameNumber extrai o número do nome do frame (frame_0042.png -> 42)
+func frameNumber(path string) (int, bool) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	digits, ok := strings.CutPrefix(name, "frame_")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	return n, err == nil
+}
+
+// sortFramePaths ordena os frames pelo número, independente da largura da
+// numeração; nomes fora do padrão ficam no fim, em ordem alfabética
+func sortFramePaths(paths []string) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		ni, oki := frameNumber(paths[i])
+		nj, okj := frameNumber(paths[j])
+		switch {
+		case oki && okj:
+			return ni < nj
+		case oki != okj:
+			return oki
+		}
+		return paths[i] < paths[j]
+	})
+}
@@ -0,0 +1,132 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFrameNameDigits(t *testing.T) {
+	testCases := map[int]int{0: 4, 9999: 4, 10000: 5, 250000: 6}
+	for expected, digits := range testCases {
+		if got := frameNameDigits(expected); got != digits {
+			t.Errorf("%d frames: esperado largura %d, obtido %d", expected, digits, got)
+		}
+	}
+}
+
+func TestFrameNameAndPattern(t *testing.T) {
+	if got := frameName(42, 6); got != "frame_000042.png" {
+		t.Errorf("nome inesperado: %s", got)
+	}
+	if got := framePattern(6); got != "frame_%06d.png" {
+		t.Errorf("padrão inesperado: %s", got)
+	}
+	if got := fmt.Sprintf(framePattern(5), 12345); got != frameName(12345, 5) {
+		t.Errorf("padrão e nome divergem: %s", got)
+	}
+}
+
+func TestSortFramePathsNumeric(t *testing.T) {
+	paths := []string{"out/frame_10000.png", "out/frame_9999.png", "out/outro.png", "out/frame_0002.png"}
+	sortFramePaths(paths)
+
+	expected := []string{"out/frame_0002.png", "out/frame_9999.png", "out/frame_10000.png", "out/outro.png"}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Fatalf("ordem inesperada: %v", paths)
+		}
+	}
+}
+
+func TestExpectedFrameCount(t *testing.T) {
+	if got := expectedFrameCount(10, 1); got != 11 {
+		t.Errorf("esperado 11 frames para 10s a 1 fps, obtido %d", got)
+	}
+	if got := expectedFrameCount(0, 1); got != 0 {
+		t.Errorf("esperado 0 frames para duração zero, obtido %d", got)
+	}
+}
+
+func TestPlanCountModeLimit(t *testing.T) {
+	extractor := &ffmpegExtractor{config: ExtractorConfig{FrameLimit: FrameLimitConfig{MaxFrames: 100}}}
+
+	_, err := extractor.Plan("video.mp4", ExtractOptions{FrameCount: 500})
+	var limitErr *FrameLimitError
+	if !errors.As(err, &limitErr) || limitErr.Expected != 500 || limitErr.Max != 100 {
+		t.Fatalf("esperado FrameLimitError, mas obteve %v", err)
+	}
+
+	extractor.config.FrameLimit.Policy = FrameLimitPolicyReduce
+	opts, err := extractor.Plan("video.mp4", ExtractOptions{FrameCount: 500})
+	if err != nil {
+		t.Fatalf("erro inesperado com política de redução: %v", err)
+	}
+	if opts.FrameCount != 100 {
+		t.Errorf("esperado quantidade reduzida para 100, mas obteve %d", opts.FrameCount)
+	}
+}
+
+func TestPlanTimestampsNeverReduced(t *testing.T) {
+	extractor := &ffmpegExtractor{config: ExtractorConfig{FrameLimit: FrameLimitConfig{MaxFrames: 2, Policy: FrameLimitPolicyReduce}}}
+	if _, err := extractor.Plan("video.mp4", ExtractOptions{Timestamps: []float64{1, 2, 3}}); err == nil {
+		t.Error("esperado erro ao exceder o limite com instantes explícitos")
+	}
+}
+
+func TestPlanRangeModeReducesRate(t *testing.T) {
+	extractor := &ffmpegExtractor{config: ExtractorConfig{FrameLimit: FrameLimitConfig{MaxFrames: 52, Policy: FrameLimitPolicyReduce}}}
+	opts, err := extractor.Plan("video.mp4", ExtractOptions{Ranges: []TimeRange{{Start: 0, End: 100}, {Start: 200, End: 300}}})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if opts.FrameRate() != 0.25 {
+		t.Errorf("esperado taxa reduzida para 0.25 fps, mas obteve %v", opts.FrameRate())
+	}
+	total := expectedFrameCount(100, opts.FrameRate()) * 2
+	if total > 52 {
+		t.Errorf("taxa reduzida ainda excede o limite: %d frames", total)
+	}
+}
+
+func TestPlanWidensNumbering(t *testing.T) {
+	opts, err := (&ffmpegExtractor{}).Plan("video.mp4", ExtractOptions{FrameCount: 20000})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if opts.nameDigits() != 5 {
+		t.Errorf("esperado numeração com 5 dígitos, mas obteve %d", opts.nameDigits())
+	}
+
+	// Opções já planejadas não são recalculadas
+	again, _ := (&ffmpegExtractor{config: ExtractorConfig{FrameLimit: FrameLimitConfig{MaxFrames: 1}}}).Plan("video.mp4", opts)
+	if again.FrameCount != 20000 {
+		t.Errorf("esperado opções planejadas inalteradas, mas obteve %+v", again)
+	}
+}
+
+func TestPlanRateModeProbeErrorWithLimit(t *testing.T) {
+	extractor := &ffmpegExtractor{config: ExtractorConfig{FrameLimit: FrameLimitConfig{MaxFrames: 10}}}
+	if _, err := extractor.Plan("video_inexistente.mp4", ExtractOptions{}); err == nil {
+		t.Error("esperado erro quando o limite não pode ser verificado")
+	}
+
+	opts, err := (&ffmpegExtractor{}).Plan("video_inexistente.mp4", ExtractOptions{})
+	if err != nil || opts.nameDigits() != minFrameNameDigits {
+		t.Errorf("esperado planejamento padrão sem limite, mas obteve %+v, %v", opts, err)
+	}
+}
+
+func TestCheckFrameLimit(t *testing.T) {
+	extractor := &ffmpegExtractor{config: ExtractorConfig{FrameLimit: FrameLimitConfig{MaxFrames: 10}}}
+	if err := extractor.checkFrameLimit(10); err != nil {
+		t.Errorf("erro inesperado no limite: %v", err)
+	}
+	if err := extractor.checkFrameLimit(11); err == nil {
+		t.Error("esperado erro acima do limite")
+	}
+	if err := (&ffmpegExtractor{}).checkFrameLimit(1 << 20); err != nil {
+		t.Errorf("esperado limite desativado por padrão, mas obteve %v", err)
+	}
+}
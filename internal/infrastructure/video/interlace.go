@@ -0,0 +1,119 @@
+package video
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strconv"
+)
+
+// DefaultIdetSampleFrames é a quantidade de frames analisada pelo idet
+const DefaultIdetSampleFrames = 300
+
+// deinterlaceFilter é o filtro aplicado a vídeos entrelaçados. Precede o fps
+// para que os campos consecutivos ainda estejam disponíveis.
+const deinterlaceFilter = "bwdif"
+
+// Origem da decisão de desentrelaçamento registrada no manifesto
+const (
+	InterlaceSourceJob       = "job"       // forçada pela mensagem
+	InterlaceSourceDetection = "detection" // decidida pelo idet
+)
+
+// InterlaceConfig controla a detecção automática de vídeo entrelaçado
+type InterlaceConfig struct {
+	Detect       bool // executa o idet em uma amostra do vídeo
+	SampleFrames int  // frames analisados; 0 usa DefaultIdetSampleFrames
+}
+
+// IdetResult contém a contagem de frames por tipo da detecção multi-frame do idet
+type IdetResult struct {
+	TFF          int `json:"tff"`
+	BFF          int `json:"bff"`
+	Progressive  int `json:"progressive"`
+	Undetermined int `json:"undetermined"`
+}
+
+// Interlaced indica se os frames entrelaçados predominam sobre os progressivos
+func (r IdetResult) Interlaced() bool {
+	interlaced := r.TFF + r.BFF
+	return interlaced > 0 && interlaced > r.Progressive
+}
+
+// InterlaceDecision registra se os frames foram desentrelaçados e por quê
+type InterlaceDecision struct {
+	Deinterlace bool        `json:"deinterlace"`
+	Source      string      `json:"source"`
+	Detection   *IdetResult `json:"detection,omitempty"`
+}
+
+// idetMultiFrame captura o resumo "Multi frame detection" impresso pelo idet
+var idetMultiFrame = regexp.MustCompile(`Multi frame detection:\s*TFF:\s*(\d+)\s*BFF:\s*(\d+)\s*Progressive:\s*(\d+)\s*Undetermined:\s*(\d+)`)
+
+// errIdetSummaryMissing indica que o ffmpeg não imprimiu o resumo do idet
+var errIdetSummaryMissing = errors.New("resumo do idet não encontrado na saída do ffmpeg")
+
+// parseIdetOutput extrai o último resumo multi-frame da saída do ffmpeg
+func parseIdetOutput(output string) (*IdetResult, bool) {
+	matches := idetMultiFrame.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	last := matches[len(matches)-1]
+	values := make([]int, 4)
+	for i := range values {
+		values[i], _ = strconv.Atoi(last[i+1])
+	}
+	return &IdetResult{TFF: values[0], BFF: values[1], Progressive: values[2], Undetermined: values[3]}, true
+}
+
+// decideInterlace define o desentrelaçamento do job: a preferência da
+// mensagem tem precedência; sem ela, o idet decide quando a detecção está
+// ativa. Retorna nil quando nada foi decidido (frames extraídos como estão).
+func (f *ffmpegExtractor) decideInterlace(videoPath string, opts ExtractOptions) *InterlaceDecision {
+	if opts.Deinterlace != nil {
+		return &InterlaceDecision{Deinterlace: *opts.Deinterlace, Source: InterlaceSourceJob}
+	}
+	if !f.config.Interlace.Detect {
+		return nil
+	}
+
+	result, err := f.detectInterlace(videoPath, opts.info)
+	if err != nil {
+		return nil
+	}
+	return &InterlaceDecision{Deinterlace: result.Interlaced(), Source: InterlaceSourceDetection, Detection: result}
+}
+
+// detectInterlace executa o idet em uma amostra a partir de 10% da duração,
+// evitando aberturas e vinhetas que costumam ser progressivas
+func (f *ffmpegExtractor) detectInterlace(videoPath string, info *VideoInfo) (*IdetResult, error) {
+	sampleFrames := f.config.Interlace.SampleFrames
+	if sampleFrames <= 0 {
+		sampleFrames = DefaultIdetSampleFrames
+	}
+
+	args := []string{"-nostdin"}
+	args = append(args, f.config.Sandbox.inputArgs(true)...)
+	if info != nil && info.Duration > 0 {
+		args = append(args, "-ss", formatFloat(info.Duration/10))
+	}
+	args = append(args,
+		"-i", videoPath,
+		"-map", "0:v:0",
+		"-vf", "idet",
+		"-frames:v", strconv.Itoa(sampleFrames),
+		"-an", "-f", "null", "-",
+	)
+
+	output, err := f.config.Sandbox.runSandboxed(exec.Command("ffmpeg", args...))
+	if err != nil {
+		return nil, wrapFFmpegError(err, output)
+	}
+	result, ok := parseIdetOutput(string(output))
+	if !ok {
+		return nil, wrapFFmpegError(errIdetSummaryMissing, output)
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package video
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleIdetOutput = `[Parsed_idet_0 @ 0x55d0] Repeated Fields: Neither:   301 Top:     0 Bottom:     0
+[Parsed_idet_0 @ 0x55d0] Single frame detection: TFF:   180 BFF:     0 Progressive:    60 Undetermined:    61
+[Parsed_idet_0 @ 0x55d0] Multi frame detection: TFF:   240 BFF:     0 Progressive:    55 Undetermined:     6
+`
+
+func TestParseIdetOutput(t *testing.T) {
+	result, ok := parseIdetOutput(sampleIdetOutput)
+	if !ok {
+		t.Fatal("esperado resumo multi-frame do idet")
+	}
+
+	expected := IdetResult{TFF: 240, BFF: 0, Progressive: 55, Undetermined: 6}
+	if *result != expected {
+		t.Errorf("esperado %+v, mas obteve %+v", expected, *result)
+	}
+	if !result.Interlaced() {
+		t.Error("esperado vídeo classificado como entrelaçado")
+	}
+
+	if _, ok := parseIdetOutput("sem resumo"); ok {
+		t.Error("esperado falha sem o resumo do idet")
+	}
+}
+
+func TestIdetResultProgressive(t *testing.T) {
+	if (IdetResult{Progressive: 290, Undetermined: 10}).Interlaced() {
+		t.Error("esperado vídeo progressivo")
+	}
+	if (IdetResult{Undetermined: 300}).Interlaced() {
+		t.Error("esperado vídeo indeterminado tratado como progressivo")
+	}
+}
+
+func TestDecideInterlaceJobOverride(t *testing.T) {
+	force := true
+	extractor := &ffmpegExtractor{config: ExtractorConfig{Interlace: InterlaceConfig{Detect: true}}}
+
+	decision := extractor.decideInterlace("video_inexistente.mp4", ExtractOptions{Deinterlace: &force})
+	if decision == nil || !decision.Deinterlace || decision.Source != InterlaceSourceJob {
+		t.Errorf("esperado desentrelaçamento forçado pelo job, mas obteve %+v", decision)
+	}
+
+	// Sem preferência do job e com a detecção desativada nada é decidido
+	if decision := (&ffmpegExtractor{}).decideInterlace("video.mp4", ExtractOptions{}); decision != nil {
+		t.Errorf("esperado nenhuma decisão, mas obteve %+v", decision)
+	}
+
+	// Falha na detecção mantém os frames como estão
+	if decision := extractor.decideInterlace("video_inexistente.mp4", ExtractOptions{}); decision != nil {
+		t.Errorf("esperado nenhuma decisão quando o idet falha, mas obteve %+v", decision)
+	}
+}
+
+func TestBuildExtractArgsDeinterlace(t *testing.T) {
+	opts := ExtractOptions{interlace: &InterlaceDecision{Deinterlace: true}}
+	args := strings.Join((&ffmpegExtractor{}).buildExtractArgs("video.mp4", opts, "", "-y", "frame_%04d.png"), " ")
+
+	if !strings.Contains(args, "-vf bwdif,fps=1 ") {
+		t.Errorf("esperado desentrelaçamento antes do fps, mas obteve: %s", args)
+	}
+}
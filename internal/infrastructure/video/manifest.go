@@ -0,0 +1,273 @@
+package video
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFileName é o nome do manifesto incluído no arquivo de saída
+const ManifestFileName = "manifest.json"
+
+// ChecksumsFileName é o nome do arquivo de somas SHA-256 (formato do sha256sum)
+const ChecksumsFileName = "SHA256SUMS"
+
+// ManifestFrame descreve um frame presente no arquivo de saída. Com
+// rendições, os arquivos e suas dimensões ficam em Renditions.
+type ManifestFrame struct {
+	File       string              `json:"file"`
+	Timestamp  float64             `json:"timestamp"` // instante de apresentação em segundos
+	Width      int                 `json:"width,omitempty"`
+	Height     int                 `json:"height,omitempty"`
+	Size       int64               `json:"size,omitempty"` // bytes
+	SHA256     string              `json:"sha256,omitempty"`
+	Renditions []ManifestRendition `json:"renditions,omitempty"`
+	Quality    *FrameQuality       `json:"quality,omitempty"`
+}
+
+// ManifestRendition descreve o arquivo de uma rendição de um frame
+type ManifestRendition struct {
+	Name   string `json:"name"`
+	File   string `json:"file"` // caminho da entrada no arquivo de saída
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int64  `json:"size"` // bytes
+	SHA256 string `json:"sha256"`
+}
+
+// ManifestAttachment descreve um arquivo adicional do arquivo de saída,
+// como o relatório de análise
+type ManifestAttachment struct {
+	File   string `json:"file"`
+	Size   int64  `json:"size"` // bytes
+	SHA256 string `json:"sha256"`
+}
+
+// ManifestSource descreve o vídeo de origem
+type ManifestSource struct {
+	Key  string     `json:"key"`
+	Size int64      `json:"size,omitempty"` // bytes
+	Info *VideoInfo `json:"info,omitempty"`
+}
+
+// Filtros de frames que podem ficar de fora do job, registrados em
+// ManifestSettings.SkippedFilters
+const (
+	FilterDedup   = "dedup"
+	FilterQuality = "quality"
+)
+
+// ManifestSettings registra as configurações usadas na extração
+type ManifestSettings struct {
+	Mode              string             `json:"mode"`
+	FrameRate         float64            `json:"frame_rate,omitempty"`
+	FrameCount        int                `json:"frame_count,omitempty"`
+	TrimStart         float64            `json:"trim_start,omitempty"`
+	TrimEnd           float64            `json:"trim_end,omitempty"`
+	Ranges            []TimeRange        `json:"ranges,omitempty"`
+	ImageFormat       string             `json:"image_format"`
+	Correction        *FrameCorrection   `json:"correction,omitempty"`
+	Interlace         *InterlaceDecision `json:"interlace,omitempty"`
+	Renditions        []Rendition        `json:"renditions,omitempty"`
+	Streaming         bool               `json:"streaming"`
+	Watermark         bool               `json:"watermark"`
+	WatermarkName     string             `json:"watermark_name,omitempty"`
+	DedupMaxDistance  *int               `json:"dedup_max_distance,omitempty"`
+	QualityThresholds *QualityThresholds `json:"quality_thresholds,omitempty"`
+	SkippedFilters    []string           `json:"skipped_filters,omitempty"` // filtros ativos no worker e não aplicados (ex.: no modo streaming)
+}
+
+// Manifest descreve o conteúdo do arquivo de frames gerado para um vídeo
+type Manifest struct {
+	Source         *ManifestSource      `json:"source,omitempty"`
+	Settings       *ManifestSettings    `json:"settings,omitempty"`
+	Frames         []ManifestFrame      `json:"frames"`
+	RejectedFrames []RejectedFrame      `json:"rejected_frames,omitempty"`
+	DedupRemoved   *int                 `json:"dedup_removed,omitempty"` // frames quase idênticos descartados; nil sem deduplicação
+	Attachments    []ManifestAttachment `json:"attachments,omitempty"`
+}
+
+// NewManifest cria um manifesto vazio; os frames são incluídos com AddFrame
+// ou AddFrameData
+func NewManifest() *Manifest {
+	return &Manifest{Frames: []ManifestFrame{}}
+}
+
+// NewManifestSettings descreve as opções de extração usadas no job
+func NewManifestSettings(opts ExtractOptions) *ManifestSettings {
+	settings := &ManifestSettings{
+		Mode:        opts.Mode(),
+		ImageFormat: "png",
+		Watermark:   opts.Watermark != nil,
+		Correction:  opts.Correction(),
+		Interlace:   opts.Interlace(),
+		Renditions:  opts.Renditions,
+	}
+	if opts.Watermark != nil {
+		settings.WatermarkName = opts.Watermark.Name
+	}
+	switch settings.Mode {
+	case ExtractionModeCount:
+		settings.FrameCount = opts.FrameCount
+		settings.TrimStart = opts.TrimStart
+		settings.TrimEnd = opts.TrimEnd
+	case ExtractionModeRange:
+		settings.FrameRate = opts.FrameRate()
+		settings.Ranges = opts.Ranges
+	case ExtractionModeRate:
+		settings.FrameRate = opts.FrameRate()
+	}
+	return settings
+}
+
+// Hash retorna o SHA-256 (hex) das configurações serializadas em JSON,
+// que identifica jobs extraídos com as mesmas opções
+func (s *ManifestSettings) Hash() string {
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AddFrame lê o frame do disco, ou cada uma de suas rendições, e o registra
+// no manifesto
+func (m *Manifest) AddFrame(frame Frame) error {
+	if len(frame.Renditions) == 0 {
+		data, err := os.ReadFile(frame.Path)
+		if err != nil {
+			return err
+		}
+		return m.AddFrameData(frame, data)
+	}
+
+	for _, r := range frame.Renditions {
+		data, err := os.ReadFile(r.Path)
+		if err != nil {
+			return err
+		}
+		rendition := Frame{Path: RenditionEntryName(r.Name, r.Path), Timestamp: frame.Timestamp, Rendition: r.Name}
+		if err := m.AddFrameData(rendition, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddFrameData registra um frame já codificado em memória, calculando
+// dimensões, tamanho e SHA-256. As rendições de um mesmo frame devem ser
+// registradas em sequência e são agrupadas pelo nome do arquivo.
+func (m *Manifest) AddFrameData(frame Frame, data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("erro ao ler dimensões do frame %s: %s", frame.Path, err.Error())
+	}
+	sum := sha256.Sum256(data)
+
+	if frame.Rendition == "" {
+		m.Frames = append(m.Frames, ManifestFrame{
+			File:      filepath.Base(frame.Path),
+			Timestamp: frame.Timestamp,
+			Width:     config.Width,
+			Height:    config.Height,
+			Size:      int64(len(data)),
+			SHA256:    hex.EncodeToString(sum[:]),
+		})
+		return nil
+	}
+
+	file := filepath.Base(frame.Path)
+	if n := len(m.Frames); n == 0 || m.Frames[n-1].File != file {
+		m.Frames = append(m.Frames, ManifestFrame{File: file, Timestamp: frame.Timestamp})
+	}
+	last := &m.Frames[len(m.Frames)-1]
+	last.Renditions = append(last.Renditions, ManifestRendition{
+		Name:   frame.Rendition,
+		File:   RenditionEntryName(frame.Rendition, frame.Path),
+		Width:  config.Width,
+		Height: config.Height,
+		Size:   int64(len(data)),
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+	return nil
+}
+
+// AddAttachment registra um arquivo adicional incluído no arquivo de saída
+func (m *Manifest) AddAttachment(name string, data []byte) {
+	sum := sha256.Sum256(data)
+	m.Attachments = append(m.Attachments, ManifestAttachment{
+		File:   name,
+		Size:   int64(len(data)),
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+}
+
+// ApplyQuality registra as métricas de qualidade nos frames do manifesto
+// e a lista de frames descartados
+func (m *Manifest) ApplyQuality(result *QualityResult) {
+	if result == nil {
+		return
+	}
+
+	scores := make(map[string]FrameQuality, len(result.Scores))
+	for path, quality := range result.Scores {
+		scores[filepath.Base(path)] = quality
+	}
+	for i := range m.Frames {
+		if quality, ok := scores[m.Frames[i].File]; ok {
+			q := quality
+			m.Frames[i].Quality = &q
+		}
+	}
+
+	for _, rejected := range result.Rejected {
+		rejected.File = filepath.Base(rejected.File)
+		m.RejectedFrames = append(m.RejectedFrames, rejected)
+	}
+}
+
+// ApplyDedup registra a quantidade de frames descartados pela deduplicação
+func (m *Manifest) ApplyDedup(result *DedupResult) {
+	if result == nil {
+		return
+	}
+	removed := result.Removed
+	m.DedupRemoved = &removed
+}
+
+// Marshal serializa o manifesto em JSON indentado
+func (m *Manifest) Marshal() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// WriteFile grava o manifesto em JSON no caminho informado
+func (m *Manifest) WriteFile(path string) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Checksums gera o conteúdo do arquivo SHA256SUMS com os frames e anexos do
+// manifesto e o próprio manifesto serializado, verificável com "sha256sum -c"
+func (m *Manifest) Checksums(manifestData []byte) []byte {
+	var buf bytes.Buffer
+	for _, frame := range m.Frames {
+		if len(frame.Renditions) == 0 {
+			fmt.Fprintf(&buf, "%s  %s\n", frame.SHA256, frame.File)
+		}
+		for _, r := range frame.Renditions {
+			fmt.Fprintf(&buf, "%s  %s\n", r.SHA256, r.File)
+		}
+	}
+	for _, attachment := range m.Attachments {
+		fmt.Fprintf(&buf, "%s  %s\n", attachment.SHA256, attachment.File)
+	}
+	sum := sha256.Sum256(manifestData)
+	fmt.Fprintf(&buf, "%s  %s\n", hex.EncodeToString(sum[:]), ManifestFileName)
+	return buf.Bytes()
+}
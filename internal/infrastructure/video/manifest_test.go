@@ -0,0 +1,180 @@
+package video
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestAddFrame(t *testing.T) {
+	outputDir := "temp_manifest_add"
+	os.MkdirAll(outputDir, 0755)
+	defer os.RemoveAll(outputDir)
+
+	path := filepath.Join(outputDir, "frame_0003.png")
+	writeTestFrame(t, path, 40, 30, func(x, y int) color.Color { return color.Gray{Y: 128} })
+
+	m := NewManifest()
+	if err := m.AddFrame(Frame{Path: path, Timestamp: 2}); err != nil {
+		t.Fatalf("erro inesperado ao adicionar frame: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	sum := sha256.Sum256(data)
+	frame := m.Frames[0]
+	if frame.File != "frame_0003.png" {
+		t.Errorf("esperado nome base 'frame_0003.png', mas obteve '%s'", frame.File)
+	}
+	if frame.Timestamp != 2 || frame.Width != 40 || frame.Height != 30 {
+		t.Errorf("instante ou dimensões inesperados: %+v", frame)
+	}
+	if frame.Size != int64(len(data)) || frame.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("tamanho ou SHA-256 inesperados: %+v", frame)
+	}
+}
+
+func TestManifestAddFrameDataInvalidImage(t *testing.T) {
+	if err := NewManifest().AddFrameData(Frame{Path: "frame_0001.png"}, []byte("não é png")); err == nil {
+		t.Error("esperado erro para dados que não são uma imagem")
+	}
+}
+
+func TestManifestApplyQuality(t *testing.T) {
+	kept := filepath.Join("temp", "frame_0002.png")
+	m := NewManifest()
+	m.Frames = append(m.Frames, ManifestFrame{File: "frame_0002.png"})
+	m.ApplyQuality(&QualityResult{
+		Frames: []string{kept},
+		Scores: map[string]FrameQuality{kept: {MeanLuminance: 120, Variance: 900, Sharpness: 50}},
+		Rejected: []RejectedFrame{
+			{File: filepath.Join("temp", "frame_0001.png"), Reason: RejectReasonBlack},
+		},
+	})
+
+	if m.Frames[0].Quality == nil || m.Frames[0].Quality.MeanLuminance != 120 {
+		t.Errorf("esperado métricas de qualidade no frame, mas obteve %+v", m.Frames[0].Quality)
+	}
+	if len(m.RejectedFrames) != 1 || m.RejectedFrames[0].File != "frame_0001.png" {
+		t.Errorf("frames rejeitados inesperados: %+v", m.RejectedFrames)
+	}
+
+	// Aplicar resultado nil não deve alterar o manifesto
+	m.ApplyQuality(nil)
+	if len(m.RejectedFrames) != 1 {
+		t.Errorf("esperado manifesto inalterado ao aplicar resultado nil")
+	}
+}
+
+func TestManifestApplyDedup(t *testing.T) {
+	m := NewManifest()
+	data, _ := m.Marshal()
+	if strings.Contains(string(data), "dedup_removed") {
+		t.Errorf("esperado dedup_removed omitido sem deduplicação: %s", data)
+	}
+
+	// Zero frames removidos também é registrado quando a deduplicação roda
+	m.ApplyDedup(&DedupResult{Removed: 0})
+	data, _ = m.Marshal()
+	if !strings.Contains(string(data), `"dedup_removed": 0`) {
+		t.Errorf("esperado dedup_removed igual a 0: %s", data)
+	}
+
+	m.ApplyDedup(&DedupResult{Frames: []string{"frame_0001.png"}, Removed: 3})
+	if m.DedupRemoved == nil || *m.DedupRemoved != 3 {
+		t.Errorf("esperado 3 frames removidos, mas obteve %v", m.DedupRemoved)
+	}
+}
+
+func TestManifestChecksums(t *testing.T) {
+	m := NewManifest()
+	m.Frames = append(m.Frames, ManifestFrame{File: "frame_0001.png", SHA256: "abc"})
+
+	manifestData := []byte(`{"frames":[]}`)
+	sum := sha256.Sum256(manifestData)
+	expected := "abc  frame_0001.png\n" + hex.EncodeToString(sum[:]) + "  " + ManifestFileName + "\n"
+
+	if got := string(m.Checksums(manifestData)); got != expected {
+		t.Errorf("esperado:\n%s\nobtido:\n%s", expected, got)
+	}
+}
+
+func TestManifestWriteFile(t *testing.T) {
+	outputDir := "temp_manifest"
+	os.MkdirAll(outputDir, 0755)
+	defer os.RemoveAll(outputDir)
+
+	m := NewManifest()
+	m.Source = &ManifestSource{Key: "videos/teste.mp4", Info: &VideoInfo{Duration: 10, Codec: "h264"}}
+	m.Settings = NewManifestSettings(ExtractOptions{})
+	m.Frames = append(m.Frames, ManifestFrame{File: "frame_0001.png"})
+
+	path := filepath.Join(outputDir, ManifestFileName)
+	if err := m.WriteFile(path); err != nil {
+		t.Fatalf("erro inesperado ao gravar manifesto: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("erro ao ler manifesto: %v", err)
+	}
+
+	var decoded Manifest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("manifesto gravado não é um JSON válido: %v", err)
+	}
+	if len(decoded.Frames) != 1 || decoded.Frames[0].Quality != nil {
+		t.Errorf("conteúdo do manifesto inesperado: %+v", decoded)
+	}
+	if decoded.Source == nil || decoded.Source.Info.Codec != "h264" {
+		t.Errorf("origem do manifesto inesperada: %+v", decoded.Source)
+	}
+	if decoded.Settings == nil || decoded.Settings.Mode != ExtractionModeRate || decoded.Settings.FrameRate != defaultFrameRate {
+		t.Errorf("configurações do manifesto inesperadas: %+v", decoded.Settings)
+	}
+	if strings.Contains(string(data), "dedup_max_distance") || strings.Contains(string(data), "frame_count") || strings.Contains(string(data), "skipped_filters") {
+		t.Error("esperado campos opcionais omitidos quando não configurados")
+	}
+}
+
+func TestManifestSettingsHash(t *testing.T) {
+	hash := NewManifestSettings(ExtractOptions{FrameCount: 50}).Hash()
+	if hash != NewManifestSettings(ExtractOptions{FrameCount: 50}).Hash() {
+		t.Error("esperado hash igual para as mesmas configurações")
+	}
+	if hash == NewManifestSettings(ExtractOptions{FrameCount: 51}).Hash() {
+		t.Error("esperado hash diferente para outras configurações")
+	}
+	if len(hash) != 64 {
+		t.Errorf("esperado SHA-256 em hex, mas obteve %q", hash)
+	}
+}
+
+func TestNewManifestSettingsCountMode(t *testing.T) {
+	settings := NewManifestSettings(ExtractOptions{FrameCount: 50, TrimStart: 5})
+
+	if settings.Mode != ExtractionModeCount || settings.FrameCount != 50 || settings.TrimStart != 5 {
+		t.Errorf("configurações inesperadas no modo de quantidade: %+v", settings)
+	}
+	if settings.FrameRate != 0 {
+		t.Errorf("esperado taxa de frames omitida no modo de quantidade, mas obteve %v", settings.FrameRate)
+	}
+}
+
+func TestManifestAttachmentChecksums(t *testing.T) {
+	m := NewManifest()
+	data := []byte(`{"findings":[]}`)
+	m.AddAttachment(AnalysisFileName, data)
+
+	sum := sha256.Sum256(data)
+	if len(m.Attachments) != 1 || m.Attachments[0].SHA256 != hex.EncodeToString(sum[:]) || m.Attachments[0].Size != int64(len(data)) {
+		t.Fatalf("anexo inesperado: %+v", m.Attachments)
+	}
+	if got := string(m.Checksums(nil)); !strings.HasPrefix(got, hex.EncodeToString(sum[:])+"  "+AnalysisFileName+"\n") {
+		t.Errorf("esperado anexo no SHA256SUMS, mas obteve:\n%s", got)
+	}
+}
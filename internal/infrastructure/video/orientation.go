@@ -0,0 +1,74 @@
+package video
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FrameCorrection descreve a normalização aplicada para que os frames
+// correspondam ao que um player exibe: rotação e pixels não quadrados
+type FrameCorrection struct {
+	Rotation          int      `json:"rotation,omitempty"`            // graus no sentido horário
+	SampleAspectRatio string   `json:"sample_aspect_ratio,omitempty"` // SAR original, convertido para 1:1
+	Filters           []string `json:"filters"`                       // filtros do ffmpeg aplicados
+}
+
+// correctionFor calcula a correção necessária para o vídeo. Retorna nil
+// quando os frames já estão na orientação e proporção de exibição.
+func correctionFor(info *VideoInfo) *FrameCorrection {
+	if info == nil {
+		return nil
+	}
+
+	correction := &FrameCorrection{}
+
+	// A proporção é corrigida antes da rotação, pois o SAR se refere à
+	// largura dos pixels armazenados
+	if num, den, ok := parseSAR(info.SampleAspectRatio); ok && num != den {
+		correction.SampleAspectRatio = info.SampleAspectRatio
+		correction.Filters = append(correction.Filters, "scale=iw*sar:ih", "setsar=1")
+	}
+
+	switch info.Rotation {
+	case 90:
+		correction.Filters = append(correction.Filters, "transpose=clock")
+	case 180:
+		correction.Filters = append(correction.Filters, "hflip", "vflip")
+	case 270:
+		correction.Filters = append(correction.Filters, "transpose=cclock")
+	}
+	if len(correction.Filters) == 0 {
+		return nil
+	}
+	correction.Rotation = info.Rotation
+	return correction
+}
+
+// normalizeRotation arredonda a rotação para o múltiplo de 90 graus mais
+// próximo no intervalo [0, 360)
+func normalizeRotation(degrees float64) int {
+	rotation := int(math.Round(degrees/90)) * 90 % 360
+	if rotation < 0 {
+		rotation += 360
+	}
+	return rotation
+}
+
+// parseSAR interpreta a proporção de pixel do ffprobe (ex.: "4:3"). Valores
+// ausentes ou indefinidos ("0:1", "N/A") são ignorados.
+func parseSAR(value string) (int, int, bool) {
+	num, den, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, 0, false
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 {
+		return 0, 0, false
+	}
+	d, err := strconv.Atoi(den)
+	if err != nil || d <= 0 {
+		return 0, 0, false
+	}
+	return n, d, true
+}
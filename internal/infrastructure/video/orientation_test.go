@@ -0,0 +1,70 @@
+package video
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRotation(t *testing.T) {
+	testCases := map[float64]int{0: 0, 90: 90, -90: 270, 180: 180, -180: 180, 270: 270, 360: 0, 89.9: 90}
+	for degrees, expected := range testCases {
+		if got := normalizeRotation(degrees); got != expected {
+			t.Errorf("%v graus: esperado %d, obtido %d", degrees, expected, got)
+		}
+	}
+}
+
+func TestParseSAR(t *testing.T) {
+	if num, den, ok := parseSAR("4:3"); !ok || num != 4 || den != 3 {
+		t.Errorf("esperado SAR 4:3, mas obteve %d:%d (%v)", num, den, ok)
+	}
+	for _, value := range []string{"", "0:1", "N/A", "1"} {
+		if _, _, ok := parseSAR(value); ok {
+			t.Errorf("esperado SAR %q ignorado", value)
+		}
+	}
+}
+
+func TestCorrectionFor(t *testing.T) {
+	if correction := correctionFor(&VideoInfo{SampleAspectRatio: "1:1"}); correction != nil {
+		t.Errorf("esperado nenhuma correção para vídeo normal, mas obteve %+v", correction)
+	}
+	if correction := correctionFor(nil); correction != nil {
+		t.Errorf("esperado nenhuma correção sem metadados")
+	}
+
+	testCases := []struct {
+		info     VideoInfo
+		expected string
+	}{
+		{VideoInfo{Rotation: 90}, "transpose=clock"},
+		{VideoInfo{Rotation: 180}, "hflip,vflip"},
+		{VideoInfo{Rotation: 270}, "transpose=cclock"},
+		{VideoInfo{SampleAspectRatio: "4:3"}, "scale=iw*sar:ih,setsar=1"},
+		{VideoInfo{Rotation: 90, SampleAspectRatio: "32:27"}, "scale=iw*sar:ih,setsar=1,transpose=clock"},
+	}
+
+	for _, tc := range testCases {
+		correction := correctionFor(&tc.info)
+		if correction == nil {
+			t.Errorf("%+v: esperado correção", tc.info)
+			continue
+		}
+		if got := strings.Join(correction.Filters, ","); got != tc.expected {
+			t.Errorf("%+v: esperado %q, obtido %q", tc.info, tc.expected, got)
+		}
+		if correction.Rotation != tc.info.Rotation {
+			t.Errorf("%+v: rotação registrada inesperada: %d", tc.info, correction.Rotation)
+		}
+	}
+}
+
+func TestBuildExtractArgsWithCorrection(t *testing.T) {
+	opts := ExtractOptions{correction: correctionFor(&VideoInfo{Rotation: 90})}
+	args := strings.Join((&ffmpegExtractor{}).buildExtractArgs("video.mp4", opts, "", "-y", "frame_%04d.png"), " ")
+
+	expected := "-nostdin -noautorotate -i video.mp4 -vf fps=1,transpose=clock -y frame_%04d.png"
+	if args != expected {
+		t.Errorf("esperado %q, mas obteve %q", expected, args)
+	}
+}
@@ -0,0 +1,182 @@
+package video
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VideoInfo contém os metadados do vídeo obtidos via ffprobe
+type VideoInfo struct {
+	Duration   float64 `json:"duration"`    // duração em segundos
+	FormatName string  `json:"format_name"` // contêiner, ex.: "mov,mp4,m4a,3gp,3g2,mj2"
+	Codec      string  `json:"codec"`       // codec do stream de vídeo, ex.: "h264"
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	FrameRate  float64 `json:"frame_rate"` // frames por segundo do stream de vídeo
+
+	// Rotation é a rotação de exibição no sentido horário (0, 90, 180 ou 270)
+	// e SampleAspectRatio a proporção dos pixels (ex.: "4:3"); ambos descrevem
+	// como um player apresenta os frames armazenados
+	Rotation          int    `json:"rotation,omitempty"`
+	SampleAspectRatio string `json:"sample_aspect_ratio,omitempty"`
+
+	// AudioCodec é o codec do primeiro stream de áudio; vazio sem áudio
+	AudioCodec string `json:"audio_codec,omitempty"`
+}
+
+// ffprobeOutput espelha os campos usados da saída JSON do ffprobe
+type ffprobeOutput struct {
+	Format struct {
+		Duration   string `json:"duration"`
+		FormatName string `json:"format_name"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		Duration     string `json:"duration"`
+		SampleAspect string `json:"sample_aspect_ratio"`
+		Tags         struct {
+			Rotate string `json:"rotate"`
+		} `json:"tags"`
+		SideDataList []struct {
+			SideDataType string  `json:"side_data_type"`
+			Rotation     float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+}
+
+// ProbeVideo lê os metadados do primeiro stream de vídeo do arquivo com o
+// sandbox padrão
+func ProbeVideo(videoPath string) (*VideoInfo, error) {
+	return ProbeVideoWithSandbox(videoPath, DefaultSandboxConfig())
+}
+
+// ProbeVideoWithSandbox lê os metadados do primeiro stream de vídeo do
+// arquivo, com o ffprobe sujeito às mesmas restrições de formato e limites de
+// recurso do ffmpeg
+func ProbeVideoWithSandbox(videoPath string, sandbox SandboxConfig) (*VideoInfo, error) {
+	args := []string{"-v", "error"}
+	args = append(args, sandbox.probeArgs()...)
+	args = append(args, "-print_format", "json", "-show_format", "-show_streams", videoPath)
+
+	output, err := sandbox.output(exec.Command("ffprobe", args...))
+	if err != nil {
+		return nil, fmt.Errorf("erro no ffprobe: %w", err)
+	}
+	return parseProbeOutput(output)
+}
+
+// parseProbeOutput interpreta a saída JSON do ffprobe
+func parseProbeOutput(data []byte) (*VideoInfo, error) {
+	var probe ffprobeOutput
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar saída do ffprobe: %s", err.Error())
+	}
+
+	info := &VideoInfo{FormatName: probe.Format.FormatName}
+	info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+
+	found := false
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "audio" && info.AudioCodec == "" {
+			info.AudioCodec = stream.CodecName
+		}
+		if stream.CodecType != "video" || found {
+			continue
+		}
+		found = true
+		info.Codec = stream.CodecName
+		info.Width = stream.Width
+		info.Height = stream.Height
+		info.FrameRate = parseRational(stream.AvgFrameRate)
+		info.SampleAspectRatio = stream.SampleAspect
+
+		// A matriz de exibição traz a rotação no sentido anti-horário;
+		// arquivos antigos usam a tag "rotate", já no sentido horário
+		for _, sideData := range stream.SideDataList {
+			if sideData.SideDataType == "Display Matrix" {
+				info.Rotation = normalizeRotation(-sideData.Rotation)
+			}
+		}
+		if info.Rotation == 0 && stream.Tags.Rotate != "" {
+			if degrees, err := strconv.ParseFloat(stream.Tags.Rotate, 64); err == nil {
+				info.Rotation = normalizeRotation(degrees)
+			}
+		}
+		if info.Duration == 0 {
+			info.Duration, _ = strconv.ParseFloat(stream.Duration, 64)
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("nenhum stream de vídeo encontrado")
+	}
+	return info, nil
+}
+
+// ProbeKeyframes retorna os instantes (em segundos) dos keyframes do stream
+// de vídeo. Com -skip_frame nokey o decodificador descarta os demais frames,
+// e a saída tem uma linha por keyframe em vez de uma por pacote.
+func ProbeKeyframes(videoPath string) ([]float64, error) {
+	return ProbeKeyframesWithSandbox(videoPath, DefaultSandboxConfig())
+}
+
+// ProbeKeyframesWithSandbox é ProbeKeyframes com o ffprobe sujeito ao sandbox
+// informado
+func ProbeKeyframesWithSandbox(videoPath string, sandbox SandboxConfig) ([]float64, error) {
+	args := []string{"-v", "error"}
+	args = append(args, sandbox.probeArgs()...)
+	args = append(args,
+		"-select_streams", "v:0",
+		"-skip_frame", "nokey",
+		"-show_entries", "frame=pts_time",
+		"-of", "csv=p=0",
+		videoPath,
+	)
+
+	output, err := sandbox.output(exec.Command("ffprobe", args...))
+	if err != nil {
+		return nil, fmt.Errorf("erro no ffprobe: %w", err)
+	}
+	return parseKeyframes(output), nil
+}
+
+// parseKeyframes interpreta uma linha "pts_time" por keyframe, ignorando
+// frames sem instante (N/A)
+func parseKeyframes(data []byte) []float64 {
+	var keyframes []float64
+	for _, line := range strings.Split(string(data), "\n") {
+		ptsTime, _, _ := strings.Cut(strings.TrimSpace(line), ",")
+		if pts, err := strconv.ParseFloat(ptsTime, 64); err == nil {
+			keyframes = append(keyframes, pts)
+		}
+	}
+	sort.Float64s(keyframes)
+	return keyframes
+}
+
+// parseRational converte frações do ffprobe (ex.: "30000/1001") em decimal
+func parseRational(value string) float64 {
+	num, den, ok := strings.Cut(value, "/")
+	if !ok {
+		parsed, _ := strconv.ParseFloat(value, 64)
+		return parsed
+	}
+
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
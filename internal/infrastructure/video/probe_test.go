@@ -0,0 +1,120 @@
+package video
+
+import (
+	"testing"
+)
+
+const sampleProbeOutput = `{
+	"streams": [
+		{"codec_type": "audio", "codec_name": "aac"},
+		{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080,
+		 "avg_frame_rate": "30000/1001", "duration": "125.500000"}
+	],
+	"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "125.533000"}
+}`
+
+func TestParseProbeOutput(t *testing.T) {
+	info, err := parseProbeOutput([]byte(sampleProbeOutput))
+	if err != nil {
+		t.Fatalf("erro inesperado ao interpretar ffprobe: %v", err)
+	}
+
+	if info.Codec != "h264" || info.Width != 1920 || info.Height != 1080 {
+		t.Errorf("stream de vídeo inesperado: %+v", info)
+	}
+	if info.Duration != 125.533 {
+		t.Errorf("esperado duração do formato 125.533, mas obteve %v", info.Duration)
+	}
+	if info.FrameRate < 29.97 || info.FrameRate > 29.98 {
+		t.Errorf("esperado frame rate ~29.97, mas obteve %v", info.FrameRate)
+	}
+	if info.FormatName != "mov,mp4,m4a,3gp,3g2,mj2" {
+		t.Errorf("formato inesperado: %s", info.FormatName)
+	}
+	if info.AudioCodec != "aac" {
+		t.Errorf("esperado áudio aac, mas obteve %q", info.AudioCodec)
+	}
+}
+
+func TestParseProbeOutputStreamDurationFallback(t *testing.T) {
+	data := `{"streams": [{"codec_type": "video", "duration": "10.0"}], "format": {}}`
+	info, err := parseProbeOutput([]byte(data))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if info.Duration != 10 {
+		t.Errorf("esperado duração do stream 10, mas obteve %v", info.Duration)
+	}
+}
+
+func TestParseProbeOutputOrientation(t *testing.T) {
+	data := `{"streams": [{"codec_type": "video", "sample_aspect_ratio": "4:3",
+		"side_data_list": [{"side_data_type": "Display Matrix", "rotation": -90}]}], "format": {}}`
+	info, err := parseProbeOutput([]byte(data))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if info.Rotation != 90 || info.SampleAspectRatio != "4:3" {
+		t.Errorf("esperado rotação 90 e SAR 4:3, mas obteve %d e %q", info.Rotation, info.SampleAspectRatio)
+	}
+
+	// Arquivos antigos trazem a rotação na tag "rotate"
+	data = `{"streams": [{"codec_type": "video", "tags": {"rotate": "270"}}], "format": {}}`
+	info, err = parseProbeOutput([]byte(data))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if info.Rotation != 270 {
+		t.Errorf("esperado rotação 270 pela tag, mas obteve %d", info.Rotation)
+	}
+}
+
+func TestParseProbeOutputErrors(t *testing.T) {
+	if _, err := parseProbeOutput([]byte("not json")); err == nil {
+		t.Errorf("esperado erro para JSON inválido")
+	}
+	if _, err := parseProbeOutput([]byte(`{"streams": [{"codec_type": "audio"}]}`)); err == nil {
+		t.Errorf("esperado erro quando não há stream de vídeo")
+	}
+}
+
+func TestParseKeyframes(t *testing.T) {
+	data := "4.004000\n0.000000\n\nN/A\n2.002000,\n"
+	keyframes := parseKeyframes([]byte(data))
+
+	expected := []float64{0, 2.002, 4.004}
+	if len(keyframes) != len(expected) {
+		t.Fatalf("esperado %d keyframes, mas obteve %v", len(expected), keyframes)
+	}
+	for i := range expected {
+		if keyframes[i] != expected[i] {
+			t.Errorf("keyframe %d: esperado %v, obtido %v", i, expected[i], keyframes[i])
+		}
+	}
+}
+
+func TestParseRational(t *testing.T) {
+	testCases := map[string]float64{
+		"25/1":  25,
+		"30":    30,
+		"0/0":   0,
+		"abc/1": 0,
+		"":      0,
+	}
+
+	for input, expected := range testCases {
+		if got := parseRational(input); got != expected {
+			t.Errorf("parseRational(%q) = %v, esperado %v", input, got, expected)
+		}
+	}
+}
+
+func TestProbeVideoMissingFile(t *testing.T) {
+	info, err := ProbeVideo("fake_video.mp4")
+	if err == nil {
+		t.Errorf("esperado erro ao analisar vídeo inexistente")
+	}
+	if info != nil {
+		t.Errorf("esperado info nil em caso de erro")
+	}
+}
@@ -0,0 +1,168 @@
+package video
+
+import (
+	"fmt"
+	"image"
+	"os"
+)
+
+// Limites padrão da análise de qualidade. Um limite igual a zero desativa a verificação.
+const (
+	DefaultMinLuminance = 16.0 // abaixo disso o frame é considerado preto
+	DefaultMinVariance  = 20.0 // abaixo disso o frame é considerado em branco (cor sólida)
+	DefaultMinSharpness = 0.0  // variância do Laplaciano; desativado por padrão
+)
+
+// Motivos de descarte de um frame pela análise de qualidade
+const (
+	RejectReasonBlack  = "black"
+	RejectReasonBlank  = "blank"
+	RejectReasonBlurry = "blurry"
+)
+
+// qualityAnalysisWidth é a largura usada na análise; frames maiores são
+// reduzidos antes do cálculo para manter o custo previsível
+const qualityAnalysisWidth = 320
+
+// QualityThresholds define os limites mínimos para que um frame seja mantido
+type QualityThresholds struct {
+	MinLuminance float64 `json:"min_luminance"`
+	MinVariance  float64 `json:"min_variance"`
+	MinSharpness float64 `json:"min_sharpness"`
+}
+
+// FrameQuality contém as métricas de qualidade calculadas para um frame
+type FrameQuality struct {
+	MeanLuminance float64 `json:"mean_luminance"`
+	Variance      float64 `json:"variance"`
+	Sharpness     float64 `json:"sharpness"`
+}
+
+// RejectedFrame representa um frame descartado pela análise de qualidade
+type RejectedFrame struct {
+	File    string       `json:"file"`
+	Reason  string       `json:"reason"`
+	Quality FrameQuality `json:"quality"`
+}
+
+// QualityResult representa o resultado da filtragem por qualidade
+type QualityResult struct {
+	Frames   []string                // frames mantidos, na ordem original
+	Scores   map[string]FrameQuality // métricas dos frames mantidos, indexadas pelo caminho
+	Rejected []RejectedFrame         // frames descartados, com o motivo
+}
+
+// FrameQualityAnalyzer pontua frames por luminância média, variância
+// (frames em branco) e nitidez (variância do Laplaciano)
+type FrameQualityAnalyzer struct {
+	thresholds QualityThresholds
+}
+
+// NewFrameQualityAnalyzer cria um analisador com os limites informados
+func NewFrameQualityAnalyzer(thresholds QualityThresholds) *FrameQualityAnalyzer {
+	return &FrameQualityAnalyzer{thresholds: thresholds}
+}
+
+// Analyze calcula as métricas de qualidade de uma imagem
+func (a *FrameQualityAnalyzer) Analyze(img image.Image) FrameQuality {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return FrameQuality{}
+	}
+	if width > qualityAnalysisWidth {
+		height = max(1, height*qualityAnalysisWidth/width)
+		width = qualityAnalysisWidth
+	}
+
+	gray := grayThumbnail(img, width, height)
+
+	var sum float64
+	for _, v := range gray {
+		sum += v
+	}
+	mean := sum / float64(len(gray))
+
+	var variance float64
+	for _, v := range gray {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(gray))
+
+	return FrameQuality{
+		MeanLuminance: mean,
+		Variance:      variance,
+		Sharpness:     laplacianVariance(gray, width, height),
+	}
+}
+
+// Evaluate retorna o motivo de descarte do frame, ou string vazia se ele deve ser mantido
+func (a *FrameQualityAnalyzer) Evaluate(q FrameQuality) string {
+	switch {
+	case a.thresholds.MinLuminance > 0 && q.MeanLuminance < a.thresholds.MinLuminance:
+		return RejectReasonBlack
+	case a.thresholds.MinVariance > 0 && q.Variance < a.thresholds.MinVariance:
+		return RejectReasonBlank
+	case a.thresholds.MinSharpness > 0 && q.Sharpness < a.thresholds.MinSharpness:
+		return RejectReasonBlurry
+	}
+	return ""
+}
+
+// Filter analisa os frames, remove do disco os reprovados e retorna as
+// métricas de todos eles
+func (a *FrameQualityAnalyzer) Filter(frames []string) (*QualityResult, error) {
+	result := &QualityResult{
+		Frames: make([]string, 0, len(frames)),
+		Scores: make(map[string]FrameQuality, len(frames)),
+	}
+
+	for _, frame := range frames {
+		img, err := decodeFrame(frame)
+		if err != nil {
+			return nil, err
+		}
+
+		quality := a.Analyze(img)
+		reason := a.Evaluate(quality)
+		if reason == "" {
+			result.Frames = append(result.Frames, frame)
+			result.Scores[frame] = quality
+			continue
+		}
+
+		if err := os.Remove(frame); err != nil {
+			return nil, fmt.Errorf("erro ao remover frame reprovado %s: %s", frame, err.Error())
+		}
+		result.Rejected = append(result.Rejected, RejectedFrame{
+			File:    frame,
+			Reason:  reason,
+			Quality: quality,
+		})
+	}
+
+	return result, nil
+}
+
+// laplacianVariance aplica o kernel Laplaciano 3x3 e retorna a variância da
+// resposta; valores baixos indicam pouca informação de borda (frame borrado)
+func laplacianVariance(gray []float64, width, height int) float64 {
+	if width < 3 || height < 3 {
+		return 0
+	}
+
+	var sum, sumSq float64
+	var count int
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			v := gray[i-width] + gray[i+width] + gray[i-1] + gray[i+1] - 4*gray[i]
+			sum += v
+			sumSq += v * v
+			count++
+		}
+	}
+
+	mean := sum / float64(count)
+	return sumSq/float64(count) - mean*mean
+}
@@ -0,0 +1,119 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func solidImage(width, height int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func defaultThresholds() QualityThresholds {
+	return QualityThresholds{
+		MinLuminance: DefaultMinLuminance,
+		MinVariance:  DefaultMinVariance,
+		MinSharpness: DefaultMinSharpness,
+	}
+}
+
+func TestAnalyzeBlackFrame(t *testing.T) {
+	analyzer := NewFrameQualityAnalyzer(defaultThresholds())
+
+	quality := analyzer.Analyze(solidImage(64, 48, color.Black))
+	if quality.MeanLuminance != 0 || quality.Variance != 0 {
+		t.Errorf("esperado luminância e variância zero, mas obteve %+v", quality)
+	}
+	if reason := analyzer.Evaluate(quality); reason != RejectReasonBlack {
+		t.Errorf("esperado motivo '%s', mas obteve '%s'", RejectReasonBlack, reason)
+	}
+}
+
+func TestAnalyzeBlankFrame(t *testing.T) {
+	analyzer := NewFrameQualityAnalyzer(defaultThresholds())
+
+	quality := analyzer.Analyze(solidImage(64, 48, color.RGBA{128, 128, 128, 255}))
+	if reason := analyzer.Evaluate(quality); reason != RejectReasonBlank {
+		t.Errorf("esperado motivo '%s', mas obteve '%s'", RejectReasonBlank, reason)
+	}
+}
+
+func TestAnalyzeSharpFrame(t *testing.T) {
+	analyzer := NewFrameQualityAnalyzer(QualityThresholds{MinSharpness: 100})
+
+	sharp := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			sharp.Set(x, y, verticalStripes(x, y))
+		}
+	}
+	quality := analyzer.Analyze(sharp)
+	if reason := analyzer.Evaluate(quality); reason != "" {
+		t.Errorf("esperado frame nítido aprovado, mas obteve motivo '%s' (%+v)", reason, quality)
+	}
+
+	smooth := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			smooth.Set(x, y, horizontalGradient(x, y))
+		}
+	}
+	if reason := analyzer.Evaluate(analyzer.Analyze(smooth)); reason != RejectReasonBlurry {
+		t.Errorf("esperado motivo '%s' para gradiente suave, mas obteve '%s'", RejectReasonBlurry, reason)
+	}
+}
+
+func TestEvaluateDisabledThresholds(t *testing.T) {
+	analyzer := NewFrameQualityAnalyzer(QualityThresholds{})
+	if reason := analyzer.Evaluate(FrameQuality{}); reason != "" {
+		t.Errorf("esperado frame aprovado com limites desativados, mas obteve '%s'", reason)
+	}
+}
+
+func TestFrameQualityFilter(t *testing.T) {
+	outputDir := "temp_quality"
+	os.MkdirAll(outputDir, 0755)
+	defer os.RemoveAll(outputDir)
+
+	black := filepath.Join(outputDir, "frame_0001.png")
+	good := filepath.Join(outputDir, "frame_0002.png")
+	writeTestFrame(t, black, 64, 48, func(x, y int) color.Color { return color.Black })
+	writeTestFrame(t, good, 64, 48, verticalStripes)
+
+	result, err := NewFrameQualityAnalyzer(defaultThresholds()).Filter([]string{black, good})
+	if err != nil {
+		t.Fatalf("erro inesperado ao filtrar frames: %v", err)
+	}
+
+	if len(result.Frames) != 1 || result.Frames[0] != good {
+		t.Errorf("frames mantidos inesperados: %v", result.Frames)
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].Reason != RejectReasonBlack {
+		t.Errorf("frames rejeitados inesperados: %+v", result.Rejected)
+	}
+	if _, ok := result.Scores[good]; !ok {
+		t.Errorf("esperado métricas registradas para o frame mantido")
+	}
+	if _, err := os.Stat(black); !os.IsNotExist(err) {
+		t.Errorf("esperado que o frame preto fosse removido do disco")
+	}
+}
+
+func TestFrameQualityFilterInvalidFrame(t *testing.T) {
+	result, err := NewFrameQualityAnalyzer(defaultThresholds()).Filter([]string{"frame_inexistente.png"})
+	if err == nil {
+		t.Errorf("esperado erro ao analisar frame inexistente")
+	}
+	if result != nil {
+		t.Errorf("esperado resultado nil em caso de erro")
+	}
+}
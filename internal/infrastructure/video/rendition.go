@@ -0,0 +1,187 @@
+package video
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxRenditions limita as saídas por frame de um único ffmpeg
+const maxRenditions = 8
+
+// renditionName restringe os nomes, usados como subpastas do arquivo
+var renditionName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Rendition é uma versão de cada frame com largura máxima Width (altura
+// proporcional). Width 0 mantém a resolução original.
+type Rendition struct {
+	Name  string `json:"name"`
+	Width int    `json:"width,omitempty"`
+}
+
+// RenditionFile é o arquivo de uma rendição de um frame
+type RenditionFile struct {
+	Name string
+	Path string
+}
+
+// ParseRenditions interpreta uma lista separada por vírgulas no formato
+// nome:largura ou nome (original), ex.: "thumb:320,medium:960,original"
+func ParseRenditions(spec string) ([]Rendition, error) {
+	var specs []string
+	for _, item := range strings.Split(spec, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			specs = append(specs, item)
+		}
+	}
+	return ParseRenditionList(specs)
+}
+
+// ParseRenditionList interpreta as rendições informadas individualmente,
+// validando nomes, larguras e repetições
+func ParseRenditionList(specs []string) ([]Rendition, error) {
+	if len(specs) > maxRenditions {
+		return nil, fmt.Errorf("no máximo %d rendições são permitidas", maxRenditions)
+	}
+
+	renditions := make([]Rendition, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		name, width, hasWidth := strings.Cut(strings.TrimSpace(spec), ":")
+		rendition := Rendition{Name: strings.ToLower(strings.TrimSpace(name))}
+		if !renditionName.MatchString(rendition.Name) {
+			return nil, fmt.Errorf("nome de rendição inválido: %q", name)
+		}
+		if seen[rendition.Name] {
+			return nil, fmt.Errorf("rendição repetida: %q", rendition.Name)
+		}
+		if hasWidth {
+			w, err := strconv.Atoi(strings.TrimSpace(width))
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("largura inválida na rendição %q: %q", rendition.Name, width)
+			}
+			rendition.Width = w
+		}
+		seen[rendition.Name] = true
+		renditions = append(renditions, rendition)
+	}
+	return renditions, nil
+}
+
+// filter retorna o filtro do ffmpeg que gera a rendição. Frames menores que a
+// largura pedida não são ampliados.
+func (r Rendition) filter() string {
+	if r.Width <= 0 {
+		return "null"
+	}
+	return fmt.Sprintf("scale=w='min(iw,%d)':h=-1", r.Width)
+}
+
+// primaryRendition retorna o índice da rendição usada como referência do
+// frame (análise de qualidade e deduplicação): a original ou a mais larga
+func (o ExtractOptions) primaryRendition() int {
+	primary := 0
+	for i, r := range o.Renditions {
+		if r.Width <= 0 {
+			return i
+		}
+		if r.Width > o.Renditions[primary].Width {
+			primary = i
+		}
+	}
+	return primary
+}
+
+// outputDirs retorna os diretórios de saída: um subdiretório por rendição
+// ou o próprio diretório quando há uma única saída
+func (o ExtractOptions) outputDirs(dir string) []string {
+	if len(o.Renditions) == 0 {
+		return []string{dir}
+	}
+	dirs := make([]string, 0, len(o.Renditions))
+	for _, r := range o.Renditions {
+		dirs = append(dirs, filepath.Join(dir, r.Name))
+	}
+	return dirs
+}
+
+// fileOutputs cria os diretórios de saída e monta os argumentos do ffmpeg
+// que gravam os frames de cada rendição em disco
+func (o ExtractOptions) fileOutputs(dir string) ([][]string, error) {
+	var outputs [][]string
+	for _, outputDir := range o.outputDirs(dir) {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, []string{"-y", filepath.Join(outputDir, framePattern(o.nameDigits()))})
+	}
+	return outputs, nil
+}
+
+// collectFrames lista os frames gravados em dir, em ordem numérica. Cada
+// item contém o arquivo de cada rendição do mesmo frame.
+func collectFrames(dir string, opts ExtractOptions) ([][]string, error) {
+	var perOutput [][]string
+	count := -1
+	for _, outputDir := range opts.outputDirs(dir) {
+		paths, err := filepath.Glob(filepath.Join(outputDir, "*.png"))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar frames: %s", err.Error())
+		}
+		sortFramePaths(paths)
+		perOutput = append(perOutput, paths)
+		if count < 0 || len(paths) < count {
+			count = len(paths)
+		}
+	}
+
+	frames := make([][]string, max(count, 0))
+	for i := range frames {
+		for _, paths := range perOutput {
+			frames[i] = append(frames[i], paths[i])
+		}
+	}
+	return frames, nil
+}
+
+// newFrame monta o frame a partir dos arquivos de cada rendição
+func newFrame(paths []string, timestamp float64, opts ExtractOptions) Frame {
+	if len(opts.Renditions) == 0 {
+		return Frame{Path: paths[0], Timestamp: timestamp}
+	}
+
+	frame := Frame{Path: paths[opts.primaryRendition()], Timestamp: timestamp}
+	for i, r := range opts.Renditions {
+		frame.Renditions = append(frame.Renditions, RenditionFile{Name: r.Name, Path: paths[i]})
+	}
+	return frame
+}
+
+// RenditionEntryName retorna o nome da entrada no arquivo de saída: o frame
+// dentro da subpasta da rendição, ou apenas o nome sem rendições
+func RenditionEntryName(rendition, path string) string {
+	if rendition == "" {
+		return filepath.Base(path)
+	}
+	return rendition + "/" + filepath.Base(path)
+}
+
+// deliverFrame entrega ao handler cada rendição do frame de número index
+// (base 1), nomeadas como as entradas do arquivo de saída
+func (o ExtractOptions) deliverFrame(handle FrameHandler, index int, timestamp float64, data [][]byte) error {
+	name := frameName(index, o.nameDigits())
+	if len(o.Renditions) == 0 {
+		return handle(Frame{Path: name, Timestamp: timestamp}, data[0])
+	}
+
+	for i, r := range o.Renditions {
+		frame := Frame{Path: RenditionEntryName(r.Name, name), Timestamp: timestamp, Rendition: r.Name}
+		if err := handle(frame, data[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
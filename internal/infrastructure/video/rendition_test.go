@@ -0,0 +1,143 @@
+package video
+
+import (
+	"bytes"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRenditions(t *testing.T) {
+	renditions, err := ParseRenditions("thumb:320, Medium:960 ,original")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	expected := []Rendition{{Name: "thumb", Width: 320}, {Name: "medium", Width: 960}, {Name: "original"}}
+	if len(renditions) != len(expected) {
+		t.Fatalf("esperado %d rendições, mas obteve %v", len(expected), renditions)
+	}
+	for i := range expected {
+		if renditions[i] != expected[i] {
+			t.Errorf("rendição %d: esperado %+v, mas obteve %+v", i, expected[i], renditions[i])
+		}
+	}
+}
+
+func TestParseRenditionsInvalid(t *testing.T) {
+	testCases := []string{"thumb:0", "thumb:abc", "../x:320", "thumb:320,thumb:640", "a,b,c,d,e,f,g,h,i"}
+	for _, spec := range testCases {
+		if _, err := ParseRenditions(spec); err == nil {
+			t.Errorf("esperado erro para %q", spec)
+		}
+	}
+}
+
+func TestRenditionFilterAndPrimary(t *testing.T) {
+	if got := (Rendition{Name: "original"}).filter(); got != "null" {
+		t.Errorf("esperado filtro null para a original, mas obteve %s", got)
+	}
+	if got := (Rendition{Name: "thumb", Width: 320}).filter(); got != "scale=w='min(iw,320)':h=-1" {
+		t.Errorf("filtro inesperado: %s", got)
+	}
+
+	opts := ExtractOptions{Renditions: []Rendition{{Name: "thumb", Width: 320}, {Name: "large", Width: 1280}, {Name: "medium", Width: 960}}}
+	if got := opts.primaryRendition(); got != 1 {
+		t.Errorf("esperado a rendição mais larga como principal, mas obteve %d", got)
+	}
+	opts.Renditions = append(opts.Renditions, Rendition{Name: "original"})
+	if got := opts.primaryRendition(); got != 3 {
+		t.Errorf("esperado a original como principal, mas obteve %d", got)
+	}
+}
+
+func TestBuildOutputArgsRenditions(t *testing.T) {
+	opts := ExtractOptions{Renditions: []Rendition{{Name: "thumb", Width: 320}, {Name: "original"}}}
+	outputs := [][]string{{"-y", "out/thumb/frame_%04d.png"}, {"-y", "out/original/frame_%04d.png"}}
+	args := strings.Join((&ffmpegExtractor{}).buildOutputArgs("video.mp4", opts, "", outputs), " ")
+
+	expected := "-filter_complex [0:v]fps=1[base];[base]split=2[s0][s1];[s0]scale=w='min(iw,320)':h=-1[r0];[s1]null[r1] " +
+		"-map [r0] -y out/thumb/frame_%04d.png -map [r1] -y out/original/frame_%04d.png"
+	if !strings.HasSuffix(args, expected) {
+		t.Errorf("esperado grafo com split e uma saída por rendição, mas obteve: %s", args)
+	}
+}
+
+func TestCollectFramesRenditions(t *testing.T) {
+	dir := t.TempDir()
+	opts := ExtractOptions{Renditions: []Rendition{{Name: "thumb", Width: 320}, {Name: "original"}}}
+	for _, name := range []string{"thumb", "original"} {
+		os.MkdirAll(filepath.Join(dir, name), 0755)
+		for _, frame := range []string{"frame_0001.png", "frame_0002.png"} {
+			os.WriteFile(filepath.Join(dir, name, frame), []byte("png"), 0644)
+		}
+	}
+	// Frame incompleto (sem a rendição original) é descartado
+	os.WriteFile(filepath.Join(dir, "thumb", "frame_0003.png"), []byte("png"), 0644)
+
+	groups, err := collectFrames(dir, opts)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("esperado 2 frames completos, mas obteve %d", len(groups))
+	}
+
+	frame := newFrame(groups[1], 1, opts)
+	if frame.Path != filepath.Join(dir, "original", "frame_0002.png") || len(frame.Renditions) != 2 {
+		t.Errorf("frame inesperado: %+v", frame)
+	}
+	if got := RenditionEntryName(frame.Renditions[0].Name, frame.Renditions[0].Path); got != "thumb/frame_0002.png" {
+		t.Errorf("entrada inesperada no arquivo: %s", got)
+	}
+}
+
+func TestReadFramePipes(t *testing.T) {
+	black := encodeTestPNG(t, color.Black)
+	white := encodeTestPNG(t, color.White)
+	readers := []io.Reader{
+		bytes.NewReader(append(append([]byte{}, black...), white...)),
+		bytes.NewReader(append(append([]byte{}, white...), black...)),
+	}
+
+	var groups [][][]byte
+	err := readFramePipes(readers, func(data [][]byte) error {
+		groups = append(groups, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("esperado 2 frames, mas obteve %d", len(groups))
+	}
+	if !bytes.Equal(groups[0][0], black) || !bytes.Equal(groups[0][1], white) || !bytes.Equal(groups[1][1], black) {
+		t.Error("rendições entregues fora de ordem")
+	}
+}
+
+func TestManifestGroupsRenditions(t *testing.T) {
+	manifest := NewManifest()
+	opts := ExtractOptions{Renditions: []Rendition{{Name: "thumb", Width: 320}, {Name: "original"}}}
+	data := encodeTestPNG(t, color.Black)
+	handle := func(frame Frame, data []byte) error { return manifest.AddFrameData(frame, data) }
+
+	for i := 1; i <= 2; i++ {
+		if err := opts.deliverFrame(handle, i, float64(i), [][]byte{data, data}); err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+	}
+
+	if len(manifest.Frames) != 2 || len(manifest.Frames[1].Renditions) != 2 {
+		t.Fatalf("esperado 2 frames com 2 rendições, mas obteve %+v", manifest.Frames)
+	}
+	if manifest.Frames[1].File != "frame_0002.png" || manifest.Frames[1].Renditions[1].File != "original/frame_0002.png" {
+		t.Errorf("frame inesperado: %+v", manifest.Frames[1])
+	}
+	if sums := string(manifest.Checksums(nil)); !strings.Contains(sums, "  thumb/frame_0001.png\n") {
+		t.Errorf("esperado rendições no SHA256SUMS, mas obteve: %s", sums)
+	}
+}
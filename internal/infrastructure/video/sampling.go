@@ -0,0 +1,256 @@
+package video
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Modos de extração registrados no manifesto
+const (
+	ExtractionModeRate       = "rate"       // frames a intervalos fixos (fps)
+	ExtractionModeCount      = "count"      // quantidade fixa de frames distribuídos no vídeo
+	ExtractionModeRange      = "range"      // frames a intervalos fixos apenas nos trechos pedidos
+	ExtractionModeTimestamps = "timestamps" // um frame em cada instante pedido
+)
+
+// TimeRange é um trecho do vídeo, em segundos
+type TimeRange struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Mode retorna o modo de extração selecionado pelas opções. Quando mais de
+// um modo é informado, vale a ordem: instantes, trechos, quantidade, taxa.
+func (o ExtractOptions) Mode() string {
+	switch {
+	case len(o.Timestamps) > 0:
+		return ExtractionModeTimestamps
+	case len(o.Ranges) > 0:
+		return ExtractionModeRange
+	case o.FrameCount > 0:
+		return ExtractionModeCount
+	}
+	return ExtractionModeRate
+}
+
+// ParseTimestamp interpreta instantes no formato HH:MM:SS[.mmm], MM:SS[.mmm]
+// ou em segundos (ex.: "00:10:00", "12:30", "95.5")
+func ParseTimestamp(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	parts := strings.Split(value, ":")
+	if value == "" || len(parts) > 3 {
+		return 0, fmt.Errorf("instante inválido: %q", value)
+	}
+
+	seconds := 0.0
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+			return 0, fmt.Errorf("instante inválido: %q", value)
+		}
+		// Minutos e segundos após o primeiro campo não podem passar de 59
+		if i > 0 && n >= 60 {
+			return 0, fmt.Errorf("instante inválido: %q", value)
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, nil
+}
+
+// rangeSegments valida os trechos pedidos e os converte em segmentos
+// ordenados pelo início. Trechos sobrepostos são rejeitados para não
+// gerar frames repetidos.
+func rangeSegments(ranges []TimeRange) ([]segment, error) {
+	sorted := append([]TimeRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	segments := make([]segment, 0, len(sorted))
+	for i, r := range sorted {
+		if r.Start < 0 || r.End <= r.Start {
+			return nil, fmt.Errorf("trecho inválido: %s a %s", formatFloat(r.Start), formatFloat(r.End))
+		}
+		if i > 0 && r.Start < sorted[i-1].End {
+			return nil, fmt.Errorf("trechos sobrepostos: %s a %s e %s a %s",
+				formatFloat(sorted[i-1].Start), formatFloat(sorted[i-1].End), formatFloat(r.Start), formatFloat(r.End))
+		}
+		segments = append(segments, segment{Start: r.Start, Duration: r.End - r.Start})
+	}
+	return segments, nil
+}
+
+// normalizeTimestamps ordena os instantes pedidos e descarta repetições
+func normalizeTimestamps(timestamps []float64) ([]float64, error) {
+	sorted := append([]float64(nil), timestamps...)
+	sort.Float64s(sorted)
+
+	normalized := make([]float64, 0, len(sorted))
+	for _, timestamp := range sorted {
+		if timestamp < 0 {
+			return nil, fmt.Errorf("instante inválido: %s", formatFloat(timestamp))
+		}
+		if len(normalized) > 0 && normalized[len(normalized)-1] == timestamp {
+			continue
+		}
+		normalized = append(normalized, timestamp)
+	}
+	return normalized, nil
+}
+
+// evenTimestamps divide o intervalo [trimStart, duration-trimEnd] em count
+// partes iguais e retorna o instante central de cada uma, evitando o
+// primeiro e o último frame (geralmente pretos ou de transição)
+func evenTimestamps(duration float64, count int, trimStart, trimEnd float64) ([]float64, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("quantidade de frames inválida: %d", count)
+	}
+	if trimStart < 0 || trimEnd < 0 {
+		return nil, fmt.Errorf("recorte inválido: início %s, fim %s", formatFloat(trimStart), formatFloat(trimEnd))
+	}
+
+	span := duration - trimStart - trimEnd
+	if span <= 0 {
+		return nil, fmt.Errorf("recorte de %s s no início e %s s no fim excede a duração do vídeo (%s s)",
+			formatFloat(trimStart), formatFloat(trimEnd), formatFloat(duration))
+	}
+
+	step := span / float64(count)
+	timestamps := make([]float64, count)
+	for i := range timestamps {
+		timestamps[i] = trimStart + (float64(i)+0.5)*step
+	}
+	return timestamps, nil
+}
+
+// sampleTimestamps calcula os instantes a extrair: os pedidos explicitamente
+// ou, no modo de quantidade fixa, a partir da duração obtida via ffprobe
+func sampleTimestamps(videoPath string, opts ExtractOptions, sandbox SandboxConfig) ([]float64, error) {
+	if len(opts.Timestamps) > 0 {
+		return normalizeTimestamps(opts.Timestamps)
+	}
+
+	info := opts.info
+	if info == nil {
+		probed, err := ProbeVideoWithSandbox(videoPath, sandbox)
+		if err != nil {
+			return nil, err
+		}
+		info = probed
+	}
+	return evenTimestamps(info.Duration, opts.FrameCount, opts.TrimStart, opts.TrimEnd)
+}
+
+// extractRanges extrai frames à taxa padrão apenas nos trechos pedidos,
+// reaproveitando a extração por segmentos (seek rápido em cada trecho)
+func (f *ffmpegExtractor) extractRanges(videoPath, outputDir string, opts ExtractOptions) ([]Frame, error) {
+	segments, err := rangeSegments(opts.Ranges)
+	if err != nil {
+		return nil, err
+	}
+	return f.extractSegments(videoPath, outputDir, opts, segments)
+}
+
+// streamRanges entrega ao handler os frames de cada trecho pedido, em ordem,
+// com numeração contínua entre os trechos
+func (f *ffmpegExtractor) streamRanges(videoPath string, opts ExtractOptions, handle FrameHandler) (int, error) {
+	segments, err := rangeSegments(opts.Ranges)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, seg := range segments {
+		segOpts := opts
+		segOpts.start = seg.Start
+		segOpts.duration = seg.Duration
+
+		count, err := f.streamPipe(videoPath, segOpts, total, handle)
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// extractSampledFrames grava em outputDir um frame por instante calculado
+func (f *ffmpegExtractor) extractSampledFrames(videoPath, outputDir string, opts ExtractOptions) ([]Frame, error) {
+	timestamps, err := sampleTimestamps(videoPath, opts, f.config.Sandbox)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range opts.outputDirs(outputDir) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	var frames []Frame
+	primary := ""
+	if len(opts.Renditions) > 0 {
+		primary = opts.Renditions[opts.primaryRendition()].Name
+	}
+	_, err = f.extractAtTimestamps(videoPath, opts, timestamps, func(frame Frame, data []byte) error {
+		path := filepath.Join(outputDir, filepath.FromSlash(frame.Path))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("erro ao gravar frame: %s", err.Error())
+		}
+
+		// As rendições de um frame chegam em sequência, começando pela primeira
+		if frame.Rendition == "" || frame.Rendition == opts.Renditions[0].Name {
+			frames = append(frames, Frame{Path: path, Timestamp: frame.Timestamp})
+		}
+		current := &frames[len(frames)-1]
+		if frame.Rendition != "" {
+			current.Renditions = append(current.Renditions, RenditionFile{Name: frame.Rendition, Path: path})
+			if frame.Rendition == primary {
+				current.Path = path
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
+
+// streamSampledFrames entrega ao handler um frame por instante calculado
+func (f *ffmpegExtractor) streamSampledFrames(videoPath string, opts ExtractOptions, handle FrameHandler) (int, error) {
+	timestamps, err := sampleTimestamps(videoPath, opts, f.config.Sandbox)
+	if err != nil {
+		return 0, err
+	}
+	return f.extractAtTimestamps(videoPath, opts, timestamps, handle)
+}
+
+// extractAtTimestamps executa o ffmpeg uma vez por instante, com seek preciso
+// antes da entrada e um único frame de saída, e entrega os frames em ordem.
+// Instantes além do fim do vídeo não produzem frame e são ignorados.
+func (f *ffmpegExtractor) extractAtTimestamps(videoPath string, opts ExtractOptions, timestamps []float64, handle FrameHandler) (int, error) {
+	textFile, cleanup, err := prepareWatermarkText(opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	count := 0
+	for _, timestamp := range timestamps {
+		frameOpts := opts
+		frameOpts.start = timestamp
+		frameOpts.singleFrame = true
+
+		err := f.runFramePipes(videoPath, frameOpts, textFile, func(data [][]byte) error {
+			count++
+			return opts.deliverFrame(handle, count, timestamp, data)
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,180 @@
+package video
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEvenTimestamps(t *testing.T) {
+	timestamps, err := evenTimestamps(100, 4, 0, 0)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	expected := []float64{12.5, 37.5, 62.5, 87.5}
+	if len(timestamps) != len(expected) {
+		t.Fatalf("esperado %d instantes, mas obteve %d", len(expected), len(timestamps))
+	}
+	for i := range expected {
+		if timestamps[i] != expected[i] {
+			t.Errorf("instante %d: esperado %v, obtido %v", i, expected[i], timestamps[i])
+		}
+	}
+}
+
+func TestEvenTimestampsWithTrim(t *testing.T) {
+	timestamps, err := evenTimestamps(100, 2, 10, 50)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if timestamps[0] != 20 || timestamps[1] != 40 {
+		t.Errorf("instantes inesperados com recorte: %v", timestamps)
+	}
+}
+
+func TestEvenTimestampsInvalid(t *testing.T) {
+	testCases := []struct {
+		name               string
+		count              int
+		trimStart, trimEnd float64
+	}{
+		{"quantidade zero", 0, 0, 0},
+		{"recorte negativo", 5, -1, 0},
+		{"recorte maior que o vídeo", 5, 60, 40},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := evenTimestamps(100, tc.count, tc.trimStart, tc.trimEnd); err == nil {
+				t.Error("esperado erro para parâmetros inválidos")
+			}
+		})
+	}
+}
+
+func TestBuildExtractArgsSingleFrame(t *testing.T) {
+	opts := ExtractOptions{start: 12.5, singleFrame: true}
+	args := (&ffmpegExtractor{}).buildExtractArgs("video.mp4", opts, "", "-f", "image2pipe", "-")
+
+	expected := []string{"-nostdin", "-accurate_seek", "-ss", "12.5", "-i", "video.mp4", "-frames:v", "1", "-f", "image2pipe", "-"}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("argumentos inesperados: %v", args)
+	}
+}
+
+func TestBuildExtractArgsSingleFrameImageWatermark(t *testing.T) {
+	opts := ExtractOptions{Watermark: validWatermark(), singleFrame: true}
+	args := strings.Join((&ffmpegExtractor{}).buildExtractArgs("video.mp4", opts, "", "-"), " ")
+
+	if strings.Contains(args, "fps=") {
+		t.Errorf("esperado extração sem filtro fps, mas obteve: %s", args)
+	}
+	if !strings.Contains(args, "[wmsrc][0:v]scale2ref") {
+		t.Errorf("esperado marca d'água aplicada direto na entrada, mas obteve: %s", args)
+	}
+}
+
+func TestExtractOptionsMode(t *testing.T) {
+	if mode := (ExtractOptions{}).Mode(); mode != ExtractionModeRate {
+		t.Errorf("esperado modo %q por padrão, mas obteve %q", ExtractionModeRate, mode)
+	}
+	if mode := (ExtractOptions{FrameCount: 50}).Mode(); mode != ExtractionModeCount {
+		t.Errorf("esperado modo %q, mas obteve %q", ExtractionModeCount, mode)
+	}
+}
+
+func TestExtractSampledFramesProbeError(t *testing.T) {
+	extractor := &ffmpegExtractor{config: DefaultExtractorConfig()}
+	if _, err := extractor.ExtractFramesWithOptions("video_inexistente.mp4", t.TempDir(), ExtractOptions{FrameCount: 10}); err == nil {
+		t.Error("esperado erro quando o vídeo não pode ser analisado")
+	}
+}
+
+func TestExtractOptionsModePrecedence(t *testing.T) {
+	opts := ExtractOptions{FrameCount: 10, Ranges: []TimeRange{{Start: 0, End: 5}}}
+	if mode := opts.Mode(); mode != ExtractionModeRange {
+		t.Errorf("esperado modo %q, mas obteve %q", ExtractionModeRange, mode)
+	}
+
+	opts.Timestamps = []float64{1}
+	if mode := opts.Mode(); mode != ExtractionModeTimestamps {
+		t.Errorf("esperado modo %q, mas obteve %q", ExtractionModeTimestamps, mode)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected float64
+	}{
+		{"95.5", 95.5},
+		{"12:30", 750},
+		{"00:10:00", 600},
+		{"01:02:03.250", 3723.25},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseTimestamp(tc.value)
+		if err != nil {
+			t.Errorf("erro inesperado para %q: %v", tc.value, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("%q: esperado %v, obtido %v", tc.value, tc.expected, got)
+		}
+	}
+}
+
+func TestParseTimestampInvalid(t *testing.T) {
+	for _, value := range []string{"", "abc", "-5", "00:75", "1:2:3:4", "NaN"} {
+		if _, err := ParseTimestamp(value); err == nil {
+			t.Errorf("esperado erro para o instante %q", value)
+		}
+	}
+}
+
+func TestRangeSegments(t *testing.T) {
+	segments, err := rangeSegments([]TimeRange{{Start: 600, End: 750}, {Start: 10, End: 20}})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	expected := []segment{{Start: 10, Duration: 10}, {Start: 600, Duration: 150}}
+	if len(segments) != len(expected) {
+		t.Fatalf("esperado %d segmentos, mas obteve %d", len(expected), len(segments))
+	}
+	for i := range expected {
+		if segments[i] != expected[i] {
+			t.Errorf("segmento %d: esperado %+v, obtido %+v", i, expected[i], segments[i])
+		}
+	}
+}
+
+func TestRangeSegmentsInvalid(t *testing.T) {
+	testCases := map[string][]TimeRange{
+		"fim antes do início": {{Start: 20, End: 10}},
+		"início negativo":     {{Start: -1, End: 10}},
+		"trechos sobrepostos": {{Start: 0, End: 30}, {Start: 20, End: 40}},
+	}
+
+	for name, ranges := range testCases {
+		if _, err := rangeSegments(ranges); err == nil {
+			t.Errorf("%s: esperado erro", name)
+		}
+	}
+}
+
+func TestNormalizeTimestamps(t *testing.T) {
+	timestamps, err := normalizeTimestamps([]float64{30, 5, 30, 12.5})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if fmt.Sprint(timestamps) != "[5 12.5 30]" {
+		t.Errorf("instantes inesperados: %v", timestamps)
+	}
+
+	if _, err := normalizeTimestamps([]float64{-1}); err == nil {
+		t.Error("esperado erro para instante negativo")
+	}
+}
@@ -0,0 +1,141 @@
+package video
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Limites de recurso cuja violação é identificada pelo sinal que encerra o
+// ffmpeg. Os limites de memória e de arquivos abertos não geram sinal: o
+// ffmpeg apenas recebe erros das chamadas e falha como em um erro comum.
+const (
+	LimitCPUTime  = "cpu_time"
+	LimitFileSize = "file_size"
+)
+
+// Valores padrão do sandbox do ffmpeg
+const (
+	DefaultSandboxCPUSeconds   = 3600
+	DefaultSandboxMemoryBytes  = 4 << 30
+	DefaultSandboxFileBytes    = 2 << 30
+	DefaultSandboxMaxOpenFiles = 256
+)
+
+// DefaultAllowedInputFormats são os demuxers aceitos para o vídeo de entrada
+var DefaultAllowedInputFormats = []string{"mov", "matroska", "webm", "avi", "flv", "mpegts", "asf"}
+
+// SandboxConfig define as restrições aplicadas ao processo do ffmpeg, que
+// processa uploads não confiáveis. Limites iguais a zero não são aplicados.
+type SandboxConfig struct {
+	Enabled        bool
+	AllowedFormats []string // demuxers aceitos para o vídeo (-format_whitelist)
+	CPUSeconds     uint64   // RLIMIT_CPU
+	MemoryBytes    uint64   // RLIMIT_AS
+	FileSizeBytes  uint64   // RLIMIT_FSIZE (tamanho máximo de cada frame gravado)
+	MaxOpenFiles   uint64   // RLIMIT_NOFILE
+}
+
+// DefaultSandboxConfig retorna o sandbox habilitado com os limites padrão
+func DefaultSandboxConfig() SandboxConfig {
+	return SandboxConfig{
+		Enabled:        true,
+		AllowedFormats: DefaultAllowedInputFormats,
+		CPUSeconds:     DefaultSandboxCPUSeconds,
+		MemoryBytes:    DefaultSandboxMemoryBytes,
+		FileSizeBytes:  DefaultSandboxFileBytes,
+		MaxOpenFiles:   DefaultSandboxMaxOpenFiles,
+	}
+}
+
+// ResourceLimitError indica que o ffmpeg foi interrompido por exceder um
+// limite de recurso do sandbox, diferenciando-o de falhas comuns de extração
+type ResourceLimitError struct {
+	Limit  string // uma das constantes Limit*
+	Output string // saída do ffmpeg
+}
+
+func (e *ResourceLimitError) Error() string {
+	return fmt.Sprintf("ffmpeg excedeu o limite de recurso %s", e.Limit)
+}
+
+// inputArgs retorna as opções de entrada que restringem o acesso do ffmpeg a
+// arquivos locais e, para o vídeo, aos demuxers permitidos. Inputs HLS,
+// concat e afins ficam bloqueados, evitando leitura de arquivos arbitrários
+// e acesso à rede.
+func (c SandboxConfig) inputArgs(videoInput bool) []string {
+	if !c.Enabled {
+		return nil
+	}
+
+	args := []string{"-protocol_whitelist", "file"}
+	if videoInput && len(c.AllowedFormats) > 0 {
+		args = append(args, "-format_whitelist", strings.Join(c.AllowedFormats, ","))
+	}
+	return args
+}
+
+// probeArgs retorna as mesmas restrições de entrada do vídeo para o ffprobe,
+// que também lê o upload não confiável. Sem o sandbox o ffprobe continua
+// limitado a arquivos locais.
+func (c SandboxConfig) probeArgs() []string {
+	if args := c.inputArgs(true); args != nil {
+		return args
+	}
+	return []string{"-protocol_whitelist", "file"}
+}
+
+// runSandboxed executa o comando aplicando os limites de recurso e retorna a
+// saída combinada (stdout e stderr)
+func (c SandboxConfig) runSandboxed(cmd *exec.Cmd) ([]byte, error) {
+	var output strings.Builder
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := c.start(cmd); err != nil {
+		return nil, err
+	}
+	err := cmd.Wait()
+	return []byte(output.String()), c.checkLimits(cmd, output.String(), err)
+}
+
+// output executa o comando com os limites de recurso e retorna apenas o
+// stdout, como exec.Cmd.Output; o stderr vai no ResourceLimitError. Usado
+// pelo ffprobe, cuja saída JSON não pode se misturar aos avisos.
+func (c SandboxConfig) output(cmd *exec.Cmd) ([]byte, error) {
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := c.start(cmd); err != nil {
+		return nil, err
+	}
+	err := cmd.Wait()
+	return []byte(stdout.String()), c.checkLimits(cmd, stderr.String(), err)
+}
+
+// start inicia o processo com os limites de recurso configurados, aplicados
+// antes do exec do comando (ver wrapWithLimits)
+func (c SandboxConfig) start(cmd *exec.Cmd) error {
+	if c.Enabled {
+		if err := wrapWithLimits(cmd, c); err != nil {
+			return fmt.Errorf("erro ao aplicar limites ao ffmpeg: %s", err.Error())
+		}
+	}
+	return cmd.Start()
+}
+
+// checkLimits converte a falha do processo em ResourceLimitError quando ela
+// foi causada por um dos limites do sandbox. A classificação usa apenas o
+// sinal que encerrou o processo (SIGXCPU, SIGXFSZ ou o SIGKILL do limite
+// "hard" de CPU); as mensagens do ffmpeg dependem do locale e se confundem
+// com falhas comuns.
+func (c SandboxConfig) checkLimits(cmd *exec.Cmd, output string, err error) error {
+	if err == nil || !c.Enabled {
+		return err
+	}
+	if limit := signalLimit(cmd.ProcessState, c); limit != "" {
+		return &ResourceLimitError{Limit: limit, Output: output}
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+//go:build linux
+
+package video
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// sandboxLimitsEnv marca o processo iniciado como auxiliar do sandbox e
+// carrega os limites a aplicar: CPU, memória, tamanho de arquivo e descritores
+const sandboxLimitsEnv = "WORKER_SANDBOX_LIMITS"
+
+// wrapWithLimits faz o comando ser iniciado pelo próprio executável do
+// worker, que aplica os limites com setrlimit(2) e então substitui-se pelo
+// comando original (execve) no mesmo processo. Os limites valem desde a
+// primeira instrução do ffmpeg, sem a janela de um prlimit(2) feito depois
+// do início do processo.
+func wrapWithLimits(cmd *exec.Cmd, c SandboxConfig) error {
+	if cmd.Err != nil {
+		// Comando não encontrado: o próprio Start reporta o erro
+		return nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd.Env = append(cmd.Environ(), fmt.Sprintf("%s=%d,%d,%d,%d",
+		sandboxLimitsEnv, c.CPUSeconds, c.MemoryBytes, c.FileSizeBytes, c.MaxOpenFiles))
+	cmd.Args = append([]string{self, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = self
+	return nil
+}
+
+// RunSandboxHelper deve ser a primeira chamada do main de binários que usam
+// o extrator. Quando o processo foi iniciado por wrapWithLimits, aplica os
+// limites e executa o comando original, sem retornar; nos demais casos não
+// faz nada.
+func RunSandboxHelper() {
+	value, ok := os.LookupEnv(sandboxLimitsEnv)
+	if !ok {
+		return
+	}
+	os.Unsetenv(sandboxLimitsEnv)
+
+	var c SandboxConfig
+	_, err := fmt.Sscanf(value, "%d,%d,%d,%d", &c.CPUSeconds, &c.MemoryBytes, &c.FileSizeBytes, &c.MaxOpenFiles)
+	if err == nil && len(os.Args) < 2 {
+		err = fmt.Errorf("comando ausente")
+	}
+	if err == nil {
+		err = setResourceLimits(c)
+	}
+	if err == nil {
+		err = syscall.Exec(os.Args[1], os.Args[1:], os.Environ())
+	}
+	fmt.Fprintf(os.Stderr, "erro no sandbox do ffmpeg: %v\n", err)
+	os.Exit(126)
+}
+
+// setResourceLimits aplica os limites ao processo atual, que os repassa ao
+// comando executado em seguida
+func setResourceLimits(c SandboxConfig) error {
+	limits := []struct {
+		resource int
+		value    uint64
+	}{
+		{syscall.RLIMIT_CPU, c.CPUSeconds},
+		{syscall.RLIMIT_AS, c.MemoryBytes},
+		{syscall.RLIMIT_FSIZE, c.FileSizeBytes},
+		{syscall.RLIMIT_NOFILE, c.MaxOpenFiles},
+	}
+
+	for _, limit := range limits {
+		if limit.value == 0 {
+			continue
+		}
+		var current syscall.Rlimit
+		if err := syscall.Getrlimit(limit.resource, &current); err != nil {
+			return err
+		}
+
+		// Limite "hard" um pouco acima do "soft" para que o processo receba
+		// SIGXCPU antes do SIGKILL. Sem privilégios o "hard" atual não pode
+		// ser ampliado.
+		value := syscall.Rlimit{Cur: limit.value, Max: limit.value}
+		if limit.resource == syscall.RLIMIT_CPU {
+			value.Max = limit.value + 5
+		}
+		value.Max = min(value.Max, current.Max)
+		value.Cur = min(value.Cur, value.Max)
+
+		if err := syscall.Setrlimit(limit.resource, &value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signalLimit identifica limites violados pelo sinal que encerrou o processo
+func signalLimit(state *os.ProcessState, c SandboxConfig) string {
+	if state == nil {
+		return ""
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	switch status.Signal() {
+	case syscall.SIGXCPU:
+		return LimitCPUTime
+	case syscall.SIGXFSZ:
+		return LimitFileSize
+	case syscall.SIGKILL:
+		// O kernel envia SIGKILL ao atingir o limite "hard" de CPU
+		used := state.UserTime() + state.SystemTime()
+		if c.CPUSeconds > 0 && used.Seconds() >= float64(c.CPUSeconds) {
+			return LimitCPUTime
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+//go:build linux
+
+package video
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestRunSandboxedCPULimit(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("shell não disponível")
+	}
+
+	config := SandboxConfig{Enabled: true, CPUSeconds: 1}
+	cmd := exec.Command("sh", "-c", "while :; do :; done")
+
+	_, err := config.runSandboxed(cmd)
+	var limitErr *ResourceLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitCPUTime {
+		t.Errorf("esperado ResourceLimitError de CPU, obtido %v", err)
+	}
+}
+
+func TestRunSandboxedSignals(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("shell não disponível")
+	}
+
+	config := SandboxConfig{Enabled: true, CPUSeconds: 60, FileSizeBytes: 1 << 20}
+	testCases := map[string]string{
+		"kill -XFSZ $$": LimitFileSize,
+		"kill -XCPU $$": LimitCPUTime,
+		// SIGKILL sem o tempo de CPU esgotado não é limite do sandbox
+		"kill -KILL $$": "",
+		"exit 1":        "",
+	}
+	for script, expected := range testCases {
+		_, err := config.runSandboxed(exec.Command("sh", "-c", script))
+		var limitErr *ResourceLimitError
+		if errors.As(err, &limitErr) {
+			if limitErr.Limit != expected {
+				t.Errorf("%q: esperado limite %q, obtido %q", script, expected, limitErr.Limit)
+			}
+		} else if expected != "" || err == nil {
+			t.Errorf("%q: esperado limite %q, obtido %v", script, expected, err)
+		}
+	}
+}
+
+func TestRunSandboxedLimitsBeforeExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("shell não disponível")
+	}
+
+	// O shell lê os próprios limites logo ao iniciar; com um prlimit(2) após o
+	// início do processo ele ainda poderia ver os limites herdados do worker
+	config := SandboxConfig{Enabled: true, CPUSeconds: 7, MaxOpenFiles: 64}
+	output, err := config.runSandboxed(exec.Command("sh", "-c", "ulimit -t; ulimit -n; echo ${WORKER_SANDBOX_LIMITS:-limpo}"))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v (%s)", err, output)
+	}
+	if string(output) != "7\n64\nlimpo\n" {
+		t.Errorf("esperado limites desde o início e sem a variável do auxiliar, mas obteve %q", output)
+	}
+}
+
+func TestRunSandboxedOutputSeparatesStderr(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("shell não disponível")
+	}
+
+	output, err := DefaultSandboxConfig().output(exec.Command("sh", "-c", "echo aviso >&2; echo '{}'"))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if string(output) != "{}\n" {
+		t.Errorf("esperado apenas o stdout, mas obteve %q", output)
+	}
+}
+
+func TestRunSandboxedMissingCommand(t *testing.T) {
+	if _, err := DefaultSandboxConfig().runSandboxed(exec.Command("comando-inexistente-sandbox")); err == nil {
+		t.Error("esperado erro para comando inexistente")
+	}
+}
+
+func TestRunSandboxedSuccess(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo não disponível")
+	}
+
+	config := DefaultSandboxConfig()
+	output, err := config.runSandboxed(exec.Command("echo", "ok"))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if string(output) != "ok\n" {
+		t.Errorf("saída inesperada: %q", output)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package video
+
+import (
+	"os"
+	"os/exec"
+)
+
+// wrapWithLimits não é suportado fora do Linux; apenas as restrições de
+// protocolo e formato do ffmpeg são aplicadas
+func wrapWithLimits(cmd *exec.Cmd, c SandboxConfig) error {
+	return nil
+}
+
+// RunSandboxHelper não faz nada fora do Linux, onde os limites não são aplicados
+func RunSandboxHelper() {}
+
+// signalLimit não identifica sinais de limite fora do Linux
+func signalLimit(state *os.ProcessState, c SandboxConfig) string {
+	return ""
+}
@@ -0,0 +1,81 @@
+package video
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestMain permite que o binário de teste sirva de auxiliar do sandbox,
+// como o main do worker
+func TestMain(m *testing.M) {
+	RunSandboxHelper()
+	os.Exit(m.Run())
+}
+
+func TestSandboxInputArgsDisabled(t *testing.T) {
+	if args := (SandboxConfig{}).inputArgs(true); args != nil {
+		t.Errorf("esperado nenhum argumento com sandbox desativado, mas obteve %v", args)
+	}
+}
+
+func TestSandboxInputArgs(t *testing.T) {
+	config := SandboxConfig{Enabled: true, AllowedFormats: []string{"mov", "matroska"}}
+
+	video := strings.Join(config.inputArgs(true), " ")
+	if video != "-protocol_whitelist file -format_whitelist mov,matroska" {
+		t.Errorf("argumentos inesperados para o vídeo: %s", video)
+	}
+
+	other := strings.Join(config.inputArgs(false), " ")
+	if other != "-protocol_whitelist file" {
+		t.Errorf("argumentos inesperados para entradas auxiliares: %s", other)
+	}
+}
+
+func TestSandboxProbeArgs(t *testing.T) {
+	config := SandboxConfig{Enabled: true, AllowedFormats: []string{"mov", "matroska"}}
+	if args := strings.Join(config.probeArgs(), " "); args != "-protocol_whitelist file -format_whitelist mov,matroska" {
+		t.Errorf("esperado ffprobe com as restrições do vídeo, mas obteve: %s", args)
+	}
+	if args := strings.Join((SandboxConfig{}).probeArgs(), " "); args != "-protocol_whitelist file" {
+		t.Errorf("esperado ffprobe restrito a arquivos locais sem sandbox, mas obteve: %s", args)
+	}
+}
+
+func TestSandboxCheckLimitsIgnoresOutput(t *testing.T) {
+	config := DefaultSandboxConfig()
+	cmd := exec.Command("ffmpeg")
+	original := errors.New("exit status 1")
+
+	// Sem sinal do kernel, mensagens parecidas com as dos limites são falhas
+	// comuns do ffmpeg (e mudam com o locale)
+	outputs := []string{
+		"[h264 @ 0x1] Cannot allocate memory",
+		"av_write_frame: File too large",
+		"Could not open: Too many open files",
+		"Impossible d'allouer de la mémoire",
+	}
+	for _, output := range outputs {
+		if err := config.checkLimits(cmd, output, original); err != original {
+			t.Errorf("%q: esperado erro original, obtido %v", output, err)
+		}
+	}
+	if err := config.checkLimits(cmd, "", nil); err != nil {
+		t.Errorf("esperado nil quando o processo terminou sem erro, obtido %v", err)
+	}
+}
+
+func TestWrapFFmpegErrorPreservesLimitError(t *testing.T) {
+	limitErr := &ResourceLimitError{Limit: LimitCPUTime}
+	if err := wrapFFmpegError(limitErr, []byte("saída")); err != limitErr {
+		t.Errorf("esperado ResourceLimitError preservado, obtido %v", err)
+	}
+
+	wrapped := wrapFFmpegError(errors.New("exit status 1"), []byte("saída do ffmpeg"))
+	if !strings.Contains(wrapped.Error(), "erro no ffmpeg") || !strings.Contains(wrapped.Error(), "saída do ffmpeg") {
+		t.Errorf("erro inesperado: %v", wrapped)
+	}
+}
@@ -0,0 +1,209 @@
+package video
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Valores padrão da extração paralela por segmentos
+const (
+	DefaultParallelMinDuration     = 600.0 // vídeos com 10 minutos ou mais são segmentados
+	DefaultParallelSegmentDuration = 300.0 // duração alvo de cada segmento (segundos)
+)
+
+// ParallelConfig controla a divisão de vídeos longos em segmentos extraídos
+// por várias instâncias do ffmpeg em paralelo
+type ParallelConfig struct {
+	MaxParallel     int     // processos ffmpeg simultâneos; 1 ou menos desativa a segmentação
+	MinDuration     float64 // duração mínima (segundos) para segmentar o vídeo
+	SegmentDuration float64 // duração alvo de cada segmento (segundos)
+}
+
+// enabled indica se a extração paralela deve ser considerada
+func (c ParallelConfig) enabled() bool {
+	return c.MaxParallel > 1 && c.SegmentDuration > 0
+}
+
+// segment representa um intervalo de tempo do vídeo extraído isoladamente
+type segment struct {
+	Start    float64
+	Duration float64
+}
+
+// frameCount retorna quantos instantes da grade de 1/frameRate, a partir do
+// início, ficam antes do fim do segmento
+func (s segment) frameCount(frameRate float64) int {
+	return int(math.Ceil(s.Duration*frameRate - 1e-6))
+}
+
+// planSegments divide [0, duration) em segmentos de aproximadamente
+// segmentDuration segundos. Cada limite é aproximado para o keyframe mais
+// próximo (seek mais barato) e depois alinhado à grade de 1/frameRate para que
+// a numeração e os instantes dos frames sejam idênticos aos de uma extração única.
+func planSegments(duration, segmentDuration, frameRate float64, keyframes []float64) []segment {
+	if duration <= 0 || segmentDuration <= 0 || frameRate <= 0 {
+		return nil
+	}
+
+	count := int(math.Ceil(duration / segmentDuration))
+	if count <= 1 {
+		return []segment{{Start: 0, Duration: duration}}
+	}
+
+	interval := 1 / frameRate
+	boundaries := []float64{0}
+	for i := 1; i < count; i++ {
+		target := nearestKeyframe(float64(i)*duration/float64(count), keyframes)
+		aligned := math.Floor(target/interval) * interval
+		if aligned > boundaries[len(boundaries)-1] && aligned < duration {
+			boundaries = append(boundaries, aligned)
+		}
+	}
+	boundaries = append(boundaries, duration)
+
+	segments := make([]segment, 0, len(boundaries)-1)
+	for i := 0; i < len(boundaries)-1; i++ {
+		segments = append(segments, segment{
+			Start:    boundaries[i],
+			Duration: boundaries[i+1] - boundaries[i],
+		})
+	}
+	return segments
+}
+
+// nearestKeyframe retorna o keyframe mais próximo do instante informado, ou o
+// próprio instante quando não há keyframes conhecidos
+func nearestKeyframe(target float64, keyframes []float64) float64 {
+	if len(keyframes) == 0 {
+		return target
+	}
+
+	i := sort.SearchFloat64s(keyframes, target)
+	best := target
+	bestDistance := math.Inf(1)
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(keyframes) {
+			continue
+		}
+		if distance := math.Abs(keyframes[j] - target); distance < bestDistance {
+			best, bestDistance = keyframes[j], distance
+		}
+	}
+	return best
+}
+
+// planParallelExtraction decide se o vídeo deve ser segmentado, alinhando os
+// limites à grade de frameRate. info são os metadados obtidos por Plan; sem
+// eles (probe com falha) ou quando a extração deve ser feita em uma única
+// invocação do ffmpeg, retorna nil.
+func (f *ffmpegExtractor) planParallelExtraction(videoPath string, info *VideoInfo, frameRate float64) []segment {
+	if !f.config.Parallel.enabled() || info == nil || info.Duration < f.config.Parallel.MinDuration {
+		return nil
+	}
+
+	// Sem keyframes os limites continuam válidos, apenas com seek mais custoso
+	keyframes, _ := ProbeKeyframesWithSandbox(videoPath, f.config.Sandbox)
+
+	segments := planSegments(info.Duration, f.config.Parallel.SegmentDuration, frameRate, keyframes)
+	if len(segments) <= 1 {
+		return nil
+	}
+	return segments
+}
+
+// extractSegments extrai cada segmento em um subdiretório com até MaxParallel
+// processos simultâneos e depois renomeia os frames para outputDir com
+// numeração global contínua
+func (f *ffmpegExtractor) extractSegments(videoPath, outputDir string, opts ExtractOptions, segments []segment) ([]Frame, error) {
+	textFile, cleanup, err := prepareWatermarkText(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	segmentFrames := make([][][]string, len(segments))
+	errs := make([]error, len(segments))
+
+	// Intervalos pedidos pelo job também passam por aqui, mesmo com a
+	// extração paralela desativada
+	sem := make(chan struct{}, max(f.config.Parallel.MaxParallel, 1))
+	var wg sync.WaitGroup
+	for i, seg := range segments {
+		wg.Add(1)
+		go func(i int, seg segment) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			segmentDir := filepath.Join(outputDir, fmt.Sprintf("segment_%03d", i))
+			outputs, err := opts.fileOutputs(segmentDir)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			segOpts := opts
+			segOpts.start = seg.Start
+			segOpts.duration = seg.Duration
+
+			cmd := exec.Command("ffmpeg", f.buildOutputArgs(videoPath, segOpts, textFile, outputs)...)
+			if output, err := f.config.Sandbox.runSandboxed(cmd); err != nil {
+				errs[i] = wrapFFmpegError(err, output)
+				return
+			}
+
+			segmentFrames[i], errs[i] = collectFrames(segmentDir, opts)
+		}(i, seg)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Mesclar na ordem dos segmentos com numeração global. Como os limites
+	// estão alinhados à grade de frames, o instante de cada frame é o início
+	// do segmento somado à sua posição local. O arredondamento do fps no fim
+	// do -t pode gerar um frame no próprio limite, que repetiria o primeiro
+	// frame do segmento seguinte; frames fora de [início, fim) são descartados.
+	total := 0
+	for i, segFrames := range segmentFrames {
+		segmentFrames[i] = segFrames[:min(len(segFrames), segments[i].frameCount(opts.FrameRate()))]
+		total += len(segmentFrames[i])
+	}
+	if err := f.checkFrameLimit(total); err != nil {
+		return nil, err
+	}
+
+	targetDirs := opts.outputDirs(outputDir)
+	for _, dir := range targetDirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	var frames []Frame
+	for i, segFrames := range segmentFrames {
+		for j, paths := range segFrames {
+			name := frameName(len(frames)+1, opts.nameDigits())
+			targets := make([]string, len(paths))
+			for k, path := range paths {
+				targets[k] = filepath.Join(targetDirs[k], name)
+				if err := os.Rename(path, targets[k]); err != nil {
+					return nil, fmt.Errorf("erro ao mesclar frames: %s", err.Error())
+				}
+			}
+			frames = append(frames, newFrame(targets, frameTimestamp(j, segments[i].Start, opts.FrameRate()), opts))
+		}
+		os.RemoveAll(filepath.Join(outputDir, fmt.Sprintf("segment_%03d", i)))
+	}
+
+	return frames, nil
+}
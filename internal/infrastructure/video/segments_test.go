@@ -0,0 +1,213 @@
+package video
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPlanSegmentsShortVideo(t *testing.T) {
+	segments := planSegments(120, 300, 1, nil)
+	if len(segments) != 1 || segments[0].Start != 0 || segments[0].Duration != 120 {
+		t.Errorf("esperado segmento único cobrindo o vídeo, mas obteve %+v", segments)
+	}
+}
+
+func TestPlanSegmentsCoversWholeVideo(t *testing.T) {
+	duration := 7200.5
+	segments := planSegments(duration, 300, 1, nil)
+
+	if len(segments) != 25 {
+		t.Fatalf("esperado 25 segmentos, mas obteve %d", len(segments))
+	}
+
+	end := 0.0
+	for i, seg := range segments {
+		if seg.Start != end {
+			t.Errorf("segmento %d começa em %v, esperado %v (sem lacunas)", i, seg.Start, end)
+		}
+		if seg.Start != math.Floor(seg.Start) {
+			t.Errorf("segmento %d não está alinhado à grade de frames: %v", i, seg.Start)
+		}
+		end = seg.Start + seg.Duration
+	}
+	if math.Abs(end-duration) > 1e-9 {
+		t.Errorf("segmentos terminam em %v, esperado %v", end, duration)
+	}
+}
+
+func TestPlanSegmentsSnapsToKeyframes(t *testing.T) {
+	keyframes := []float64{0, 98.4, 203.7, 297.1}
+	segments := planSegments(400, 100, 1, keyframes)
+
+	expectedStarts := []float64{0, 98, 203, 297}
+	if len(segments) != len(expectedStarts) {
+		t.Fatalf("esperado %d segmentos, mas obteve %+v", len(expectedStarts), segments)
+	}
+	for i, start := range expectedStarts {
+		if segments[i].Start != start {
+			t.Errorf("segmento %d: esperado início %v, obtido %v", i, start, segments[i].Start)
+		}
+	}
+}
+
+func TestPlanSegmentsInvalidInput(t *testing.T) {
+	if planSegments(0, 300, 1, nil) != nil {
+		t.Errorf("esperado nil para duração zero")
+	}
+	if planSegments(100, 0, 1, nil) != nil {
+		t.Errorf("esperado nil para duração de segmento zero")
+	}
+}
+
+func TestNearestKeyframe(t *testing.T) {
+	keyframes := []float64{0, 10, 20}
+	testCases := map[float64]float64{
+		4:   0,
+		6:   10,
+		19:  20,
+		100: 20,
+	}
+
+	for target, expected := range testCases {
+		if got := nearestKeyframe(target, keyframes); got != expected {
+			t.Errorf("nearestKeyframe(%v) = %v, esperado %v", target, got, expected)
+		}
+	}
+
+	if got := nearestKeyframe(7.5, nil); got != 7.5 {
+		t.Errorf("esperado instante original sem keyframes, obtido %v", got)
+	}
+}
+
+func TestPlanParallelExtractionDisabled(t *testing.T) {
+	extractor := &ffmpegExtractor{config: ExtractorConfig{Parallel: ParallelConfig{MaxParallel: 1}}}
+	info := &VideoInfo{Duration: 3600}
+	if segments := extractor.planParallelExtraction("fake_video.mp4", info, defaultFrameRate); segments != nil {
+		t.Errorf("esperado extração única com paralelismo desativado, mas obteve %+v", segments)
+	}
+}
+
+func TestPlanParallelExtractionWithoutInfo(t *testing.T) {
+	extractor := &ffmpegExtractor{config: ExtractorConfig{Parallel: ParallelConfig{
+		MaxParallel:     4,
+		SegmentDuration: DefaultParallelSegmentDuration,
+	}}}
+	if segments := extractor.planParallelExtraction("fake_video.mp4", nil, defaultFrameRate); segments != nil {
+		t.Errorf("esperado fallback para extração única quando o probe falha")
+	}
+}
+
+func TestPlanParallelExtractionUsesPlannedInfo(t *testing.T) {
+	extractor := &ffmpegExtractor{config: ExtractorConfig{Parallel: ParallelConfig{
+		MaxParallel:     4,
+		MinDuration:     DefaultParallelMinDuration,
+		SegmentDuration: DefaultParallelSegmentDuration,
+	}}}
+	// O arquivo não existe: a duração vem dos metadados de Plan, sem novo probe
+	segments := extractor.planParallelExtraction("fake_video.mp4", &VideoInfo{Duration: 1200}, defaultFrameRate)
+	if len(segments) != 4 {
+		t.Errorf("esperado 4 segmentos a partir da duração informada, mas obteve %+v", segments)
+	}
+	if segments := extractor.planParallelExtraction("fake_video.mp4", &VideoInfo{Duration: 300}, defaultFrameRate); segments != nil {
+		t.Errorf("esperado extração única abaixo da duração mínima, mas obteve %+v", segments)
+	}
+}
+
+func TestBuildExtractArgsWithSegment(t *testing.T) {
+	opts := ExtractOptions{start: 300, duration: 299.5}
+	args := strings.Join((&ffmpegExtractor{}).buildExtractArgs("video.mp4", opts, "", "-y", "frame_%04d.png"), " ")
+
+	if !strings.HasPrefix(args, "-nostdin -ss 300 -t 299.5 -i video.mp4") {
+		t.Errorf("esperado seek de entrada antes do -i, mas obteve: %s", args)
+	}
+}
+
+// fakeFFmpegScript simula o ffmpeg com -ss/-t antes da entrada e o filtro
+// fps: os instantes de saída recomeçam em zero no início do trecho e há um
+// frame a cada 1/taxa enquanto o instante for menor que a duração. Com
+// FAKE_FFMPEG_BOUNDARY=1, como o arredondamento do fps do ffmpeg real pode
+// fazer, também sai o frame no próprio fim do trecho. Cada arquivo recebe o
+// instante absoluto do frame.
+const fakeFFmpegScript = `#!/bin/sh
+start=0; duration=0; rate=1
+while [ $# -gt 1 ]; do
+	case "$1" in
+	-ss) start=$2; shift ;;
+	-t) duration=$2; shift ;;
+	-vf) rate=${2#fps=}; shift ;;
+	esac
+	shift
+done
+pattern=$1
+mkdir -p "$(dirname "$pattern")"
+awk -v s="$start" -v d="$duration" -v r="$rate" -v p="$pattern" 'BEGIN {
+	limit = ENVIRON["FAKE_FFMPEG_BOUNDARY"] == "1" ? d + 1e-9 : d - 1e-9
+	for (n = 0; n / r < limit; n++) {
+		file = sprintf(p, n + 1)
+		printf "%.6f", s + n / r > file
+		close(file)
+	}
+}'
+`
+
+func TestExtractSegmentsBoundaries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ffmpeg simulado exige sh")
+	}
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte(fakeFFmpegScript), 0755); err != nil {
+		t.Fatalf("erro ao criar ffmpeg simulado: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// Keyframes fora da grade de frames forçam o alinhamento dos limites
+	duration := 95.5
+	segments := planSegments(duration, 30, 2, []float64{0, 29.7, 61.3, 88.1})
+	if len(segments) < 3 {
+		t.Fatalf("esperado vários segmentos, mas obteve %+v", segments)
+	}
+
+	for _, boundary := range []string{"0", "1"} {
+		t.Setenv("FAKE_FFMPEG_BOUNDARY", boundary)
+		checkSegmentFrames(t, segments, duration)
+	}
+}
+
+// checkSegmentFrames extrai os segmentos com o ffmpeg simulado e confere que
+// numeração e instantes são os de uma extração única a 2 frames/s
+func checkSegmentFrames(t *testing.T, segments []segment, duration float64) {
+	t.Helper()
+
+	extractor := &ffmpegExtractor{config: ExtractorConfig{Parallel: ParallelConfig{MaxParallel: 4}}}
+	outputDir := t.TempDir()
+	frames, err := extractor.extractSegments("video.mp4", outputDir, ExtractOptions{frameRate: 2}, segments)
+	if err != nil {
+		t.Fatalf("erro inesperado na extração por segmentos: %v", err)
+	}
+
+	// Uma extração única geraria um frame a cada 0,5s de 0 a 95, sem repetir
+	// o frame do limite entre segmentos
+	if expected := int(math.Ceil(duration * 2)); len(frames) != expected {
+		t.Fatalf("boundary=%s: esperado %d frames, mas obteve %d", os.Getenv("FAKE_FFMPEG_BOUNDARY"), expected, len(frames))
+	}
+	for i, frame := range frames {
+		expected := float64(i) / 2
+		data, err := os.ReadFile(frame.Path)
+		if err != nil {
+			t.Fatalf("erro ao ler frame %d: %v", i+1, err)
+		}
+		produced, _ := strconv.ParseFloat(string(data), 64)
+		if math.Abs(produced-expected) > 1e-6 || math.Abs(frame.Timestamp-expected) > 1e-6 {
+			t.Errorf("frame %d: esperado instante %v, ffmpeg produziu %v e o manifesto registrou %v",
+				i+1, expected, produced, frame.Timestamp)
+		}
+		if name := filepath.Base(frame.Path); name != frameName(i+1, minFrameNameDigits) {
+			t.Errorf("frame %d com numeração inesperada: %s", i+1, name)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+// pngSignature é a assinatura de 8 bytes que inicia todo arquivo PNG
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// maxPNGChunkSize limita o tamanho de um chunk para evitar alocações absurdas
+// caso o fluxo do ffmpeg esteja corrompido
+const maxPNGChunkSize = 256 << 20
+
+// FrameHandler recebe cada frame (nome da entrada em Path e PNG codificado)
+// assim que ele é produzido
+type FrameHandler func(frame Frame, data []byte) error
+
+// StreamFrames executa o ffmpeg enviando os frames em PNG pelo stdout
+// (image2pipe) e entrega cada imagem ao handler sem gravá-la em disco.
+// Retorna a quantidade de frames entregues. A extração paralela por segmentos
+// não é usada aqui: entregar os frames em ordem exigiria manter em memória os
+// segmentos seguintes enquanto o primeiro é consumido.
+func (f *ffmpegExtractor) StreamFrames(videoPath string, opts ExtractOptions, handle FrameHandler) (int, error) {
+	opts, err := f.Plan(videoPath, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	switch opts.Mode() {
+	case ExtractionModeTimestamps, ExtractionModeCount:
+		return f.streamSampledFrames(videoPath, opts, handle)
+	case ExtractionModeRange:
+		return f.streamRanges(videoPath, opts, handle)
+	}
+	return f.streamPipe(videoPath, opts, 0, handle)
+}
+
+// streamPipe executa uma única instância do ffmpeg com saída image2pipe.
+// offset é a quantidade de frames já entregues, usada na numeração.
+func (f *ffmpegExtractor) streamPipe(videoPath string, opts ExtractOptions, offset int, handle FrameHandler) (int, error) {
+	textFile, cleanup, err := prepareWatermarkText(opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	count := 0
+	err = f.runFramePipes(videoPath, opts, textFile, func(data [][]byte) error {
+		if err := f.checkFrameLimit(offset + count + 1); err != nil {
+			return err
+		}
+		timestamp := frameTimestamp(count, opts.start, opts.FrameRate())
+		count++
+		return opts.deliverFrame(handle, offset+count, timestamp, data)
+	})
+	return count, err
+}
+
+// runFramePipes executa o ffmpeg com uma saída image2pipe por rendição (o
+// stdout, sem rendições, ou os descritores 3 em diante) e entrega a handle
+// os PNGs de cada frame, com todas as rendições, na ordem de produção
+func (f *ffmpegExtractor) runFramePipes(videoPath string, opts ExtractOptions, textFile string, handle func(data [][]byte) error) error {
+	var outputs [][]string
+	var readers []io.Reader
+	var writers []*os.File
+	closeAll := func() {
+		for _, w := range writers {
+			w.Close()
+		}
+	}
+
+	cmd := exec.Command("ffmpeg")
+	if len(opts.Renditions) == 0 {
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return fmt.Errorf("erro ao preparar saída do ffmpeg: %s", err.Error())
+		}
+		readers = append(readers, stdout)
+		outputs = append(outputs, []string{"-f", "image2pipe", "-c:v", "png", "-"})
+	} else {
+		for i := range opts.Renditions {
+			r, w, err := os.Pipe()
+			if err != nil {
+				closeAll()
+				return fmt.Errorf("erro ao preparar saída do ffmpeg: %s", err.Error())
+			}
+			defer r.Close()
+			readers = append(readers, r)
+			writers = append(writers, w)
+			outputs = append(outputs, []string{"-f", "image2pipe", "-c:v", "png", "pipe:" + strconv.Itoa(3+i)})
+		}
+		cmd.ExtraFiles = writers
+	}
+	cmd.Args = append(cmd.Args, f.buildOutputArgs(videoPath, opts, textFile, outputs)...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := f.config.Sandbox.start(cmd)
+	// As pontas de escrita pertencem ao ffmpeg a partir daqui
+	closeAll()
+	if err != nil {
+		return fmt.Errorf("erro no ffmpeg: %s", err.Error())
+	}
+
+	readErr := readFramePipes(readers, handle)
+	if readErr != nil {
+		cmd.Process.Kill()
+	}
+	// Drena o que restou nos pipes para que o ffmpeg termine e Wait não
+	// fique bloqueado (inclusive quando uma saída acaba antes das outras)
+	drainPipes(readers)
+
+	waitErr := cmd.Wait()
+	waitErr = f.config.Sandbox.checkLimits(cmd, stderr.String(), waitErr)
+	if readErr != nil {
+		return readErr
+	}
+	if waitErr != nil {
+		return wrapFFmpegError(waitErr, stderr.Bytes())
+	}
+	return nil
+}
+
+// readFramePipes lê todas as saídas em paralelo, pois o ffmpeg bloqueia
+// quando qualquer pipe enche, e agrupa o n-ésimo PNG de cada uma em um frame
+func readFramePipes(readers []io.Reader, handle func(data [][]byte) error) error {
+	done := make(chan struct{})
+	channels := make([]chan []byte, len(readers))
+	errs := make([]error, len(readers))
+
+	var wg sync.WaitGroup
+	for i, reader := range readers {
+		channels[i] = make(chan []byte, 2)
+		wg.Add(1)
+		go func(i int, reader io.Reader) {
+			defer wg.Done()
+			defer close(channels[i])
+			errs[i] = splitPNGStream(reader, func(data []byte) error {
+				select {
+				case channels[i] <- data:
+					return nil
+				case <-done:
+					return errPipeStopped
+				}
+			})
+		}(i, reader)
+	}
+
+	var handleErr error
+	for handleErr == nil {
+		data := make([][]byte, len(channels))
+		complete := true
+		for i, ch := range channels {
+			frame, ok := <-ch
+			if !ok {
+				complete = false
+				break
+			}
+			data[i] = frame
+		}
+		if !complete {
+			break
+		}
+		handleErr = handle(data)
+	}
+	close(done)
+	wg.Wait()
+
+	if handleErr != nil {
+		return handleErr
+	}
+	for _, err := range errs {
+		if err != nil && err != errPipeStopped {
+			return err
+		}
+	}
+	return nil
+}
+
+// drainPipes descarta o conteúdo restante de todos os pipes em paralelo
+func drainPipes(readers []io.Reader) {
+	var wg sync.WaitGroup
+	for _, reader := range readers {
+		wg.Add(1)
+		go func(reader io.Reader) {
+			defer wg.Done()
+			io.Copy(io.Discard, reader)
+		}(reader)
+	}
+	wg.Wait()
+}
+
+// errPipeStopped interrompe a leitura de um pipe quando as demais terminaram
+var errPipeStopped = errors.New("leitura de frames interrompida")
+
+// splitPNGStream separa um fluxo de PNGs concatenados (saída do image2pipe)
+// em imagens individuais, percorrendo os chunks de cada arquivo até o IEND
+func splitPNGStream(r io.Reader, handle func(data []byte) error) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	for {
+		signature := make([]byte, len(pngSignature))
+		if _, err := io.ReadFull(reader, signature); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("fluxo de frames truncado: %s", err.Error())
+		}
+		if !bytes.Equal(signature, pngSignature) {
+			return errors.New("fluxo de frames inválido: assinatura PNG não encontrada")
+		}
+
+		var frame bytes.Buffer
+		frame.Write(signature)
+
+		for {
+			header := make([]byte, 8)
+			if _, err := io.ReadFull(reader, header); err != nil {
+				return fmt.Errorf("fluxo de frames truncado: %s", err.Error())
+			}
+
+			length := binary.BigEndian.Uint32(header[:4])
+			if length > maxPNGChunkSize {
+				return fmt.Errorf("fluxo de frames inválido: chunk de %d bytes", length)
+			}
+
+			frame.Write(header)
+			// Dados do chunk + CRC de 4 bytes
+			if _, err := io.CopyN(&frame, reader, int64(length)+4); err != nil {
+				return fmt.Errorf("fluxo de frames truncado: %s", err.Error())
+			}
+
+			if string(header[4:8]) == "IEND" {
+				break
+			}
+		}
+
+		if err := handle(frame.Bytes()); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package video
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodeTestPNG(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("erro ao codificar PNG de teste: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSplitPNGStream(t *testing.T) {
+	first := encodeTestPNG(t, color.Black)
+	second := encodeTestPNG(t, color.White)
+	stream := append(append([]byte{}, first...), second...)
+
+	var frames [][]byte
+	err := splitPNGStream(bytes.NewReader(stream), func(data []byte) error {
+		frames = append(frames, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado ao separar fluxo: %v", err)
+	}
+
+	if len(frames) != 2 {
+		t.Fatalf("esperado 2 frames, mas obteve %d", len(frames))
+	}
+	if !bytes.Equal(frames[0], first) || !bytes.Equal(frames[1], second) {
+		t.Errorf("frames separados não conferem com os PNGs originais")
+	}
+	if _, err := png.Decode(bytes.NewReader(frames[1])); err != nil {
+		t.Errorf("frame separado não é um PNG válido: %v", err)
+	}
+}
+
+func TestSplitPNGStreamEmpty(t *testing.T) {
+	called := false
+	err := splitPNGStream(bytes.NewReader(nil), func(data []byte) error {
+		called = true
+		return nil
+	})
+	if err != nil || called {
+		t.Errorf("esperado fluxo vazio sem erro e sem frames, obteve err=%v called=%v", err, called)
+	}
+}
+
+func TestSplitPNGStreamTruncated(t *testing.T) {
+	data := encodeTestPNG(t, color.Black)
+	err := splitPNGStream(bytes.NewReader(data[:len(data)-6]), func(data []byte) error { return nil })
+	if err == nil {
+		t.Errorf("esperado erro para fluxo truncado")
+	}
+}
+
+func TestSplitPNGStreamInvalidSignature(t *testing.T) {
+	err := splitPNGStream(bytes.NewReader([]byte("not a png stream")), func(data []byte) error { return nil })
+	if err == nil {
+		t.Errorf("esperado erro para assinatura inválida")
+	}
+}
+
+func TestSplitPNGStreamHandlerError(t *testing.T) {
+	expected := errors.New("falha no handler")
+	err := splitPNGStream(bytes.NewReader(encodeTestPNG(t, color.Black)), func(data []byte) error { return expected })
+	if !errors.Is(err, expected) {
+		t.Errorf("esperado erro do handler, mas obteve: %v", err)
+	}
+}
+
+func TestStreamFramesFFmpegError(t *testing.T) {
+	extractor := NewFFmpegExtractor()
+	count, err := extractor.StreamFrames("fake_video.mp4", ExtractOptions{}, func(frame Frame, data []byte) error {
+		return nil
+	})
+	if err == nil {
+		t.Errorf("esperado erro do ffmpeg, mas não ocorreu")
+	}
+	if count != 0 {
+		t.Errorf("esperado 0 frames, mas obteve %d", count)
+	}
+}
@@ -0,0 +1,250 @@
+package video
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Contêineres reconhecidos pela inspeção dos bytes iniciais do arquivo
+const (
+	ContainerMP4      = "mp4"
+	ContainerMatroska = "matroska"
+	ContainerAVI      = "avi"
+	ContainerFLV      = "flv"
+	ContainerMPEGTS   = "mpegts"
+	ContainerASF      = "asf"
+)
+
+// Códigos de rejeição retornados em ValidationError
+const (
+	RejectTooLarge          = "TOO_LARGE"
+	RejectUnknownContainer  = "UNKNOWN_CONTAINER"
+	RejectUnreadable        = "UNREADABLE"
+	RejectCodecNotAllowed   = "CODEC_NOT_ALLOWED"
+	RejectDurationTooLong   = "DURATION_TOO_LONG"
+	RejectResolutionTooHigh = "RESOLUTION_TOO_HIGH"
+)
+
+// DefaultAllowedCodecs são os codecs de vídeo aceitos por padrão
+var DefaultAllowedCodecs = []string{"h264", "hevc", "vp8", "vp9", "av1", "mpeg4", "mpeg2video", "mjpeg", "prores", "wmv3", "vc1", "flv1"}
+
+// mpegTSPacketSize é o tamanho fixo de um pacote MPEG-TS
+const mpegTSPacketSize = 188
+
+// sniffLength é a quantidade de bytes lida para identificar o contêiner
+const sniffLength = 3 * mpegTSPacketSize
+
+// ValidationError indica que o vídeo foi rejeitado antes do processamento.
+// Reason é uma mensagem apresentável ao usuário; Err guarda a causa, quando
+// houver, para o log.
+type ValidationError struct {
+	Code   string
+	Reason string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// InputLimits define os limites aceitos para um vídeo de entrada. Valores
+// zero (ou lista vazia) desativam a verificação correspondente.
+type InputLimits struct {
+	MaxSizeBytes  int64
+	MaxDuration   float64 // segundos
+	MaxWidth      int
+	MaxHeight     int
+	AllowedCodecs []string
+}
+
+// InputValidator valida vídeos de entrada antes da extração de frames
+type InputValidator struct {
+	limits  InputLimits
+	sandbox SandboxConfig
+}
+
+// NewInputValidator cria um validador com os limites informados e o ffprobe
+// restrito pelo sandbox padrão
+func NewInputValidator(limits InputLimits) *InputValidator {
+	return NewInputValidatorWithSandbox(limits, DefaultSandboxConfig())
+}
+
+// NewInputValidatorWithSandbox cria um validador cujo ffprobe segue o
+// sandbox informado, o mesmo do ffmpeg
+func NewInputValidatorWithSandbox(limits InputLimits, sandbox SandboxConfig) *InputValidator {
+	return &InputValidator{limits: limits, sandbox: sandbox}
+}
+
+// ValidateSize verifica o tamanho do objeto, permitindo rejeitar o vídeo antes do download
+func (v *InputValidator) ValidateSize(size int64) error {
+	if v.limits.MaxSizeBytes > 0 && size > v.limits.MaxSizeBytes {
+		return &ValidationError{
+			Code:   RejectTooLarge,
+			Reason: fmt.Sprintf("o arquivo tem %s e excede o tamanho máximo permitido de %s", formatBytes(size), formatBytes(v.limits.MaxSizeBytes)),
+		}
+	}
+	return nil
+}
+
+// Validate identifica o contêiner pelos bytes iniciais e verifica codec,
+// duração e resolução via ffprobe. Retorna os metadados do vídeo aprovado.
+// Falhas do ffprobe que não são do vídeo (ResourceLimitError, binário
+// ausente) são retornadas sem ValidationError.
+func (v *InputValidator) Validate(videoPath string) (*VideoInfo, error) {
+	container, err := SniffContainer(videoPath)
+	if err != nil {
+		return nil, err
+	}
+	if container == "" {
+		return nil, &ValidationError{
+			Code:   RejectUnknownContainer,
+			Reason: "o arquivo enviado não é um vídeo em formato suportado (MP4, MOV, MKV, WebM, AVI, FLV, MPEG-TS ou WMV)",
+		}
+	}
+
+	info, err := ProbeVideoWithSandbox(videoPath, v.sandbox)
+	if err != nil {
+		// Limites do sandbox e ffprobe ausente não indicam vídeo corrompido
+		var limitErr *ResourceLimitError
+		if errors.As(err, &limitErr) || errors.Is(err, exec.ErrNotFound) {
+			return nil, err
+		}
+		return nil, &ValidationError{
+			Code:   RejectUnreadable,
+			Reason: "não foi possível ler o vídeo; o arquivo pode estar corrompido",
+			Err:    err,
+		}
+	}
+
+	if err := v.ValidateInfo(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ValidateInfo verifica os metadados obtidos via ffprobe contra os limites configurados
+func (v *InputValidator) ValidateInfo(info *VideoInfo) error {
+	if len(v.limits.AllowedCodecs) > 0 && !containsFold(v.limits.AllowedCodecs, info.Codec) {
+		return &ValidationError{
+			Code:   RejectCodecNotAllowed,
+			Reason: fmt.Sprintf("o codec de vídeo %q não é suportado", info.Codec),
+		}
+	}
+	if v.limits.MaxDuration > 0 && info.Duration > v.limits.MaxDuration {
+		return &ValidationError{
+			Code:   RejectDurationTooLong,
+			Reason: fmt.Sprintf("o vídeo tem %.0f segundos e excede a duração máxima de %.0f segundos", info.Duration, v.limits.MaxDuration),
+		}
+	}
+	if (v.limits.MaxWidth > 0 && info.Width > v.limits.MaxWidth) || (v.limits.MaxHeight > 0 && info.Height > v.limits.MaxHeight) {
+		return &ValidationError{
+			Code:   RejectResolutionTooHigh,
+			Reason: fmt.Sprintf("a resolução %dx%d excede %s", info.Width, info.Height, v.resolutionLimit()),
+		}
+	}
+	return nil
+}
+
+// resolutionLimit descreve apenas os limites de resolução configurados
+func (v *InputValidator) resolutionLimit() string {
+	switch {
+	case v.limits.MaxWidth > 0 && v.limits.MaxHeight > 0:
+		return fmt.Sprintf("o máximo permitido de %dx%d", v.limits.MaxWidth, v.limits.MaxHeight)
+	case v.limits.MaxWidth > 0:
+		return fmt.Sprintf("a largura máxima de %d pixels", v.limits.MaxWidth)
+	default:
+		return fmt.Sprintf("a altura máxima de %d pixels", v.limits.MaxHeight)
+	}
+}
+
+// SniffContainer identifica o contêiner do arquivo pelos bytes iniciais.
+// Retorna string vazia quando a assinatura não é reconhecida.
+func SniffContainer(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return detectContainer(header[:n]), nil
+}
+
+// detectContainer compara os bytes iniciais com as assinaturas conhecidas
+func detectContainer(header []byte) string {
+	switch {
+	case len(header) >= 8 && isISOBMFFBox(string(header[4:8])):
+		return ContainerMP4
+	case bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return ContainerMatroska
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "AVI ":
+		return ContainerAVI
+	case bytes.HasPrefix(header, []byte("FLV")):
+		return ContainerFLV
+	case bytes.HasPrefix(header, []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11}):
+		return ContainerASF
+	case isMPEGTS(header):
+		return ContainerMPEGTS
+	}
+	return ""
+}
+
+// isISOBMFFBox reconhece o primeiro box de arquivos MP4/MOV. Além do "ftyp",
+// arquivos QuickTime antigos podem começar direto com moov, mdat, free ou wide.
+func isISOBMFFBox(boxType string) bool {
+	switch boxType {
+	case "ftyp", "moov", "mdat", "free", "wide", "skip":
+		return true
+	}
+	return false
+}
+
+// isMPEGTS verifica o byte de sincronismo 0x47 no início de pacotes consecutivos
+func isMPEGTS(header []byte) bool {
+	if len(header) < 2*mpegTSPacketSize+1 {
+		return false
+	}
+	for offset := 0; offset < len(header); offset += mpegTSPacketSize {
+		if header[offset] != 0x47 {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold verifica se a lista contém o valor, ignorando maiúsculas
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBytes formata um tamanho em bytes para exibição ao usuário
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
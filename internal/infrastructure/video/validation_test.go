@@ -0,0 +1,213 @@
+package video
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDetectContainer(t *testing.T) {
+	ts := make([]byte, sniffLength)
+	for offset := 0; offset < len(ts); offset += mpegTSPacketSize {
+		ts[offset] = 0x47
+	}
+
+	testCases := []struct {
+		desc     string
+		header   []byte
+		expected string
+	}{
+		{"mp4 ftyp", []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), ContainerMP4},
+		{"mov moov", []byte("\x00\x00\x00\x08moov"), ContainerMP4},
+		{"matroska", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x01}, ContainerMatroska},
+		{"avi", []byte("RIFF\x10\x00\x00\x00AVI LIST"), ContainerAVI},
+		{"wav não é avi", []byte("RIFF\x10\x00\x00\x00WAVEfmt "), ""},
+		{"flv", []byte("FLV\x01\x05"), ContainerFLV},
+		{"asf", []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11, 0xA6}, ContainerASF},
+		{"mpeg-ts", ts, ContainerMPEGTS},
+		{"mpeg-ts curto", ts[:mpegTSPacketSize], ""},
+		{"pdf", []byte("%PDF-1.7"), ""},
+		{"vazio", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		if got := detectContainer(tc.header); got != tc.expected {
+			t.Errorf("Teste '%s': esperado '%s', obtido '%s'", tc.desc, tc.expected, got)
+		}
+	}
+}
+
+func TestSniffContainer(t *testing.T) {
+	outputDir := "temp_sniff"
+	os.MkdirAll(outputDir, 0755)
+	defer os.RemoveAll(outputDir)
+
+	mkv := filepath.Join(outputDir, "video.mkv")
+	os.WriteFile(mkv, []byte{0x1A, 0x45, 0xDF, 0xA3, 0x93, 0x42, 0x82, 0x88}, 0644)
+
+	container, err := SniffContainer(mkv)
+	if err != nil || container != ContainerMatroska {
+		t.Errorf("esperado '%s', obtido '%s' (err=%v)", ContainerMatroska, container, err)
+	}
+
+	if _, err := SniffContainer(filepath.Join(outputDir, "inexistente.mp4")); err == nil {
+		t.Errorf("esperado erro ao abrir arquivo inexistente")
+	}
+}
+
+func TestValidateSize(t *testing.T) {
+	validator := NewInputValidator(InputLimits{MaxSizeBytes: 1024 * 1024})
+
+	if err := validator.ValidateSize(1024); err != nil {
+		t.Errorf("esperado tamanho aceito, mas ocorreu erro: %v", err)
+	}
+
+	err := validator.ValidateSize(5 * 1024 * 1024)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code != RejectTooLarge {
+		t.Fatalf("esperado ValidationError %s, obtido %v", RejectTooLarge, err)
+	}
+	if validationErr.Error() != "o arquivo tem 5.0 MB e excede o tamanho máximo permitido de 1.0 MB" {
+		t.Errorf("mensagem inesperada: %s", validationErr.Error())
+	}
+
+	if err := NewInputValidator(InputLimits{}).ValidateSize(1 << 40); err != nil {
+		t.Errorf("esperado sem limite de tamanho quando MaxSizeBytes é zero")
+	}
+}
+
+func TestValidateInfo(t *testing.T) {
+	validator := NewInputValidator(InputLimits{
+		MaxDuration:   3600,
+		MaxWidth:      1920,
+		MaxHeight:     1080,
+		AllowedCodecs: []string{"h264", "HEVC"},
+	})
+
+	testCases := []struct {
+		desc     string
+		info     VideoInfo
+		expected string
+	}{
+		{"válido", VideoInfo{Codec: "h264", Duration: 60, Width: 1280, Height: 720}, ""},
+		{"codec case insensitive", VideoInfo{Codec: "hevc", Duration: 60, Width: 1280, Height: 720}, ""},
+		{"codec não permitido", VideoInfo{Codec: "theora", Duration: 60, Width: 1280, Height: 720}, RejectCodecNotAllowed},
+		{"duração excedida", VideoInfo{Codec: "h264", Duration: 7200, Width: 1280, Height: 720}, RejectDurationTooLong},
+		{"resolução excedida", VideoInfo{Codec: "h264", Duration: 60, Width: 3840, Height: 2160}, RejectResolutionTooHigh},
+	}
+
+	for _, tc := range testCases {
+		info := tc.info
+		err := validator.ValidateInfo(&info)
+		if tc.expected == "" {
+			if err != nil {
+				t.Errorf("Teste '%s': esperado sucesso, obtido %v", tc.desc, err)
+			}
+			continue
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Code != tc.expected {
+			t.Errorf("Teste '%s': esperado código %s, obtido %v", tc.desc, tc.expected, err)
+		}
+	}
+}
+
+func TestValidateUnknownContainer(t *testing.T) {
+	outputDir := "temp_validate"
+	os.MkdirAll(outputDir, 0755)
+	defer os.RemoveAll(outputDir)
+
+	fake := filepath.Join(outputDir, "video.mp4")
+	os.WriteFile(fake, []byte("isto não é um vídeo"), 0644)
+
+	info, err := NewInputValidator(InputLimits{}).Validate(fake)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code != RejectUnknownContainer {
+		t.Errorf("esperado rejeição %s, obtido %v", RejectUnknownContainer, err)
+	}
+	if info != nil {
+		t.Errorf("esperado info nil para vídeo rejeitado")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	testCases := map[int64]string{
+		512:             "512 B",
+		2048:            "2.0 KB",
+		3 * 1024 * 1024: "3.0 MB",
+		5 << 30:         "5.0 GB",
+	}
+
+	for input, expected := range testCases {
+		if got := formatBytes(input); got != expected {
+			t.Errorf("formatBytes(%d) = %s, esperado %s", input, got, expected)
+		}
+	}
+}
+
+// validateWithFakeProbe valida um arquivo com cabeçalho MP4 usando o
+// ffprobe simulado pelo script informado (vazio deixa o ffprobe ausente)
+func validateWithFakeProbe(t *testing.T, script string) error {
+	t.Helper()
+	binDir := t.TempDir()
+	if script != "" {
+		if err := os.WriteFile(filepath.Join(binDir, "ffprobe"), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+			t.Fatalf("erro ao criar ffprobe simulado: %v", err)
+		}
+	}
+	t.Setenv("PATH", binDir)
+
+	videoPath := filepath.Join(t.TempDir(), "video.mp4")
+	os.WriteFile(videoPath, []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), 0644)
+	sandbox := SandboxConfig{Enabled: true, CPUSeconds: 60}
+	_, err := NewInputValidatorWithSandbox(InputLimits{}, sandbox).Validate(videoPath)
+	return err
+}
+
+func TestValidateProbeFailures(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ffprobe simulado e sinais do sandbox exigem Linux")
+	}
+
+	// Vídeo ilegível: rejeição com a causa preservada para o log
+	err := validateWithFakeProbe(t, `echo "moov atom not found" >&2; exit 1`)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code != RejectUnreadable || validationErr.Err == nil {
+		t.Errorf("esperado %s com a causa, obtido %v", RejectUnreadable, err)
+	}
+
+	// Limite do sandbox: não é vídeo corrompido
+	err = validateWithFakeProbe(t, `kill -XCPU $$`)
+	var limitErr *ResourceLimitError
+	if !errors.As(err, &limitErr) || errors.As(err, &validationErr) {
+		t.Errorf("esperado ResourceLimitError sem rejeição do vídeo, obtido %v", err)
+	}
+
+	// ffprobe ausente: falha do worker, não do vídeo
+	err = validateWithFakeProbe(t, "")
+	if !errors.Is(err, exec.ErrNotFound) || errors.As(err, &validationErr) {
+		t.Errorf("esperado ffprobe ausente sem rejeição do vídeo, obtido %v", err)
+	}
+}
+
+func TestValidateInfoResolutionMessage(t *testing.T) {
+	info := &VideoInfo{Codec: "h264", Duration: 60, Width: 3840, Height: 2160}
+	testCases := []struct {
+		limits   InputLimits
+		expected string
+	}{
+		{InputLimits{MaxWidth: 1920, MaxHeight: 1080}, "a resolução 3840x2160 excede o máximo permitido de 1920x1080"},
+		{InputLimits{MaxWidth: 1920}, "a resolução 3840x2160 excede a largura máxima de 1920 pixels"},
+		{InputLimits{MaxHeight: 1080}, "a resolução 3840x2160 excede a altura máxima de 1080 pixels"},
+	}
+	for _, tc := range testCases {
+		err := NewInputValidator(tc.limits).ValidateInfo(info)
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("limites %+v: esperado %q, obtido %v", tc.limits, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package video
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Posições suportadas para a marca d'água
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// Valores padrão da marca d'água
+const (
+	DefaultWatermarkPosition = WatermarkBottomRight
+	DefaultWatermarkOpacity  = 0.5
+	DefaultWatermarkScale    = 0.15
+	DefaultWatermarkMargin   = 16
+)
+
+// textScaleDivisor converte a escala na altura da fonte da marca d'água em
+// texto: com a escala padrão, a imagem ocupa 15% da largura do frame e o
+// texto, cerca de 3,75% da altura, tamanhos visualmente próximos
+const textScaleDivisor = 4
+
+// Watermark descreve a sobreposição aplicada a cada frame extraído. Deve ser
+// informado ImagePath (imagem local, normalmente PNG com transparência) ou Text.
+type Watermark struct {
+	Name      string  // nome usado pela mensagem para selecioná-la; vazio na marca d'água padrão
+	ImagePath string  // caminho local da imagem da marca d'água
+	Text      string  // texto da marca d'água, usado quando ImagePath está vazio
+	FontFile  string  // fonte opcional para a marca d'água em texto
+	Position  string  // uma das constantes Watermark*
+	Opacity   float64 // 0 (transparente) a 1 (opaco)
+	Scale     float64 // largura da imagem relativa à largura do frame; no texto, a fonte tem Scale/4 da altura do frame
+	Margin    int     // distância em pixels da borda do frame
+}
+
+// Validate verifica se a configuração da marca d'água é aplicável
+func (w *Watermark) Validate() error {
+	if w.ImagePath == "" && w.Text == "" {
+		return fmt.Errorf("marca d'água sem imagem ou texto configurado")
+	}
+	switch w.Position {
+	case WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter:
+	default:
+		return fmt.Errorf("posição de marca d'água inválida: %s", w.Position)
+	}
+	if w.Opacity <= 0 || w.Opacity > 1 {
+		return fmt.Errorf("opacidade de marca d'água inválida: %v", w.Opacity)
+	}
+	if w.Scale <= 0 || w.Scale > 1 {
+		return fmt.Errorf("escala de marca d'água inválida: %v", w.Scale)
+	}
+	if w.Margin < 0 {
+		return fmt.Errorf("margem de marca d'água inválida: %d", w.Margin)
+	}
+	return nil
+}
+
+// imageFilter monta o trecho de filter_complex que redimensiona a imagem da
+// marca d'água (entrada 1) em relação ao frame e a sobrepõe ao vídeo de entrada
+func (w *Watermark) imageFilter(input, output string) string {
+	x, y := w.overlayPosition("main_w", "main_h", "overlay_w", "overlay_h")
+	return fmt.Sprintf(
+		"[1:v]format=rgba,colorchannelmixer=aa=%s[wmsrc];"+
+			"[wmsrc]%sscale2ref=w='main_w*%s':h='ow/a'[wm][wmbase];"+
+			"[wmbase][wm]overlay=x='%s':y='%s'%s",
+		formatFloat(w.Opacity), input, formatFloat(w.Scale), x, y, output,
+	)
+}
+
+// textFilter monta o filtro drawtext que escreve o conteúdo de textFile sobre o
+// frame, com fonte de Scale/textScaleDivisor da altura do frame
+func (w *Watermark) textFilter(textFile string) string {
+	x, y := w.overlayPosition("w", "h", "tw", "th")
+	filter := fmt.Sprintf(
+		"drawtext=textfile=%s:fontcolor=white@%s:shadowcolor=black@%s:shadowx=1:shadowy=1:fontsize='h*%s':x='%s':y='%s'",
+		escapeFilterValue(textFile), formatFloat(w.Opacity), formatFloat(w.Opacity),
+		formatFloat(w.Scale/textScaleDivisor), x, y,
+	)
+	if w.FontFile != "" {
+		filter += ":fontfile=" + escapeFilterValue(w.FontFile)
+	}
+	return filter
+}
+
+// overlayPosition retorna as expressões x/y de posicionamento usando os nomes
+// de variáveis do filtro em uso (overlay ou drawtext)
+func (w *Watermark) overlayPosition(mainW, mainH, itemW, itemH string) (string, string) {
+	margin := fmt.Sprintf("%d", w.Margin)
+	left := margin
+	right := fmt.Sprintf("%s-%s-%s", mainW, itemW, margin)
+	top := margin
+	bottom := fmt.Sprintf("%s-%s-%s", mainH, itemH, margin)
+
+	switch w.Position {
+	case WatermarkTopLeft:
+		return left, top
+	case WatermarkTopRight:
+		return right, top
+	case WatermarkBottomLeft:
+		return left, bottom
+	case WatermarkCenter:
+		return fmt.Sprintf("(%s-%s)/2", mainW, itemW), fmt.Sprintf("(%s-%s)/2", mainH, itemH)
+	default:
+		return right, bottom
+	}
+}
+
+// escapeFilterValue escapa um valor livre (ex.: caminho de arquivo) nos dois
+// níveis interpretados pelo ffmpeg: opção do filtro e descrição do filtergraph
+func escapeFilterValue(value string) string {
+	return escapeChars(escapeChars(value, `\':`), `\'[],;`)
+}
+
+// escapeChars prefixa com barra invertida cada ocorrência dos caracteres especiais
+func escapeChars(value, special string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// formatFloat formata números para expressões de filtro sem notação exponencial
+func formatFloat(value float64) string {
+	formatted := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", value), "0"), ".")
+	if formatted == "" || formatted == "-" {
+		return "0"
+	}
+	return formatted
+}
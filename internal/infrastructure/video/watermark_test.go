@@ -0,0 +1,127 @@
+package video
+
+import (
+	"strings"
+	"testing"
+)
+
+func validWatermark() *Watermark {
+	return &Watermark{
+		ImagePath: "watermark.png",
+		Position:  DefaultWatermarkPosition,
+		Opacity:   DefaultWatermarkOpacity,
+		Scale:     DefaultWatermarkScale,
+		Margin:    DefaultWatermarkMargin,
+	}
+}
+
+func TestWatermarkValidate(t *testing.T) {
+	if err := validWatermark().Validate(); err != nil {
+		t.Errorf("esperado marca d'água válida, mas ocorreu erro: %v", err)
+	}
+
+	testCases := []struct {
+		desc   string
+		mutate func(w *Watermark)
+	}{
+		{"sem imagem ou texto", func(w *Watermark) { w.ImagePath = "" }},
+		{"posição inválida", func(w *Watermark) { w.Position = "middle" }},
+		{"opacidade zero", func(w *Watermark) { w.Opacity = 0 }},
+		{"opacidade acima de 1", func(w *Watermark) { w.Opacity = 1.5 }},
+		{"escala zero", func(w *Watermark) { w.Scale = 0 }},
+		{"margem negativa", func(w *Watermark) { w.Margin = -1 }},
+	}
+
+	for _, tc := range testCases {
+		w := validWatermark()
+		tc.mutate(w)
+		if err := w.Validate(); err == nil {
+			t.Errorf("Teste '%s': esperado erro de validação", tc.desc)
+		}
+	}
+}
+
+func TestWatermarkOverlayPosition(t *testing.T) {
+	testCases := []struct {
+		position string
+		x, y     string
+	}{
+		{WatermarkTopLeft, "16", "16"},
+		{WatermarkTopRight, "main_w-overlay_w-16", "16"},
+		{WatermarkBottomLeft, "16", "main_h-overlay_h-16"},
+		{WatermarkBottomRight, "main_w-overlay_w-16", "main_h-overlay_h-16"},
+		{WatermarkCenter, "(main_w-overlay_w)/2", "(main_h-overlay_h)/2"},
+	}
+
+	for _, tc := range testCases {
+		w := validWatermark()
+		w.Position = tc.position
+		x, y := w.overlayPosition("main_w", "main_h", "overlay_w", "overlay_h")
+		if x != tc.x || y != tc.y {
+			t.Errorf("posição '%s': esperado (%s, %s), mas obteve (%s, %s)", tc.position, tc.x, tc.y, x, y)
+		}
+	}
+}
+
+func TestWatermarkImageFilter(t *testing.T) {
+	filter := validWatermark().imageFilter("[base]", "[out]")
+
+	expected := []string{"colorchannelmixer=aa=0.5", "[base]scale2ref=w='main_w*0.15'", "overlay=x='main_w-overlay_w-16'", "[out]"}
+	for _, part := range expected {
+		if !strings.Contains(filter, part) {
+			t.Errorf("esperado que o filtro contivesse '%s', mas obteve: %s", part, filter)
+		}
+	}
+}
+
+func TestWatermarkTextFilter(t *testing.T) {
+	w := validWatermark()
+	w.ImagePath = ""
+	w.Text = "Conteúdo pago"
+	w.FontFile = "/fonts/font.ttf"
+
+	filter := w.textFilter("/tmp/watermark.txt")
+	if !strings.HasPrefix(filter, "drawtext=textfile=/tmp/watermark.txt:") {
+		t.Errorf("filtro drawtext inesperado: %s", filter)
+	}
+	if !strings.Contains(filter, "fontcolor=white@0.5") || !strings.Contains(filter, ":fontfile=/fonts/font.ttf") {
+		t.Errorf("esperado cor e fonte no filtro drawtext, mas obteve: %s", filter)
+	}
+	// A fonte tem Scale/4 da altura do frame: 0.15 vira h*0.0375
+	if !strings.Contains(filter, ":fontsize='h*0.0375':") {
+		t.Errorf("esperado fonte proporcional à escala, mas obteve: %s", filter)
+	}
+}
+
+func TestEscapeFilterValue(t *testing.T) {
+	testCases := map[string]string{
+		"/tmp/file.txt":  "/tmp/file.txt",
+		"C:/file.txt":    `C\\:/file.txt`,
+		"it's":           `it\\\'s`,
+		"a,b;c[d]":       `a\,b\;c\[d\]`,
+		`back\slash.txt`: `back\\\\slash.txt`,
+	}
+
+	for input, expected := range testCases {
+		if got := escapeFilterValue(input); got != expected {
+			t.Errorf("escapeFilterValue(%q) = %q, esperado %q", input, got, expected)
+		}
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	testCases := map[float64]string{
+		0:      "0",
+		0.5:    "0.5",
+		0.15:   "0.15",
+		1:      "1",
+		10:     "10",
+		0.0375: "0.0375",
+	}
+
+	for input, expected := range testCases {
+		if got := formatFloat(input); got != expected {
+			t.Errorf("formatFloat(%v) = %s, esperado %s", input, got, expected)
+		}
+	}
+}